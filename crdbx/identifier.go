@@ -0,0 +1,36 @@
+// Copyright 2023 The Infratographer Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crdbx
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrInvalidTableName is returned when a table name is not a valid identifier.
+var ErrInvalidTableName = errors.New("invalid table name")
+
+var tableNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// ValidateTableName ensures the table name, optionally qualified with a schema, is a plain identifier.
+// Table names which pass validation are safe to format into statements, as they may not be passed as a parameter.
+func ValidateTableName(table string) error {
+	if !tableNameRegex.MatchString(table) {
+		return fmt.Errorf("%w: %q", ErrInvalidTableName, table)
+	}
+
+	return nil
+}
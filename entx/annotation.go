@@ -56,3 +56,22 @@ func EventsHookSubjectName(s string) *EventsHookAnnotation {
 }
 
 var _ schema.Annotation = EventsHookAnnotation{}
+
+// EventHooksConfigAnnotationName is the name of the annotation holding the event hooks generation config
+var EventHooksConfigAnnotationName = "INFRA9_EVENTHOOKS_CONFIG"
+
+// EventHooksConfig provides the global configuration used when generating event hooks.
+// This shouldn't be set directly, you should use WithEventHooksOutbox() instead
+type EventHooksConfig struct {
+	// Outbox writes change messages to the outbox table within the mutation transaction instead of publishing directly
+	Outbox bool
+	// OutboxTable is the name of the outbox table change messages are written to
+	OutboxTable string
+}
+
+// Name implements the ent Annotation interface.
+func (c EventHooksConfig) Name() string {
+	return EventHooksConfigAnnotationName
+}
+
+var _ schema.Annotation = EventHooksConfig{}
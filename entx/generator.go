@@ -20,6 +20,10 @@ import (
 	"entgo.io/ent/entc/gen"
 )
 
+// DefaultOutboxTable is the default name of the outbox table event hooks write change messages to.
+// It matches the outbox.DefaultTable of go.infratographer.com/x/events/outbox, which the generated hooks import.
+const DefaultOutboxTable = "event_outbox"
+
 // Extension is an implementation of entc.Extension that adds all the templates
 // that entx needs.
 type Extension struct {
@@ -28,6 +32,8 @@ type Extension struct {
 	templates []*gen.Template
 
 	gqlSchemaHooks []entgql.SchemaHook
+
+	annotations []entc.Annotation
 }
 
 // ExtensionOption allow for control over the behavior of the generator
@@ -61,12 +67,32 @@ func WithEventHooks() ExtensionOption {
 	}
 }
 
+// WithEventHooksOutbox configures the generated event hooks to write change messages to the outbox table
+// within the mutation transaction instead of publishing them directly, an outbox.Relay is then responsible
+// for publishing the messages. If table is empty DefaultOutboxTable is used.
+// This must be used with WithEventHooks and requires the ent sql/execquery feature to be enabled.
+func WithEventHooksOutbox(table string) ExtensionOption {
+	return func(ex *Extension) error {
+		if table == "" {
+			table = DefaultOutboxTable
+		}
+
+		ex.annotations = append(ex.annotations, EventHooksConfig{
+			Outbox:      true,
+			OutboxTable: table,
+		})
+
+		return nil
+	}
+}
+
 // NewExtension returns an entc Extension that allows the entx package to generate
 // the schema changes and templates needed to function
 func NewExtension(opts ...ExtensionOption) (*Extension, error) {
 	e := &Extension{
 		templates:      []*gen.Template{},
 		gqlSchemaHooks: []entgql.SchemaHook{},
+		annotations:    []entc.Annotation{},
 	}
 
 	for _, opt := range opts {
@@ -83,6 +109,11 @@ func (e *Extension) Templates() []*gen.Template {
 	return e.templates
 }
 
+// Annotations of the extension
+func (e *Extension) Annotations() []entc.Annotation {
+	return e.annotations
+}
+
 // GQLSchemaHooks of the extension to seamlessly edit the final gql interface.
 func (e *Extension) GQLSchemaHooks() []entgql.SchemaHook {
 	return e.gqlSchemaHooks
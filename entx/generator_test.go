@@ -0,0 +1,152 @@
+// Copyright 2023 The Infratographer Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entx_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"entgo.io/ent"
+	"entgo.io/ent/entc/gen"
+	"entgo.io/ent/entc/load"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/field"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/entx"
+	"go.infratographer.com/x/events/outbox"
+	"go.infratographer.com/x/gidx"
+)
+
+type Widget struct {
+	ent.Schema
+}
+
+func (Widget) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").GoType(gidx.PrefixedID("")),
+		field.String("name"),
+	}
+}
+
+func (Widget) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entx.EventsHookSubjectName("widget"),
+	}
+}
+
+// generate runs the ent code generation for the Widget schema with the extension, returning the target directory.
+func generate(t *testing.T, opts ...entx.ExtensionOption) string {
+	t.Helper()
+
+	ext, err := entx.NewExtension(opts...)
+	require.NoError(t, err)
+
+	buf, err := load.MarshalSchema(Widget{})
+	require.NoError(t, err)
+
+	widget, err := load.UnmarshalSchema(buf)
+	require.NoError(t, err)
+
+	storage, err := gen.NewStorage("sql")
+	require.NoError(t, err)
+
+	cfg := &gen.Config{
+		Target:      t.TempDir(),
+		Package:     "example.com/app/internal/ent/generated",
+		Storage:     storage,
+		Templates:   ext.Templates(),
+		Features:    []gen.Feature{gen.FeatureExecQuery},
+		Annotations: gen.Annotations{},
+	}
+
+	for _, annotation := range ext.Annotations() {
+		cfg.Annotations[annotation.Name()] = annotation
+	}
+
+	graph, err := gen.NewGraph(cfg, widget)
+	require.NoError(t, err)
+	require.NoError(t, graph.Gen())
+
+	return cfg.Target
+}
+
+func readGenerated(t *testing.T, target string, path ...string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(append([]string{target}, path...)...))
+	require.NoError(t, err)
+
+	return string(content)
+}
+
+func TestEventHooksOutbox(t *testing.T) {
+	testCases := []struct {
+		name  string
+		table string
+		want  string
+	}{
+		{
+			name: "default table",
+			want: outbox.DefaultTable,
+		},
+		{
+			name:  "custom table",
+			table: "widget_outbox",
+			want:  "widget_outbox",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := generate(t, entx.WithEventHooks(), entx.WithEventHooksOutbox(tc.table))
+
+			hooks := readGenerated(t, target, "eventhooks", "hooks.go")
+
+			assert.Contains(t, hooks, `"go.infratographer.com/x/events/outbox"`)
+			assert.NotContains(t, hooks, "EventsPublisher.PublishChange")
+
+			// the change is written with the mutation, once by the create and update hook and once by the delete hook.
+			write := `outbox.WriteChange(ctx, m, "` + tc.want + `", "widget", msg)`
+			require.Equal(t, 2, strings.Count(hooks, write))
+
+			// the change is written after the mutation is executed, before the hook returns.
+			for _, hook := range strings.Split(hooks, "next.Mutate(ctx, m)")[1:] {
+				assert.Less(t, strings.Index(hook, write), strings.Index(hook, "return retValue, nil"))
+			}
+
+			// mutations execute with the driver of the transaction they are run in, which runs the outbox insert.
+			assert.Contains(t, readGenerated(t, target, "mutation.go"), "type WidgetMutation struct {\n\tconfig\n")
+			assert.Contains(t, readGenerated(t, target, "client.go"), "func (c *config) ExecContext(ctx context.Context, query string, args ...any) (stdsql.Result, error)")
+			assert.Contains(t, readGenerated(t, target, "tx.go"), "func (tx *txDriver) ExecContext(ctx context.Context, query string, args ...any) (stdsql.Result, error)")
+		})
+	}
+}
+
+func TestEventHooksPublish(t *testing.T) {
+	target := generate(t, entx.WithEventHooks())
+
+	hooks := readGenerated(t, target, "eventhooks", "hooks.go")
+
+	assert.NotContains(t, hooks, "outbox")
+	assert.Equal(t, 2, strings.Count(hooks, `m.EventsPublisher.PublishChange(ctx, "widget", msg)`))
+}
+
+func TestDefaultOutboxTable(t *testing.T) {
+	assert.Equal(t, outbox.DefaultTable, entx.DefaultOutboxTable)
+}
@@ -0,0 +1,356 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.infratographer.com/x/gidx"
+)
+
+const (
+	// EncodingJSON encodes messages as the message struct json, this is the default encoding.
+	EncodingJSON = "json"
+	// EncodingCloudEventsBinary encodes messages as CloudEvents in binary content mode.
+	// The message struct json is kept as the payload and the CloudEvents attributes are provided as ce- prefixed headers.
+	// Consumers which are not CloudEvents aware are still able to decode the payload.
+	EncodingCloudEventsBinary = "cloudevents-binary"
+	// EncodingCloudEventsStructured encodes messages as CloudEvents in structured content mode.
+	// The payload is a CloudEvents json envelope with the message struct json as the event data, and the Content-Type
+	// header is set to application/cloudevents+json, which is how consumers detect the envelope.
+	// Consumers built with versions of this package which predate CloudEvents support decode the envelope as the message
+	// struct, returning messages with mostly zero value fields without any error. Only use the structured encoding once
+	// every consumer of the topic supports CloudEvents, otherwise use the binary encoding.
+	EncodingCloudEventsStructured = "cloudevents-structured"
+
+	// CloudEventsSpecVersion is the CloudEvents specification version messages are encoded with.
+	CloudEventsSpecVersion = "1.0"
+
+	// CloudEventsHeaderPrefix is the prefix of headers containing CloudEvents attributes in binary content mode.
+	CloudEventsHeaderPrefix = "ce-"
+
+	// HeaderContentType is the header containing the content type of the payload.
+	HeaderContentType = "Content-Type"
+
+	contentTypeJSON                 = "application/json"
+	contentTypeCloudEventsJSON      = "application/cloudevents+json"
+	cloudEventsAttrSpecVersion      = "specversion"
+	cloudEventsAttrID               = "id"
+	cloudEventsAttrSource           = "source"
+	cloudEventsAttrType             = "type"
+	cloudEventsAttrSubject          = "subject"
+	cloudEventsAttrTime             = "time"
+	cloudEventsAttrDataContentType  = "datacontenttype"
+	cloudEventsAttrTraceParent      = "traceparent"
+	cloudEventsAttrTraceState       = "tracestate"
+	cloudEventsAttrActorID          = "actorid"
+	cloudEventsAttrData             = "data"
+	cloudEventsTraceContextParent   = "traceparent"
+	cloudEventsTraceContextState    = "tracestate"
+	cloudEventsDefaultSourceUnknown = "unknown"
+)
+
+// cloudEvent holds the CloudEvents context attributes for a message.
+type cloudEvent struct {
+	attributes map[string]string
+}
+
+// newCloudEvent builds the CloudEvents attributes for the provided message, source is used if the message does not define one.
+// contentType is the content type of the message data.
+func newCloudEvent(message any, id, source, contentType string) cloudEvent {
+	ce := cloudEvent{
+		attributes: map[string]string{
+			cloudEventsAttrSpecVersion:     CloudEventsSpecVersion,
+			cloudEventsAttrID:              id,
+			cloudEventsAttrSource:          source,
+			cloudEventsAttrDataContentType: contentType,
+		},
+	}
+
+	var (
+		eventType    string
+		subjectID    gidx.PrefixedID
+		msgSource    string
+		timestamp    time.Time
+		traceContext map[string]string
+	)
+
+	switch m := message.(type) {
+	case ChangeMessage:
+		eventType, subjectID, msgSource, timestamp, traceContext = m.EventType, m.SubjectID, m.Source, m.Timestamp, m.TraceContext
+
+		if m.ActorID != "" {
+			ce.attributes[cloudEventsAttrActorID] = m.ActorID.String()
+		}
+	case EventMessage:
+		eventType, subjectID, msgSource, timestamp, traceContext = m.EventType, m.SubjectID, m.Source, m.Timestamp, m.TraceContext
+	case AuthRelationshipRequest:
+		eventType, subjectID, traceContext = string(m.Action), m.ObjectID, m.TraceContext
+	case AuthRelationshipResponse:
+		traceContext = m.TraceContext
+	}
+
+	// type is a required attribute.
+	ce.attributes[cloudEventsAttrType] = fmt.Sprintf("%T", message)
+
+	if eventType != "" {
+		ce.attributes[cloudEventsAttrType] = eventType
+	}
+
+	if subjectID != "" {
+		ce.attributes[cloudEventsAttrSubject] = subjectID.String()
+	}
+
+	if msgSource != "" {
+		ce.attributes[cloudEventsAttrSource] = msgSource
+	}
+
+	if ce.attributes[cloudEventsAttrSource] == "" {
+		ce.attributes[cloudEventsAttrSource] = cloudEventsDefaultSourceUnknown
+	}
+
+	if !timestamp.IsZero() {
+		ce.attributes[cloudEventsAttrTime] = timestamp.UTC().Format(time.RFC3339Nano)
+	}
+
+	if v := traceContext[cloudEventsTraceContextParent]; v != "" {
+		ce.attributes[cloudEventsAttrTraceParent] = v
+	}
+
+	if v := traceContext[cloudEventsTraceContextState]; v != "" {
+		ce.attributes[cloudEventsAttrTraceState] = v
+	}
+
+	return ce
+}
+
+// headers returns the attributes as binary content mode headers.
+func (ce cloudEvent) headers() map[string]string {
+	headers := make(map[string]string, len(ce.attributes))
+
+	for key, value := range ce.attributes {
+		if key == cloudEventsAttrDataContentType {
+			headers[HeaderContentType] = value
+
+			continue
+		}
+
+		headers[CloudEventsHeaderPrefix+key] = value
+	}
+
+	return headers
+}
+
+// structured wraps data in a structured content mode envelope.
+func (ce cloudEvent) structured(data []byte) ([]byte, error) {
+	envelope := make(map[string]json.RawMessage, len(ce.attributes)+1)
+
+	for key, value := range ce.attributes {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		envelope[key] = encoded
+	}
+
+	envelope[cloudEventsAttrData] = data
+
+	return json.Marshal(envelope)
+}
+
+// cloudEventFromHeaders loads binary content mode attributes, returning false if the headers are not a CloudEvent.
+func cloudEventFromHeaders(get func(key string) string, keys []string) (cloudEvent, bool) {
+	if get(CloudEventsHeaderPrefix+cloudEventsAttrSpecVersion) == "" {
+		return cloudEvent{}, false
+	}
+
+	ce := cloudEvent{attributes: map[string]string{}}
+
+	for _, key := range keys {
+		if len(key) > len(CloudEventsHeaderPrefix) && strings.EqualFold(key[:len(CloudEventsHeaderPrefix)], CloudEventsHeaderPrefix) {
+			ce.attributes[strings.ToLower(key[len(CloudEventsHeaderPrefix):])] = get(key)
+		}
+	}
+
+	return ce, true
+}
+
+// cloudEventFromStructured unwraps a structured content mode envelope returning the attributes and event data.
+func cloudEventFromStructured(payload []byte) (cloudEvent, []byte, error) {
+	var envelope map[string]json.RawMessage
+
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return cloudEvent{}, nil, err
+	}
+
+	ce := cloudEvent{attributes: map[string]string{}}
+
+	for key, value := range envelope {
+		if key == cloudEventsAttrData {
+			continue
+		}
+
+		var attr string
+
+		if err := json.Unmarshal(value, &attr); err != nil {
+			// non string extension attributes are kept in their json form.
+			attr = string(value)
+		}
+
+		ce.attributes[key] = attr
+	}
+
+	return ce, envelope[cloudEventsAttrData], nil
+}
+
+// validate ensures the CloudEvent is a supported version.
+func (ce cloudEvent) validate() error {
+	if version := ce.attributes[cloudEventsAttrSpecVersion]; version != CloudEventsSpecVersion {
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidCloudEvent, version)
+	}
+
+	return nil
+}
+
+// apply fills fields on the decoded message which were not provided in the event data from the CloudEvents attributes.
+func (ce cloudEvent) apply(message any) {
+	subjectID := gidx.PrefixedID(ce.attributes[cloudEventsAttrSubject])
+	eventType := ce.attributes[cloudEventsAttrType]
+	source := ce.attributes[cloudEventsAttrSource]
+	timestamp, _ := time.Parse(time.RFC3339Nano, ce.attributes[cloudEventsAttrTime])
+
+	switch m := message.(type) {
+	case *ChangeMessage:
+		setDefault(&m.SubjectID, subjectID)
+		setDefault(&m.EventType, eventType)
+		setDefault(&m.Source, source)
+		setDefault(&m.ActorID, gidx.PrefixedID(ce.attributes[cloudEventsAttrActorID]))
+		setDefault(&m.Timestamp, timestamp)
+		m.TraceContext = ce.traceContext(m.TraceContext)
+	case *EventMessage:
+		setDefault(&m.SubjectID, subjectID)
+		setDefault(&m.EventType, eventType)
+		setDefault(&m.Source, source)
+		setDefault(&m.Timestamp, timestamp)
+		m.TraceContext = ce.traceContext(m.TraceContext)
+	case *AuthRelationshipRequest:
+		setDefault(&m.ObjectID, subjectID)
+		m.TraceContext = ce.traceContext(m.TraceContext)
+	case *AuthRelationshipResponse:
+		m.TraceContext = ce.traceContext(m.TraceContext)
+	}
+}
+
+// traceContext merges the distributed tracing extension attributes into the trace context if none was provided.
+func (ce cloudEvent) traceContext(traceContext map[string]string) map[string]string {
+	if len(traceContext) != 0 || ce.attributes[cloudEventsAttrTraceParent] == "" {
+		return traceContext
+	}
+
+	traceContext = map[string]string{
+		cloudEventsTraceContextParent: ce.attributes[cloudEventsAttrTraceParent],
+	}
+
+	if state := ce.attributes[cloudEventsAttrTraceState]; state != "" {
+		traceContext[cloudEventsTraceContextState] = state
+	}
+
+	return traceContext
+}
+
+func setDefault[T comparable](field *T, value T) {
+	var zero T
+
+	if *field == zero {
+		*field = value
+	}
+}
+
+// encodePayload encodes the message data, marshaled with codec, with the provided encoding returning the payload and any headers to set.
+// id is the unique id of the message, used as the CloudEvents id.
+func encodePayload(encoding string, codec Codec, id, source string, message any, data []byte) ([]byte, map[string]string, error) {
+	switch encoding {
+	case "", EncodingJSON:
+		return data, nil, nil
+	case EncodingCloudEventsBinary:
+		return data, newCloudEvent(message, id, source, codec.ContentType()).headers(), nil
+	case EncodingCloudEventsStructured:
+		payload, err := newCloudEvent(message, id, source, codec.ContentType()).structured(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return payload, map[string]string{HeaderContentType: contentTypeCloudEventsJSON}, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// decodePayload decodes the payload into message, the encoding is detected from the headers so json and both CloudEvents
+// content modes are accepted.
+// The payload is decoded with the codec declared in the HeaderCodec header, defaulting to json.
+// get and keys provide access to the message headers, providers without header support should pass a get func returning an empty string.
+// When a schema is provided, the payload is upcast to the current version and validated before being returned.
+// Payloads which fail validation are still decoded into message.
+func decodePayload(get func(key string) string, keys []string, payload []byte, message any, schema *messageSchema) error {
+	codec, err := codecByName(get(HeaderCodec))
+	if err != nil {
+		return err
+	}
+
+	ce, ok := cloudEventFromHeaders(get, keys)
+
+	// structured CloudEvents are always json and are only detected by their content type, json payloads which look
+	// like an envelope are decoded as is.
+	if !ok && isJSONCodec(codec.Name()) && strings.HasPrefix(get(HeaderContentType), contentTypeCloudEventsJSON) {
+		ce, payload, err = cloudEventFromStructured(payload)
+		if err != nil {
+			return err
+		}
+
+		ok = true
+	}
+
+	if ok {
+		if err := ce.validate(); err != nil {
+			return err
+		}
+
+		if dataType := ce.attributes[cloudEventsAttrDataContentType]; dataType != "" && !strings.HasPrefix(dataType, codec.ContentType()) {
+			return fmt.Errorf("%w: unsupported datacontenttype %q", ErrInvalidCloudEvent, dataType)
+		}
+	}
+
+	var schemaErr error
+
+	if schema != nil && len(payload) != 0 {
+		// schemas and upcasters operate on json.
+		payload, err = jsonPayload(codec, payload)
+		if err != nil {
+			return err
+		}
+
+		codec = jsonCodec{}
+
+		payload, err = schema.upcast(get(HeaderSchemaVersion), payload)
+		if err != nil {
+			return err
+		}
+
+		schemaErr = schema.validateConsume(payload)
+	}
+
+	if len(payload) != 0 {
+		if err := codec.Unmarshal(payload, message); err != nil {
+			return err
+		}
+	}
+
+	if ok {
+		ce.apply(message)
+	}
+
+	return schemaErr
+}
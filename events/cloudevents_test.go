@@ -0,0 +1,201 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	nc "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestNATSCloudEvents(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name        string
+		encoding    string
+		contentType string
+		checkFn     func(t *testing.T, change events.ChangeMessage, nMsg *nc.Msg)
+	}{
+		{
+			name:        "binary",
+			encoding:    events.EncodingCloudEventsBinary,
+			contentType: "application/json",
+			checkFn: func(t *testing.T, change events.ChangeMessage, nMsg *nc.Msg) {
+				assert.Equal(t, events.CloudEventsSpecVersion, nMsg.Header.Get("ce-specversion"))
+				assert.NotEmpty(t, nMsg.Header.Get("ce-id"))
+				assert.Equal(t, change.EventType, nMsg.Header.Get("ce-type"))
+				assert.Equal(t, change.SubjectID.String(), nMsg.Header.Get("ce-subject"))
+				assert.Equal(t, change.ActorID.String(), nMsg.Header.Get("ce-actorid"))
+				assert.Equal(t, "testing-cloudevents", nMsg.Header.Get("ce-source"))
+
+				// payload remains decodable by consumers which are not cloudevents aware.
+				var legacy events.ChangeMessage
+
+				require.NoError(t, json.Unmarshal(nMsg.Data, &legacy))
+				assert.Equal(t, change.SubjectID, legacy.SubjectID)
+			},
+		},
+		{
+			name:        "structured",
+			encoding:    events.EncodingCloudEventsStructured,
+			contentType: "application/cloudevents+json",
+			checkFn: func(t *testing.T, change events.ChangeMessage, nMsg *nc.Msg) {
+				var envelope struct {
+					SpecVersion string               `json:"specversion"`
+					Type        string               `json:"type"`
+					Subject     string               `json:"subject"`
+					Source      string               `json:"source"`
+					Data        events.ChangeMessage `json:"data"`
+				}
+
+				require.NoError(t, json.Unmarshal(nMsg.Data, &envelope))
+				assert.Equal(t, events.CloudEventsSpecVersion, envelope.SpecVersion)
+				assert.Equal(t, change.EventType, envelope.Type)
+				assert.Equal(t, change.SubjectID.String(), envelope.Subject)
+				assert.Equal(t, "testing-cloudevents", envelope.Source)
+				assert.Equal(t, change.SubjectID, envelope.Data.SubjectID)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nats, err := eventtools.NewNatsServer()
+			require.NoError(t, err)
+
+			defer nats.Close()
+
+			natsCfg := nats.Config.NATS
+			natsCfg.Source = "testing-cloudevents"
+			natsCfg.Encoding = tc.encoding
+
+			conn, err := events.NewNATSConnection(natsCfg)
+			require.NoError(t, err)
+
+			defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+			// legacy consumers decode messages regardless of the publisher encoding.
+			legacyCfg := nats.Config.NATS
+			legacyCfg.Encoding = events.EncodingJSON
+
+			legacyConn, err := events.NewNATSConnection(legacyCfg)
+			require.NoError(t, err)
+
+			defer legacyConn.Shutdown(ctx) //nolint:errcheck // within test
+
+			change := testCreateChange()
+
+			msg, err := conn.PublishChange(ctx, "test", change)
+			require.NoError(t, err)
+
+			messages, err := legacyConn.SubscribeChanges(ctx, ">")
+			require.NoError(t, err)
+
+			receivedMsg, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, receivedMsg.Error())
+			assert.EqualValues(t, msg.Message(), receivedMsg.Message())
+
+			jMsg, ok := receivedMsg.Source().(jetstream.Msg)
+			require.True(t, ok)
+
+			nMsg := &nc.Msg{Subject: jMsg.Subject(), Header: jMsg.Headers(), Data: jMsg.Data()}
+
+			assert.Equal(t, tc.contentType, nMsg.Header.Get(events.HeaderContentType))
+
+			tc.checkFn(t, msg.Message(), nMsg)
+
+			require.NoError(t, receivedMsg.Ack())
+		})
+	}
+}
+
+func TestNATSCloudEventsForeignEvent(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	conn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeEvents(ctx, ">")
+	require.NoError(t, err)
+
+	subjectID := gidx.MustNewID("testing")
+
+	js, err := conn.Source().(*nc.Conn).JetStream()
+	require.NoError(t, err)
+
+	// events published by other cloudevents producers only define the standard attributes.
+	nMsg := nc.NewMsg(eventtools.Prefix + ".events.ping.test")
+	nMsg.Header.Set("ce-specversion", "1.0")
+	nMsg.Header.Set("ce-id", "abc-123")
+	nMsg.Header.Set("ce-source", "external-service")
+	nMsg.Header.Set("ce-type", "ping")
+	nMsg.Header.Set("ce-subject", subjectID.String())
+	nMsg.Header.Set("ce-time", "2024-01-02T03:04:05Z")
+	nMsg.Header.Set("ce-traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	nMsg.Header.Set(events.HeaderContentType, "application/json")
+	nMsg.Data = []byte(`{"data":{"field":"value"}}`)
+
+	_, err = js.PublishMsg(nMsg)
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, receivedMsg.Error())
+
+	event := receivedMsg.Message()
+
+	assert.Equal(t, subjectID, event.SubjectID)
+	assert.Equal(t, "ping", event.EventType)
+	assert.Equal(t, "external-service", event.Source)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), event.Timestamp)
+	assert.Equal(t, map[string]any{"field": "value"}, event.Data)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", event.TraceContext["traceparent"])
+
+	require.NoError(t, receivedMsg.Ack())
+
+	// structured envelopes are only detected by their content type, other payloads are decoded as the message.
+	nMsg = nc.NewMsg(eventtools.Prefix + ".events.ping.test")
+	nMsg.Data = []byte(`{"specversion":"1.0","type":"ping","data":{"field":"value"}}`)
+
+	_, err = js.PublishMsg(nMsg)
+	require.NoError(t, err)
+
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, receivedMsg.Error())
+	assert.Empty(t, receivedMsg.Message().EventType)
+	assert.Equal(t, map[string]any{"field": "value"}, receivedMsg.Message().Data)
+
+	require.NoError(t, receivedMsg.Ack())
+
+	// unsupported versions are surfaced as errors.
+	nMsg = nc.NewMsg(eventtools.Prefix + ".events.ping.test")
+	nMsg.Header.Set("ce-specversion", "0.3")
+	nMsg.Data = []byte(`{}`)
+
+	_, err = js.PublishMsg(nMsg)
+	require.NoError(t, err)
+
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.ErrorIs(t, receivedMsg.Error(), events.ErrInvalidCloudEvent)
+
+	require.NoError(t, receivedMsg.Ack())
+}
@@ -0,0 +1,225 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// RegisterCobraCommand adds an events command to the cobra command provided for inspecting, replaying and publishing messages.
+// configFunc is called when a subcommand runs and returns the nats configuration to connect with,
+// topics and subjects provided to the subcommands are relative to the configured prefixes.
+//
+// Commands:
+//
+//	events tail <topic>                     Print messages as they're published, such as changes.update.loadbalancer
+//	events replay <topic>                   Republish a range of stored messages, optionally to a different subject
+//	events publish <changes|events> <topic> Publish a change or event message read from a json file
+func RegisterCobraCommand(cmd *cobra.Command, configFunc func() NATSConfig, options ...NATSOption) {
+	eventsCmd := &cobra.Command{
+		Use:   "events",
+		Short: "Inspect, replay and publish event messages",
+	}
+
+	connect := func() (*NATSConnection, error) {
+		return NewNATSConnection(configFunc(), options...)
+	}
+
+	eventsCmd.AddCommand(
+		newTailCommand(connect),
+		newReplayCommand(connect),
+		newPublishCommand(connect),
+	)
+
+	cmd.AddCommand(eventsCmd)
+}
+
+func newTailCommand(connect func() (*NATSConnection, error)) *cobra.Command {
+	var (
+		since   string
+		fromSeq uint64
+		count   int
+		headers bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tail <topic>",
+		Short: "Print messages published to the topic",
+		Long: `Tail prints the messages published to the topic, such as changes.update.loadbalancer or events.>.
+Change and event messages are decoded and pretty-printed regardless of the encoding they were published with.
+Only new messages are printed unless --since or --from-seq is provided.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start := natsStreamRange{FromSequence: fromSeq}
+
+			if err := parseCommandTime(since, &start.Since); err != nil {
+				return err
+			}
+
+			return withCommandConnection(cmd.Context(), connect, func(ctx context.Context, conn *NATSConnection) error {
+				return conn.tail(ctx, args[0], start, count, headers, cmd.OutOrStdout())
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "print messages stored since a time (RFC3339) or duration ago (1h)")
+	cmd.Flags().Uint64Var(&fromSeq, "from-seq", 0, "print messages starting at the stream sequence")
+	cmd.Flags().IntVar(&count, "count", 0, "exit after printing the number of messages, zero prints until interrupted")
+	cmd.Flags().BoolVar(&headers, "headers", false, "print message headers")
+
+	return cmd
+}
+
+func newReplayCommand(connect func() (*NATSConnection, error)) *cobra.Command {
+	var (
+		replayRange  natsStreamRange
+		since, until string
+		target       string
+		limit        float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay <topic>",
+		Short: "Republish stored messages on the topic",
+		Long: `Replay republishes the stored messages on the topic, selected by stream sequence or time.
+Messages are republished to their original subject so existing consumers process them again,
+or to --target to process them with a separate consumer.
+Without an end of the range, messages stored up to when the replay started are replayed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := parseCommandTime(since, &replayRange.Since); err != nil {
+				return err
+			}
+
+			if err := parseCommandTime(until, &replayRange.Until); err != nil {
+				return err
+			}
+
+			return withCommandConnection(cmd.Context(), connect, func(ctx context.Context, conn *NATSConnection) error {
+				replayed, err := conn.replay(ctx, args[0], replayRange, target, limit)
+
+				fmt.Fprintf(cmd.OutOrStdout(), "replayed %d messages\n", replayed)
+
+				return err
+			})
+		},
+	}
+
+	cmd.Flags().Uint64Var(&replayRange.FromSequence, "from-seq", 0, "first stream sequence to replay")
+	cmd.Flags().Uint64Var(&replayRange.ToSequence, "to-seq", 0, "last stream sequence to replay")
+	cmd.Flags().StringVar(&since, "since", "", "replay messages stored since a time (RFC3339) or duration ago (1h)")
+	cmd.Flags().StringVar(&until, "until", "", "replay messages stored until a time (RFC3339) or duration ago (1h)")
+	cmd.Flags().StringVar(&target, "target", "", "subject to republish messages to, defaults to the original subject")
+	cmd.Flags().Float64Var(&limit, "rate", 0, "max messages replayed per second, zero is unlimited")
+
+	return cmd
+}
+
+func newPublishCommand(connect func() (*NATSConnection, error)) *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "publish <changes|events> <topic>",
+		Short: "Publish a change or event message read from a json file",
+		Args:  cobra.ExactArgs(2), //nolint:mnd // kind and topic
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := readCommandFile(cmd.InOrStdin(), file)
+			if err != nil {
+				return err
+			}
+
+			return withCommandConnection(cmd.Context(), connect, func(ctx context.Context, conn *NATSConnection) error {
+				var id string
+
+				switch args[0] {
+				case "changes":
+					var change ChangeMessage
+
+					if err := json.Unmarshal(data, &change); err != nil {
+						return err
+					}
+
+					msg, err := conn.PublishChange(ctx, args[1], change)
+					if err != nil {
+						return err
+					}
+
+					id = msg.ID()
+				case "events":
+					var event EventMessage
+
+					if err := json.Unmarshal(data, &event); err != nil {
+						return err
+					}
+
+					msg, err := conn.PublishEvent(ctx, args[1], event)
+					if err != nil {
+						return err
+					}
+
+					id = msg.ID()
+				default:
+					return fmt.Errorf("%w: message kind %q, expected changes or events", ErrInvalidCommandArgument, args[0])
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "published message %s\n", id)
+
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "-", "json file containing the message, - reads from stdin")
+
+	return cmd
+}
+
+func withCommandConnection(ctx context.Context, connect func() (*NATSConnection, error), fn func(ctx context.Context, conn *NATSConnection) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	conn, err := connect()
+	if err != nil {
+		return err
+	}
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // connection is closed on exit
+
+	return fn(ctx, conn)
+}
+
+// parseCommandTime parses an RFC3339 time or a duration before now into t, an empty value leaves t unchanged.
+func parseCommandTime(value string, t *time.Time) error {
+	if value == "" {
+		return nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		*t = parsed
+
+		return nil
+	}
+
+	ago, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%w: %q is not an RFC3339 time or duration", ErrInvalidCommandArgument, value)
+	}
+
+	*t = time.Now().Add(-ago)
+
+	return nil
+}
+
+func readCommandFile(stdin io.Reader, file string) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(stdin)
+	}
+
+	return os.ReadFile(file)
+}
@@ -0,0 +1,82 @@
+package events_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestRegisterCobraCommand(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+	defer cancel()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	runCommand := func(t *testing.T, stdin string, args ...string) (string, error) {
+		t.Helper()
+
+		root := &cobra.Command{Use: "test"}
+
+		events.RegisterCobraCommand(root, func() events.NATSConfig { return nats.Config.NATS })
+
+		var out bytes.Buffer
+
+		root.SetArgs(append([]string{"events"}, args...))
+		root.SetIn(strings.NewReader(stdin))
+		root.SetOut(&out)
+
+		err := root.ExecuteContext(ctx)
+
+		return out.String(), err
+	}
+
+	change := `{"subjectID": "loadbal-abc123", "eventType": "create"}`
+
+	out, err := runCommand(t, change, "publish", "changes", "loadbalancer")
+	require.NoError(t, err)
+	assert.Contains(t, out, "published message "+events.MessageIDPrefix)
+
+	out, err = runCommand(t, change, "publish", "changes", "loadbalancer")
+	require.NoError(t, err)
+	assert.Contains(t, out, "published message ")
+
+	_, err = runCommand(t, change, "publish", "unknown", "loadbalancer")
+	require.ErrorIs(t, err, events.ErrInvalidCommandArgument)
+
+	out, err = runCommand(t, "", "tail", "changes.create.loadbalancer", "--from-seq", "1", "--count", "2")
+	require.NoError(t, err)
+	assert.Contains(t, out, "--- "+eventtools.Prefix+".changes.create.loadbalancer seq=1 ")
+	assert.Contains(t, out, "--- "+eventtools.Prefix+".changes.create.loadbalancer seq=2 ")
+	assert.Contains(t, out, `"subjectID": "loadbal-abc123"`)
+
+	// replaying into a subject read by the replay only replays the messages stored before the replay started.
+	out, err = runCommand(t, "", "replay", "changes.>", "--target", "changes.create.replayed", "--rate", "100")
+	require.NoError(t, err)
+	assert.Equal(t, "replayed 2 messages\n", out)
+
+	out, err = runCommand(t, "", "replay", "changes.>", "--from-seq", "2", "--to-seq", "2")
+	require.NoError(t, err)
+	assert.Equal(t, "replayed 1 messages\n", out)
+
+	out, err = runCommand(t, "", "tail", "changes.create.replayed", "--since", "1m", "--count", "2", "--headers")
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(out, "--- "+eventtools.Prefix+".changes.create.replayed seq="))
+	assert.Contains(t, out, events.HeaderActorID+": unknown-actor")
+	assert.NotContains(t, out, "Nats-Msg-Id")
+
+	_, err = runCommand(t, "", "tail", "changes.>", "--since", "yesterday")
+	require.ErrorIs(t, err, events.ErrInvalidCommandArgument)
+}
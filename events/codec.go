@@ -0,0 +1,320 @@
+package events
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// CodecJSON encodes message payloads as json, this is the default codec.
+	CodecJSON = "json"
+	// CodecMsgpack encodes message payloads as msgpack, using the json field names of the message struct.
+	CodecMsgpack = "msgpack"
+	// CodecGzipJSON encodes message payloads as gzip compressed json.
+	CodecGzipJSON = "json+gzip"
+	// CodecZstdJSON encodes message payloads as zstd compressed json.
+	CodecZstdJSON = "json+zstd"
+
+	// HeaderCodec is the header containing the name of the codec the payload was encoded with.
+	// Payloads without the header are decoded as json.
+	HeaderCodec = "Events-Codec"
+
+	// DefaultMaxDecodedPayloadSize is the default max size in bytes of decompressed message payloads.
+	DefaultMaxDecodedPayloadSize = 64 << 20
+
+	contentTypeMsgpack = "application/msgpack"
+	contentTypeGzip    = "application/gzip"
+	contentTypeZstd    = "application/zstd"
+)
+
+// Codec marshals message payloads.
+// The codec used to publish a message is declared in the HeaderCodec header so consumers decode messages
+// using the codec they were published with, allowing messages published with different codecs to share a stream.
+// Codecs other than the builtin codecs must be registered with RegisterCodec by both publishers and consumers.
+type Codec interface {
+	// Name is the unique name of the codec, it is sent in the HeaderCodec header.
+	Name() string
+	// ContentType is the media type of payloads encoded by the codec.
+	ContentType() string
+	// Marshal encodes v.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes data into v.
+	Unmarshal(data []byte, v any) error
+}
+
+var codecs = struct {
+	sync.RWMutex
+	byName map[string]Codec
+}{
+	byName: map[string]Codec{
+		CodecJSON:     jsonCodec{},
+		CodecMsgpack:  msgpackCodec{},
+		CodecGzipJSON: gzipJSONCodec{},
+		CodecZstdJSON: zstdJSONCodec{},
+	},
+}
+
+// RegisterCodec registers a codec so it may be configured on connections and used to decode received messages.
+// Registering a codec with the name of an existing codec replaces it.
+func RegisterCodec(codec Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+
+	codecs.byName[codec.Name()] = codec
+}
+
+// codecByName returns the registered codec with the name, an empty name returns the json codec.
+func codecByName(name string) (Codec, error) {
+	if name == "" {
+		name = CodecJSON
+	}
+
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	codec, ok := codecs.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedCodec, name)
+	}
+
+	return codec, nil
+}
+
+// isJSONCodec reports whether payloads encoded with the named codec are plain json.
+func isJSONCodec(name string) bool {
+	return name == "" || name == CodecJSON
+}
+
+// maxDecodedPayloadSize is the max size of decompressed payloads, shared by all connections as codecs are registered globally.
+var maxDecodedPayloadSize atomic.Int64
+
+// SetMaxDecodedPayloadSize sets the max size in bytes of payloads decompressed by the gzip and zstd codecs, defaults to DefaultMaxDecodedPayloadSize.
+// Payloads which decompress to more than the max size fail to decode with ErrPayloadTooLarge, so a small compressed payload
+// can not exhaust the memory of consumers. A size of zero or less restores the default.
+func SetMaxDecodedPayloadSize(size int64) {
+	if size <= 0 {
+		size = DefaultMaxDecodedPayloadSize
+	}
+
+	maxDecodedPayloadSize.Store(size)
+	zstdDecoders.Store(newZstdDecoder(size))
+}
+
+// jsonPayload converts a payload encoded with codec to json, for validating and upcasting payloads against their schema.
+func jsonPayload(codec Codec, payload []byte) ([]byte, error) {
+	switch codec.(type) {
+	case jsonCodec:
+		return payload, nil
+	case gzipJSONCodec:
+		return gunzip(payload)
+	case zstdJSONCodec:
+		return unzstd(payload)
+	}
+
+	var object any
+
+	if err := codec.Unmarshal(payload, &object); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(object)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                       { return CodecJSON }
+func (jsonCodec) ContentType() string                { return contentTypeJSON }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string        { return CodecMsgpack }
+func (msgpackCodec) ContentType() string { return contentTypeMsgpack }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+
+	enc.Reset(&buf)
+	enc.SetCustomStructTag("json")
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.GetDecoder()
+	defer msgpack.PutDecoder(dec)
+
+	dec.Reset(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+
+	return dec.Decode(v)
+}
+
+type gzipJSONCodec struct{}
+
+func (gzipJSONCodec) Name() string        { return CodecGzipJSON }
+func (gzipJSONCodec) ContentType() string { return contentTypeGzip }
+
+func (gzipJSONCodec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gzipJSONCodec) Unmarshal(data []byte, v any) error {
+	data, err := gunzip(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// gunzip decompresses the data, returning ErrPayloadTooLarge if it decompresses to more than the max decoded payload size.
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	maxSize := maxDecodedPayloadSize.Load()
+
+	data, err = io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: gzip payload exceeds %d bytes", ErrPayloadTooLarge, maxSize)
+	}
+
+	return data, nil
+}
+
+// unzstd decompresses the data, returning ErrPayloadTooLarge if it decompresses to more than the max decoded payload size.
+func unzstd(data []byte) ([]byte, error) {
+	data, err := zstdDecoders.Load().DecodeAll(data, nil)
+	if err != nil {
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, fmt.Errorf("%w: zstd payload exceeds %d bytes: %w", ErrPayloadTooLarge, maxDecodedPayloadSize.Load(), err)
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// zstd encoders and decoders are safe for concurrent use with EncodeAll and DecodeAll and are expensive to create.
+// The decoder is replaced when the max decoded payload size changes.
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		enc, _ := zstd.NewWriter(nil) // only fails for invalid options
+
+		return enc
+	})
+	zstdDecoders atomic.Pointer[zstd.Decoder]
+)
+
+func init() {
+	maxDecodedPayloadSize.Store(DefaultMaxDecodedPayloadSize)
+	zstdDecoders.Store(newZstdDecoder(DefaultMaxDecodedPayloadSize))
+}
+
+func newZstdDecoder(maxSize int64) *zstd.Decoder {
+	// only fails for invalid options
+	dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(uint64(maxSize))) //nolint:gosec // size is positive
+
+	return dec
+}
+
+type zstdJSONCodec struct{}
+
+func (zstdJSONCodec) Name() string        { return CodecZstdJSON }
+func (zstdJSONCodec) ContentType() string { return contentTypeZstd }
+
+func (zstdJSONCodec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return zstdEncoder().EncodeAll(data, nil), nil
+}
+
+func (zstdJSONCodec) Unmarshal(data []byte, v any) error {
+	data, err := unzstd(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// EncodeMsgpack encodes the errors as an array of strings, matching [Errors.MarshalJSON].
+func (e Errors) EncodeMsgpack(enc *msgpack.Encoder) error {
+	errs := make([]string, 0, len(e))
+
+	for _, err := range e {
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) == 0 {
+		return enc.EncodeNil()
+	}
+
+	return enc.Encode(errs)
+}
+
+// DecodeMsgpack decodes an array of strings into new errors, matching [Errors.UnmarshalJSON].
+func (e *Errors) DecodeMsgpack(dec *msgpack.Decoder) error {
+	var errs []string
+
+	if err := dec.Decode(&errs); err != nil {
+		return err
+	}
+
+	*e = nil
+
+	for _, err := range errs {
+		*e = append(*e, errors.New(err)) //nolint:err113 // errors are dynamically returned
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package events_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestNATSCodecs(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	consumer, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer consumer.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := consumer.SubscribeChanges(ctx, "create.codecs")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		codec    string
+		encoding string
+		header   string
+	}{
+		{codec: "", header: ""},
+		{codec: events.CodecJSON, header: ""},
+		{codec: events.CodecMsgpack, header: events.CodecMsgpack},
+		{codec: events.CodecGzipJSON, header: events.CodecGzipJSON},
+		{codec: events.CodecZstdJSON, header: events.CodecZstdJSON},
+		{codec: events.CodecMsgpack, encoding: events.EncodingCloudEventsBinary, header: events.CodecMsgpack},
+	}
+
+	// messages published with each codec share the stream and are decoded by a single consumer.
+	for _, tc := range testCases {
+		t.Run(tc.codec+tc.encoding, func(t *testing.T) {
+			natsCfg := nats.Config.NATS
+			natsCfg.Codec = tc.codec
+			natsCfg.Encoding = tc.encoding
+
+			publisher, err := events.NewNATSConnection(natsCfg)
+			require.NoError(t, err)
+
+			defer publisher.Shutdown(ctx) //nolint:errcheck // within test
+
+			change := testCreateChange()
+			change.AdditionalData = map[string]any{"codec": tc.codec}
+
+			_, err = publisher.PublishChange(ctx, "codecs", change)
+			require.NoError(t, err)
+
+			received, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, received.Error())
+
+			assert.Equal(t, tc.header, received.Headers().Get(events.HeaderCodec))
+			assert.Equal(t, change.SubjectID, received.Message().SubjectID)
+			assert.Equal(t, change.AdditionalSubjectIDs, received.Message().AdditionalSubjectIDs)
+			assert.Equal(t, change.FieldChanges, received.Message().FieldChanges)
+			assert.Equal(t, change.AdditionalData, received.Message().AdditionalData)
+
+			require.NoError(t, received.Ack())
+		})
+	}
+}
+
+func TestMemoryCodecSchemaValidation(t *testing.T) {
+	ctx := context.Background()
+
+	registry := events.NewSchemaRegistry()
+
+	require.NoError(t, events.RegisterSchema[testInvoice](registry, events.MessageSchema{
+		Version:    1,
+		JSONSchema: []byte(`{"type": "object", "required": ["id"], "properties": {"amount": {"type": "integer", "minimum": 0}}}`),
+	}))
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, Codec: events.CodecMsgpack}, events.WithMemorySchemaRegistry(registry))
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := events.Subscribe[testInvoice](ctx, conn, "invoices", "issued")
+	require.NoError(t, err)
+
+	_, err = events.Publish(ctx, conn, "invoices", "issued", testInvoice{ID: "invoice", Amount: -1})
+	require.ErrorIs(t, err, events.ErrSchemaValidationFailed)
+
+	_, err = events.Publish(ctx, conn, "invoices", "issued", testInvoice{ID: "invoice", Amount: 10})
+	require.NoError(t, err)
+
+	received, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, received.Error())
+
+	assert.Equal(t, events.CodecMsgpack, received.Headers().Get(events.HeaderCodec))
+	assert.Equal(t, testInvoice{ID: "invoice", Amount: 10}, received.Message())
+}
+
+type testBase64Codec struct{}
+
+func (testBase64Codec) Name() string        { return "json+base64" }
+func (testBase64Codec) ContentType() string { return "text/plain" }
+
+func (testBase64Codec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(data)), nil
+}
+
+func (testBase64Codec) Unmarshal(data []byte, v any) error {
+	data, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func TestRegisterCodec(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, Codec: "json+base64"})
+	require.ErrorIs(t, err, events.ErrUnsupportedCodec)
+
+	events.RegisterCodec(testBase64Codec{})
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, Codec: "json+base64"})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	change := testCreateChange()
+
+	_, err = conn.PublishChange(ctx, "codecs", change)
+	require.NoError(t, err)
+
+	received, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, received.Error())
+
+	assert.Equal(t, "json+base64", received.Headers().Get(events.HeaderCodec))
+	assert.Equal(t, change.SubjectID, received.Message().SubjectID)
+}
+
+func TestNATSConfigCodecValidation(t *testing.T) {
+	cfg := events.NATSConfig{URL: "nats://localhost", Codec: "unknown"}
+	require.ErrorIs(t, cfg.Validate(), events.ErrUnsupportedCodec)
+
+	cfg = events.NATSConfig{URL: "nats://localhost", Codec: events.CodecMsgpack, Encoding: events.EncodingCloudEventsStructured}
+	require.ErrorIs(t, cfg.Validate(), events.ErrUnsupportedCodec)
+
+	cfg = events.NATSConfig{URL: "nats://localhost", Codec: events.CodecMsgpack, Encoding: events.EncodingCloudEventsBinary}
+	require.NoError(t, cfg.Validate())
+}
+
+func TestMemoryCodecRequestReply(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, Codec: events.CodecMsgpack})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	subCtx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	requests, err := conn.SubscribeAuthRelationshipRequests(subCtx, "*.codecs")
+	require.NoError(t, err)
+
+	go func() {
+		reqMsg, ok := <-requests
+		if !ok {
+			return
+		}
+
+		_, err := reqMsg.Reply(ctx, events.AuthRelationshipResponse{
+			Errors: events.Errors{errors.New("relation failed"), nil}, //nolint:err113 // test error
+		})
+		assert.NoError(t, err)
+	}()
+
+	reqCtx, reqCancel := context.WithTimeout(ctx, time.Second*2)
+
+	defer reqCancel()
+
+	resp, err := conn.PublishAuthRelationshipRequest(reqCtx, "codecs", events.AuthRelationshipRequest{
+		Action:    events.WriteAuthRelationshipAction,
+		ObjectID:  gidx.PrefixedID("prntobj-abc123"),
+		Relations: []events.AuthRelationshipRelation{{Relation: "owner", SubjectID: gidx.PrefixedID("chldobj-abc123")}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, resp.Error())
+
+	assert.Equal(t, events.CodecMsgpack, resp.Headers().Get(events.HeaderCodec))
+	require.Len(t, resp.Message().Errors, 1)
+	assert.EqualError(t, resp.Message().Errors[0], "relation failed")
+}
+
+func TestMemoryCodecMaxDecodedPayloadSize(t *testing.T) {
+	ctx := context.Background()
+
+	// well above the size of a change message with random test data, and well below the decompressed bomb.
+	events.SetMaxDecodedPayloadSize(64 << 10)
+
+	defer events.SetMaxDecodedPayloadSize(0)
+
+	for _, codec := range []string{events.CodecGzipJSON, events.CodecZstdJSON} {
+		t.Run(codec, func(t *testing.T) {
+			conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, Codec: codec})
+			require.NoError(t, err)
+
+			defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+			messages, err := conn.SubscribeChanges(ctx, ">")
+			require.NoError(t, err)
+
+			_, err = conn.PublishChange(ctx, "codecs", testCreateChange())
+			require.NoError(t, err)
+
+			received, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, received.Error())
+
+			// a highly compressible payload which decompresses to more than the max size.
+			bomb := testCreateChange()
+			bomb.SubjectFields = map[string]string{"padding": strings.Repeat("a", 1<<20)}
+
+			_, err = conn.PublishChange(ctx, "codecs", bomb)
+			require.NoError(t, err)
+
+			received, err = getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.ErrorIs(t, received.Error(), events.ErrPayloadTooLarge)
+			assert.Less(t, len(received.Source().(*events.MemoryMsg).Data), 1<<14)
+		})
+	}
+}
@@ -15,6 +15,7 @@
 package events
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -30,12 +31,35 @@ const (
 
 // Config contains event provider configs.
 type Config struct {
-	NATS NATSConfig `mapstructure:"nats"`
+	NATS   NATSConfig   `mapstructure:"nats"`
+	Memory MemoryConfig `mapstructure:"memory"`
+	Redis  RedisConfig  `mapstructure:"redis"`
+}
+
+// validateMessageConfig validates the encoding, codec and trusted signing keys shared by the provider configurations.
+func validateMessageConfig(encoding, codec string, trustedSigningKeys []string) error {
+	var err error
+
+	switch encoding {
+	case "", EncodingJSON, EncodingCloudEventsBinary, EncodingCloudEventsStructured:
+	default:
+		err = multierr.Append(err, ErrUnsupportedEncoding)
+	}
+
+	if _, codecErr := codecByName(codec); codecErr != nil {
+		err = multierr.Append(err, codecErr)
+	} else if encoding == EncodingCloudEventsStructured && !isJSONCodec(codec) {
+		err = multierr.Append(err, fmt.Errorf("%w: %s encoding requires the json codec", ErrUnsupportedCodec, encoding))
+	}
+
+	return multierr.Append(err, validateTrustedKeys(trustedSigningKeys))
 }
 
 // MustViperFlags returns the cobra flags and viper config for events.
 func MustViperFlags(v *viper.Viper, flags *pflag.FlagSet, appName string) {
 	MustViperFlagsForNATS(v, flags, appName)
+	MustViperFlagsForMemory(v, flags, appName)
+	MustViperFlagsForRedis(v, flags, appName)
 }
 
 // Option configures a connection option.
@@ -45,6 +69,42 @@ type Option func(config *Config) error
 func WithLogger(logger *zap.SugaredLogger) Option {
 	return func(config *Config) error {
 		config.NATS.logger = logger
+		config.Memory.logger = logger
+		config.Redis.logger = logger
+
+		return nil
+	}
+}
+
+// WithSchemaRegistry sets the schema registry used to version and validate messages.
+func WithSchemaRegistry(registry *SchemaRegistry) Option {
+	return func(config *Config) error {
+		config.NATS.schemas = registry
+		config.Memory.schemas = registry
+		config.Redis.schemas = registry
+
+		return nil
+	}
+}
+
+// WithSigner signs published messages with the signer.
+func WithSigner(signer MessageSigner) Option {
+	return func(config *Config) error {
+		config.NATS.signer = signer
+		config.Memory.signer = signer
+		config.Redis.signer = signer
+
+		return nil
+	}
+}
+
+// WithEncryptionKeys sets the keys used to encrypt messages published to the EncryptedTopics and decrypt received messages.
+// Messages are encrypted with the first key, all keys are used to decrypt messages so keys may be rotated.
+func WithEncryptionKeys(keys ...KeyEncrypter) Option {
+	return func(config *Config) error {
+		config.NATS.encryptionKeys = append(config.NATS.encryptionKeys, keys...)
+		config.Memory.encryptionKeys = append(config.Memory.encryptionKeys, keys...)
+		config.Redis.encryptionKeys = append(config.Redis.encryptionKeys, keys...)
 
 		return nil
 	}
@@ -62,3 +122,29 @@ func WithNATSOptions(options ...NATSOption) Option {
 		return err
 	}
 }
+
+// WithMemoryOptions configures in-memory provider options.
+func WithMemoryOptions(options ...MemoryOption) Option {
+	return func(config *Config) error {
+		var err error
+
+		for _, opt := range options {
+			err = multierr.Append(err, opt(&config.Memory))
+		}
+
+		return err
+	}
+}
+
+// WithRedisOptions configures Redis Streams provider options.
+func WithRedisOptions(options ...RedisOption) Option {
+	return func(config *Config) error {
+		var err error
+
+		for _, opt := range options {
+			err = multierr.Append(err, opt(&config.Redis))
+		}
+
+		return err
+	}
+}
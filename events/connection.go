@@ -14,6 +14,10 @@ type Connection interface {
 	// Source gives you the raw underlying connection object.
 	Source() any
 
+	// HealthCheck returns an error if the connection is not healthy.
+	// The signature matches echox.CheckFunc and ginx.CheckFunc so it may be used as a readiness check.
+	HealthCheck(ctx context.Context) error
+
 	Subscriber
 	Publisher
 
@@ -61,6 +65,15 @@ func NewConnection(config Config, options ...Option) (Connection, error) {
 		return nil, err
 	}
 
+	// The memory and redis providers must be explicitly configured, so they take precedence over a defaulted NATS url.
+	if config.Memory.Configured() {
+		return NewMemoryConnection(config.Memory)
+	}
+
+	if config.Redis.Configured() {
+		return NewRedisConnection(config.Redis)
+	}
+
 	if config.NATS.Configured() {
 		return NewNATSConnection(config.NATS)
 	}
@@ -29,4 +29,50 @@ var (
 
 	// ErrRequestNoResponders is returned when a request is attempted but no responder is listening.
 	ErrRequestNoResponders = errors.New("no responders for request")
+	// ErrRequestFailed is wrapped by the ReplyError returned when a responder failed a request.
+	ErrRequestFailed = errors.New("request failed")
+	// ErrResponderPanic is returned when a responder panics while handling a request.
+	ErrResponderPanic = errors.New("responder panic")
+
+	// ErrUnsupportedEncoding is returned when the configured message encoding is not supported.
+	ErrUnsupportedEncoding = errors.New("unsupported message encoding")
+	// ErrUnsupportedCodec is returned when a message codec is not registered or can not be used with the configured encoding.
+	ErrUnsupportedCodec = errors.New("unsupported message codec")
+	// ErrPayloadTooLarge is returned when a compressed message payload decompresses to more than the max decoded payload size.
+	ErrPayloadTooLarge = errors.New("decoded message payload too large")
+	// ErrInvalidCloudEvent is returned when a received CloudEvent is not valid or not supported.
+	ErrInvalidCloudEvent = errors.New("invalid cloudevent")
+
+	// ErrUnsupportedConnection is returned when a connection does not support typed messages.
+	ErrUnsupportedConnection = errors.New("connection does not support typed messages")
+	// ErrInvalidMessageFamily is returned when a typed message family is not a single subject token.
+	ErrInvalidMessageFamily = errors.New("invalid message family")
+
+	// ErrInvalidSchema is returned when a message schema being registered is not valid.
+	ErrInvalidSchema = errors.New("invalid message schema")
+	// ErrSchemaValidationFailed is returned when a message payload does not match its JSON Schema.
+	ErrSchemaValidationFailed = errors.New("message schema validation failed")
+	// ErrSchemaVersionUnsupported is returned when a message payload version can not be converted to the current version.
+	ErrSchemaVersionUnsupported = errors.New("unsupported message schema version")
+	// ErrSchemaUpcastFailed is returned when an upcaster fails to convert a message payload.
+	ErrSchemaUpcastFailed = errors.New("message schema upcast failed")
+
+	// ErrInvalidSigningKey is returned when a signing key or trusted signing key is not valid.
+	ErrInvalidSigningKey = errors.New("invalid signing key")
+	// ErrMessageUnverified is returned when trusted signing keys are configured and a received message is not signed by one of them.
+	ErrMessageUnverified = errors.New("message signature could not be verified")
+	// ErrInvalidEncryptionKey is returned when an encryption key is not valid.
+	ErrInvalidEncryptionKey = errors.New("invalid encryption key")
+	// ErrEncryptionKeyRequired is returned when encrypted topics are configured without an encryption key.
+	ErrEncryptionKeyRequired = errors.New("encrypted topics require an encryption key")
+	// ErrMessageDecryptionFailed is returned when a received encrypted message can not be decrypted.
+	ErrMessageDecryptionFailed = errors.New("message decryption failed")
+
+	// ErrSchedulingUnsupported is returned when publishing a scheduled message with a connection which does not support scheduling.
+	ErrSchedulingUnsupported = errors.New("connection does not support scheduled messages")
+	// ErrScheduledMessageNotFound is returned when canceling a scheduled message which is not pending.
+	ErrScheduledMessageNotFound = errors.New("scheduled message not found")
+
+	// ErrInvalidCommandArgument is returned by the events command when an argument is not valid.
+	ErrInvalidCommandArgument = errors.New("invalid command argument")
 )
@@ -0,0 +1,179 @@
+package events
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"go.infratographer.com/x/echojwtx"
+)
+
+const (
+	// HeaderSource is the header containing the source which published the message.
+	HeaderSource = "Events-Source"
+	// HeaderActorID is the header containing the id of the actor which caused the message to be published.
+	HeaderActorID = "Events-Actor-ID"
+	// HeaderSchemaVersion is the header containing the schema version of the message payload.
+	HeaderSchemaVersion = "Events-Schema-Version"
+	// HeaderCorrelationID is the header containing an id correlating related messages.
+	HeaderCorrelationID = "Events-Correlation-ID"
+	// HeaderMessageID is the header containing the id the message was published with, on providers without a native message id header.
+	HeaderMessageID = "Events-Message-ID"
+	// HeaderReplayedFrom is the header containing the stream and stream sequence, separated by a period, of the message a replayed message was republished from.
+	HeaderReplayedFrom = "Events-Replayed-From"
+	// HeaderDeliverAt is the header containing the time a scheduled message is delivered at, formatted as RFC 3339.
+	HeaderDeliverAt = "Events-Deliver-At"
+	// HeaderReplyErrorCode is the header containing the code of the error a responder failed a request with.
+	HeaderReplyErrorCode = "Events-Reply-Error-Code"
+	// HeaderReplyError is the header containing the message of the error a responder failed a request with.
+	HeaderReplyError = "Events-Reply-Error"
+)
+
+var _ propagation.TextMapCarrier = Headers(nil)
+
+// Headers holds message metadata which travels alongside the message payload.
+// Keys are case-sensitive.
+type Headers map[string][]string
+
+// Get returns the first value for the key or an empty string if the key is not set.
+func (h Headers) Get(key string) string {
+	if values := h[key]; len(values) != 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// Values returns all values for the key.
+func (h Headers) Values(key string) []string {
+	return h[key]
+}
+
+// Set replaces any values for the key with the provided value.
+func (h Headers) Set(key, value string) {
+	h[key] = []string{value}
+}
+
+// Add appends the value to the values for the key.
+func (h Headers) Add(key, value string) {
+	h[key] = append(h[key], value)
+}
+
+// Del removes all values for the key.
+func (h Headers) Del(key string) {
+	delete(h, key)
+}
+
+// Keys returns the keys which are set.
+func (h Headers) Keys() []string {
+	return slices.Collect(maps.Keys(h))
+}
+
+// Clone returns a copy of the headers.
+func (h Headers) Clone() Headers {
+	clone := make(Headers, len(h))
+
+	for key, values := range h {
+		clone[key] = slices.Clone(values)
+	}
+
+	return clone
+}
+
+type headersCtxKey struct{}
+
+// ContextWithHeaders returns a context which adds the provided headers to published messages.
+// Headers are merged with any headers already on the context, replacing existing keys.
+func ContextWithHeaders(ctx context.Context, headers Headers) context.Context {
+	merged := HeadersFromContext(ctx).Clone()
+
+	for key, values := range headers {
+		merged[key] = slices.Clone(values)
+	}
+
+	return context.WithValue(ctx, headersCtxKey{}, merged)
+}
+
+// HeadersFromContext returns the headers set with ContextWithHeaders.
+func HeadersFromContext(ctx context.Context) Headers {
+	headers, _ := ctx.Value(headersCtxKey{}).(Headers)
+
+	return headers
+}
+
+// publishHeaders builds the headers for a message published with the provided context.
+// The trace context is propagated along with the source and actor if not already provided by the context headers.
+func publishHeaders(ctx context.Context, source string, message any) Headers {
+	headers := HeadersFromContext(ctx).Clone()
+
+	otel.GetTextMapPropagator().Inject(ctx, headers)
+
+	if source != "" && headers.Get(HeaderSource) == "" {
+		headers.Set(HeaderSource, source)
+	}
+
+	if actorID := messageActorID(ctx, message); actorID != "" && headers.Get(HeaderActorID) == "" {
+		headers.Set(HeaderActorID, actorID)
+	}
+
+	return headers
+}
+
+func messageActorID(ctx context.Context, message any) string {
+	if change, ok := message.(ChangeMessage); ok && change.ActorID != "" {
+		return change.ActorID.String()
+	}
+
+	if id, ok := ctx.Value(echojwtx.ActorCtxKey).(string); ok {
+		return id
+	}
+
+	return ""
+}
+
+// legacyTraceContext returns the trace context to write to the message body.
+func legacyTraceContext(ctx context.Context) map[string]string {
+	var mapCarrier propagation.MapCarrier = make(map[string]string)
+
+	otel.GetTextMapPropagator().Inject(ctx, mapCarrier)
+
+	return mapCarrier
+}
+
+// applyHeaderTraceContext sets the message trace context from the headers when the message body did not provide one,
+// allowing consumers using GetTraceContext to continue to receive the publisher trace context.
+func applyHeaderTraceContext(headers Headers, message any) {
+	traceContext := map[string]string{}
+
+	for _, field := range otel.GetTextMapPropagator().Fields() {
+		if value := headers.Get(field); value != "" {
+			traceContext[field] = value
+		}
+	}
+
+	if len(traceContext) == 0 {
+		return
+	}
+
+	switch m := message.(type) {
+	case *ChangeMessage:
+		if len(m.TraceContext) == 0 {
+			m.TraceContext = traceContext
+		}
+	case *EventMessage:
+		if len(m.TraceContext) == 0 {
+			m.TraceContext = traceContext
+		}
+	case *AuthRelationshipRequest:
+		if len(m.TraceContext) == 0 {
+			m.TraceContext = traceContext
+		}
+	case *AuthRelationshipResponse:
+		if len(m.TraceContext) == 0 {
+			m.TraceContext = traceContext
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.infratographer.com/x/echojwtx"
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func testTraceContext(t *testing.T) (context.Context, string) {
+	t.Helper()
+
+	propagator := otel.GetTextMapPropagator()
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	return ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+}
+
+func TestNATSHeaders(t *testing.T) {
+	testCases := []struct {
+		name               string
+		legacyTraceContext bool
+	}{
+		{
+			name: "headers only",
+		},
+		{
+			name:               "with legacy trace context",
+			legacyTraceContext: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, traceParent := testTraceContext(t)
+
+			nats, err := eventtools.NewNatsServer()
+			require.NoError(t, err)
+
+			defer nats.Close()
+
+			natsCfg := nats.Config.NATS
+			natsCfg.Source = "testing-headers"
+			natsCfg.LegacyTraceContext = tc.legacyTraceContext
+
+			conn, err := events.NewNATSConnection(natsCfg)
+			require.NoError(t, err)
+
+			defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+			pubCtx := context.WithValue(ctx, echojwtx.ActorCtxKey, "idntusr-abc123")
+			pubCtx = events.ContextWithHeaders(pubCtx, events.Headers{events.HeaderCorrelationID: {"correlation-1"}})
+
+			change := testCreateChange()
+			change.ActorID = ""
+
+			_, err = conn.PublishChange(pubCtx, "test", change)
+			require.NoError(t, err)
+
+			messages, err := conn.SubscribeChanges(ctx, ">")
+			require.NoError(t, err)
+
+			receivedMsg, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, receivedMsg.Error())
+
+			headers := receivedMsg.Headers()
+
+			assert.Equal(t, traceParent, headers.Get("traceparent"))
+			assert.Equal(t, "testing-headers", headers.Get(events.HeaderSource))
+			assert.Equal(t, "idntusr-abc123", headers.Get(events.HeaderActorID))
+			assert.Equal(t, "correlation-1", headers.Get(events.HeaderCorrelationID))
+
+			// consumers reading the trace context from the message receive the header trace context.
+			assert.Equal(t, traceParent, receivedMsg.Message().TraceContext["traceparent"])
+
+			var body events.ChangeMessage
+
+			require.NoError(t, json.Unmarshal(receivedMsg.Source().(jetstream.Msg).Data(), &body))
+
+			if tc.legacyTraceContext {
+				assert.Equal(t, traceParent, body.TraceContext["traceparent"])
+			} else {
+				assert.Empty(t, body.TraceContext["traceparent"])
+			}
+
+			require.NoError(t, receivedMsg.Ack())
+		})
+	}
+}
+
+func TestMemoryHeaders(t *testing.T) {
+	ctx, traceParent := testTraceContext(t)
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, Source: "testing-headers"})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeEvents(ctx, ">")
+	require.NoError(t, err)
+
+	pubCtx := events.ContextWithHeaders(ctx, events.Headers{events.HeaderSchemaVersion: {"2"}})
+
+	_, err = conn.PublishEvent(pubCtx, "test", events.EventMessage{
+		SubjectID: gidx.MustNewID("testing"),
+		EventType: "ping",
+	})
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2", receivedMsg.Headers().Get(events.HeaderSchemaVersion))
+	assert.Equal(t, "testing-headers", receivedMsg.Headers().Get(events.HeaderSource))
+	assert.Equal(t, traceParent, receivedMsg.Message().TraceContext["traceparent"])
+
+	require.NoError(t, receivedMsg.Ack())
+}
@@ -0,0 +1,23 @@
+// Copyright 2023 The Infratographer Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package idempotency drops messages which were already processed.
+//
+// Messages may be delivered more than once, such as after a nak, an ack timeout or a consumer restart.
+// The keys of processed messages are recorded in a Store, messages received again within the store window
+// are acked and dropped without being handled. Stores are provided for an in-memory LRU cache,
+// a SQL table, such as a CockroachDB database opened with crdbx, and a NATS KV bucket.
+//
+// Router handlers are made idempotent with Middleware, messages received from a subscription with Messages.
+package idempotency
@@ -0,0 +1,251 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.infratographer.com/x/events"
+)
+
+// DefaultWindow is the default duration a processed message is remembered for.
+const DefaultWindow = 24 * time.Hour
+
+// Store records the keys of processed messages.
+type Store interface {
+	// Processed reports whether the key was marked processed within the store window.
+	Processed(ctx context.Context, key string) (bool, error)
+	// MarkProcessed records the key as processed.
+	MarkProcessed(ctx context.Context, key string) error
+}
+
+// Option configures how messages are de-duplicated.
+type Option func(c *config)
+
+type config struct {
+	scope   string
+	keyFunc func(msg any) string
+}
+
+func newConfig(options []Option) config {
+	c := config{
+		keyFunc: Key,
+	}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	return c
+}
+
+func (c config) key(msg any) string {
+	key := c.keyFunc(msg)
+
+	if key == "" || c.scope == "" {
+		return key
+	}
+
+	return c.scope + "." + key
+}
+
+// WithScope prefixes keys with the scope.
+// Consumers which each process every message, such as separate services sharing a store, must use different scopes.
+func WithScope(scope string) Option {
+	return func(c *config) {
+		c.scope = scope
+	}
+}
+
+// WithKeyFunc sets the function returning the key of a received message, defaults to Key.
+// Messages with an empty key are always processed.
+func WithKeyFunc(keyFunc func(msg any) string) Option {
+	return func(c *config) {
+		c.keyFunc = keyFunc
+	}
+}
+
+// Key returns the key identifying the message across deliveries.
+// The id the message was published with is used when available, such as the NATS message id,
+// otherwise the stream and stream sequence of the message, falling back to the message ID.
+// Replayed messages keep the id of the original message, so they're keyed by their own stream and stream sequence
+// and processed again. Messages returned by Messages return the key of the received message.
+func Key(msg any) string {
+	if m, ok := msg.(interface{ IdempotencyKey() string }); ok {
+		return m.IdempotencyKey()
+	}
+
+	if m, ok := msg.(interface{ MessageID() string }); ok && !replayed(msg) {
+		if id := m.MessageID(); id != "" {
+			return id
+		}
+	}
+
+	if m, ok := msg.(events.SequencedMessage); ok && m.Stream() != "" {
+		return m.Stream() + "." + strconv.FormatUint(m.Sequence(), 10) //nolint:mnd // base 10
+	}
+
+	if m, ok := msg.(interface{ ID() string }); ok {
+		return m.ID()
+	}
+
+	return ""
+}
+
+// replayed reports whether the message was republished by a replay, see events.HeaderReplayedFrom.
+func replayed(msg any) bool {
+	m, ok := msg.(interface{ Headers() events.Headers })
+
+	return ok && m.Headers().Get(events.HeaderReplayedFrom) != ""
+}
+
+// Middleware returns router middleware which acks messages already processed without calling the handler.
+// Messages are marked processed once handled successfully, an error checking or marking a message is returned
+// so the message is redelivered.
+func Middleware(store Store, options ...Option) events.Middleware {
+	cfg := newConfig(options)
+
+	return func(next events.HandlerFunc) events.HandlerFunc {
+		return func(ctx context.Context, delivery events.Delivery) error {
+			key := cfg.key(delivery.Message)
+			if key == "" {
+				return next(ctx, delivery)
+			}
+
+			processed, err := store.Processed(ctx, key)
+			if err != nil {
+				return fmt.Errorf("checking processed message %s: %w", key, err)
+			}
+
+			if processed {
+				return nil
+			}
+
+			if err := next(ctx, delivery); err != nil {
+				return err
+			}
+
+			if err := store.MarkProcessed(ctx, key); err != nil {
+				return fmt.Errorf("marking message %s processed: %w", key, err)
+			}
+
+			return nil
+		}
+	}
+}
+
+// Messages returns a channel of the messages received from msgs which were not already processed.
+// Messages already processed are acked and dropped, returned messages are marked processed when acked.
+// Messages which failed to decode and messages which could not be checked against the store are returned unchanged.
+// The returned channel is closed when msgs is closed or the context is canceled.
+func Messages[T any](ctx context.Context, store Store, msgs <-chan events.Message[T], options ...Option) <-chan events.Message[T] {
+	cfg := newConfig(options)
+
+	out := make(chan events.Message[T])
+
+	go func() {
+		defer close(out)
+
+		for {
+			var msg events.Message[T]
+
+			select {
+			case <-ctx.Done():
+				return
+			case received, ok := <-msgs:
+				if !ok {
+					return
+				}
+
+				msg = received
+			}
+
+			if msg.Error() == nil {
+				if key := cfg.key(msg); key != "" {
+					processed, err := store.Processed(ctx, key)
+
+					if err == nil && processed {
+						msg.Ack() //nolint:errcheck // a failed ack is redelivered and dropped again
+
+						continue
+					}
+
+					if err == nil {
+						msg = &message[T]{receivedMessage: msg, ctx: ctx, store: store, key: key}
+					}
+				}
+			}
+
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// receivedMessage is embedded by message, an embedded events.Message field would shadow the Message method.
+type receivedMessage[T any] interface {
+	events.Message[T]
+}
+
+var (
+	_ events.NakWithErrorMessage = (*message[any])(nil)
+	_ events.SequencedMessage    = (*message[any])(nil)
+)
+
+// message marks the message processed when acked.
+// NakWithError, Stream and Sequence are forwarded to the received message when it implements them.
+type message[T any] struct {
+	receivedMessage[T]
+
+	ctx   context.Context
+	store Store
+	key   string
+}
+
+// IdempotencyKey returns the key the message is marked processed with.
+func (m *message[T]) IdempotencyKey() string {
+	return m.key
+}
+
+// Ack marks the message processed and acks the message.
+// The message is not acked if it could not be marked processed so it's redelivered.
+func (m *message[T]) Ack() error {
+	if err := m.store.MarkProcessed(m.ctx, m.key); err != nil {
+		return fmt.Errorf("marking message %s processed: %w", m.key, err)
+	}
+
+	return m.receivedMessage.Ack()
+}
+
+// NakWithError naks the received message with the cause when supported, otherwise the message is naked.
+func (m *message[T]) NakWithError(delay time.Duration, cause error) error {
+	if naker, ok := m.receivedMessage.(events.NakWithErrorMessage); ok {
+		return naker.NakWithError(delay, cause)
+	}
+
+	return m.receivedMessage.Nak(delay)
+}
+
+// Stream returns the name of the stream the received message is stored in, if any.
+func (m *message[T]) Stream() string {
+	if sequenced, ok := m.receivedMessage.(events.SequencedMessage); ok {
+		return sequenced.Stream()
+	}
+
+	return ""
+}
+
+// Sequence returns the stream sequence of the received message, if any.
+func (m *message[T]) Sequence() uint64 {
+	if sequenced, ok := m.receivedMessage.(events.SequencedMessage); ok {
+		return sequenced.Sequence()
+	}
+
+	return 0
+}
@@ -0,0 +1,340 @@
+package idempotency_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/v2/testserver"
+	_ "github.com/lib/pq" // Register the Postgres driver.
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/events/idempotency"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+var errHandler = errors.New("handler failed")
+
+type testMessage struct {
+	id string
+}
+
+func (m testMessage) MessageID() string {
+	return m.id
+}
+
+func testStore(t *testing.T, store idempotency.Store) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	processed, err := store.Processed(ctx, "evntmsg-abc123")
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	require.NoError(t, store.MarkProcessed(ctx, "evntmsg-abc123"))
+	require.NoError(t, store.MarkProcessed(ctx, "evntmsg-abc123"))
+
+	processed, err = store.Processed(ctx, "evntmsg-abc123")
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	processed, err = store.Processed(ctx, "consumer.events-tests.12")
+	require.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestLRUStore(t *testing.T) {
+	ctx := context.Background()
+
+	testStore(t, idempotency.NewLRUStore(0, 0))
+
+	store := idempotency.NewLRUStore(2, 50*time.Millisecond)
+
+	require.NoError(t, store.MarkProcessed(ctx, "a"))
+	require.NoError(t, store.MarkProcessed(ctx, "b"))
+
+	// a is used more recently than b so b is evicted.
+	processed, err := store.Processed(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	require.NoError(t, store.MarkProcessed(ctx, "c"))
+
+	processed, err = store.Processed(ctx, "b")
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	time.Sleep(60 * time.Millisecond)
+
+	processed, err = store.Processed(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, processed, "expected key to expire after the window")
+}
+
+func TestNATSKVStore(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	js, err := jetstream.New(nats.Conn)
+	require.NoError(t, err)
+
+	store, err := idempotency.NewNATSKVStore(ctx, js, idempotency.DefaultBucket, time.Minute)
+	require.NoError(t, err)
+
+	testStore(t, store)
+
+	kv, err := js.KeyValue(ctx, idempotency.DefaultBucket)
+	require.NoError(t, err)
+
+	status, err := kv.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, status.TTL())
+}
+
+func TestSQLStore(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := idempotency.NewSQLStore(nil, "bad; DROP TABLE", 0)
+	require.ErrorIs(t, err, idempotency.ErrInvalidTable)
+
+	server, err := testserver.NewTestServer()
+	require.NoError(t, err)
+
+	defer server.Stop()
+
+	db, err := sql.Open("postgres", server.PGURL().String())
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	schema, err := idempotency.Schema(idempotency.DefaultTable)
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, schema)
+	require.NoError(t, err)
+
+	store, err := idempotency.NewSQLStore(db, idempotency.DefaultTable, time.Minute)
+	require.NoError(t, err)
+
+	testStore(t, store)
+
+	deleted, err := store.DeleteExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	store := idempotency.NewLRUStore(0, 0)
+
+	var calls int
+
+	handlerErr := errHandler
+
+	handler := idempotency.Middleware(store, idempotency.WithScope("consumer"))(func(context.Context, events.Delivery) error {
+		calls++
+
+		return handlerErr
+	})
+
+	delivery := events.Delivery{Message: testMessage{id: "evntmsg-abc123"}}
+
+	// failed messages are not marked processed.
+	require.ErrorIs(t, handler(ctx, delivery), errHandler)
+
+	handlerErr = nil
+
+	require.NoError(t, handler(ctx, delivery))
+	require.NoError(t, handler(ctx, delivery))
+	assert.Equal(t, 2, calls)
+
+	processed, err := store.Processed(ctx, "consumer.evntmsg-abc123")
+	require.NoError(t, err)
+	assert.True(t, processed)
+}
+
+func TestMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	subscription, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	store := idempotency.NewLRUStore(0, 0)
+
+	messages := idempotency.Messages(ctx, store, subscription)
+
+	first := events.ChangeMessage{SubjectID: gidx.MustNewID("testing"), EventType: string(events.CreateChangeType)}
+	second := events.ChangeMessage{SubjectID: gidx.MustNewID("testing"), EventType: string(events.CreateChangeType)}
+
+	_, err = conn.PublishChange(ctx, "test", first)
+	require.NoError(t, err)
+
+	_, err = conn.PublishChange(ctx, "test", second)
+	require.NoError(t, err)
+
+	receive := func() events.Message[events.ChangeMessage] {
+		t.Helper()
+
+		select {
+		case msg := <-messages:
+			require.NoError(t, msg.Error())
+
+			return msg
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for message")
+
+			return nil
+		}
+	}
+
+	msg := receive()
+	assert.Equal(t, first.SubjectID, msg.Message().SubjectID)
+
+	// the message is processed but the ack is lost, the redelivered message is dropped.
+	require.NoError(t, store.MarkProcessed(ctx, idempotency.Key(msg)))
+	require.NoError(t, msg.Nak(0))
+
+	msg = receive()
+	assert.Equal(t, second.SubjectID, msg.Message().SubjectID)
+	require.NoError(t, msg.Ack())
+
+	processed, err := store.Processed(ctx, idempotency.Key(msg))
+	require.NoError(t, err)
+	assert.True(t, processed)
+}
+
+func TestMessagesForwarding(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	conn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	subscription, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	messages := idempotency.Messages(ctx, idempotency.NewLRUStore(0, 0), subscription)
+
+	published, err := conn.PublishChange(ctx, "test", events.ChangeMessage{SubjectID: gidx.MustNewID("testing"), EventType: string(events.CreateChangeType)})
+	require.NoError(t, err)
+
+	receive := func() events.Message[events.ChangeMessage] {
+		t.Helper()
+
+		select {
+		case msg := <-messages:
+			require.NoError(t, msg.Error())
+
+			return msg
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for message")
+
+			return nil
+		}
+	}
+
+	msg := receive()
+
+	sequenced, ok := msg.(events.SequencedMessage)
+	require.True(t, ok, "message does not implement SequencedMessage")
+	assert.Equal(t, published.(events.SequencedMessage).Stream(), sequenced.Stream())
+	assert.Equal(t, published.(events.SequencedMessage).Sequence(), sequenced.Sequence())
+
+	require.NoError(t, msg.InProgress())
+
+	naker, ok := msg.(events.NakWithErrorMessage)
+	require.True(t, ok, "message does not implement NakWithErrorMessage")
+	require.NoError(t, naker.NakWithError(0, errHandler))
+
+	// the naked message was not marked processed and is redelivered.
+	msg = receive()
+	assert.Equal(t, sequenced.Sequence(), msg.(events.SequencedMessage).Sequence())
+	require.NoError(t, msg.Ack())
+}
+
+func TestMessagesReplayed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	conn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	subscription, err := conn.SubscribeChanges(ctx, "create.test")
+	require.NoError(t, err)
+
+	messages := idempotency.Messages(ctx, idempotency.NewLRUStore(0, 0), subscription)
+
+	published, err := conn.PublishChange(ctx, "test", events.ChangeMessage{SubjectID: gidx.MustNewID("testing"), EventType: string(events.CreateChangeType)})
+	require.NoError(t, err)
+
+	receive := func() events.Message[events.ChangeMessage] {
+		t.Helper()
+
+		select {
+		case msg := <-messages:
+			require.NoError(t, msg.Error())
+
+			return msg
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for message")
+
+			return nil
+		}
+	}
+
+	handled := receive()
+	require.NoError(t, handled.Ack())
+
+	root := &cobra.Command{Use: "test"}
+
+	events.RegisterCobraCommand(root, func() events.NATSConfig { return nats.Config.NATS })
+
+	root.SetArgs([]string{"events", "replay", "changes.create.test"})
+	root.SetOut(io.Discard)
+
+	require.NoError(t, root.ExecuteContext(ctx))
+
+	// the replayed message keeps the id of the original but is processed again.
+	replayed := receive()
+	assert.Equal(t, published.ID(), replayed.Headers().Get(events.HeaderMessageID))
+	assert.NotEmpty(t, replayed.Headers().Get(events.HeaderReplayedFrom))
+	assert.NotEqual(t, idempotency.Key(handled), idempotency.Key(replayed))
+	require.NoError(t, replayed.Ack())
+}
@@ -0,0 +1,96 @@
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultLRUSize is the default number of keys remembered by an LRUStore.
+const DefaultLRUSize = 10000
+
+var _ Store = (*LRUStore)(nil)
+
+// LRUStore remembers processed keys in memory, up to size keys are remembered for the window.
+// Keys are not shared between processes and are lost on restart, use a shared store when consumers are scaled out.
+type LRUStore struct {
+	size   int
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// NewLRUStore creates a new LRUStore, zero values use DefaultLRUSize and DefaultWindow.
+func NewLRUStore(size int, window time.Duration) *LRUStore {
+	if size <= 0 {
+		size = DefaultLRUSize
+	}
+
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	return &LRUStore{
+		size:    size,
+		window:  window,
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// Processed reports whether the key was marked processed within the window.
+func (s *LRUStore) Processed(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return false, nil
+	}
+
+	if time.Now().After(elem.Value.(*lruEntry).expiresAt) {
+		s.remove(elem)
+
+		return false, nil
+	}
+
+	s.order.MoveToFront(elem)
+
+	return true, nil
+}
+
+// MarkProcessed records the key as processed, evicting the least recently used key when the store is full.
+func (s *LRUStore) MarkProcessed(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := time.Now().Add(s.window)
+
+	if elem, ok := s.entries[key]; ok {
+		elem.Value.(*lruEntry).expiresAt = expiresAt
+		s.order.MoveToFront(elem)
+
+		return nil
+	}
+
+	s.entries[key] = s.order.PushFront(&lruEntry{key: key, expiresAt: expiresAt})
+
+	for s.order.Len() > s.size {
+		s.remove(s.order.Back())
+	}
+
+	return nil
+}
+
+func (s *LRUStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(*lruEntry).key)
+}
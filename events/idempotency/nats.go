@@ -0,0 +1,65 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DefaultBucket is the default name of the processed messages NATS KV bucket.
+const DefaultBucket = "processed_messages"
+
+var _ Store = (*NATSKVStore)(nil)
+
+// NATSKVStore remembers processed keys in a NATS KV bucket, shared by every consumer using the bucket.
+// Keys expire after the window with the bucket TTL.
+type NATSKVStore struct {
+	kv jetstream.KeyValue
+}
+
+// NewNATSKVStore creates a new NATSKVStore, creating or updating the bucket with the window as its TTL.
+// A zero window uses DefaultWindow.
+func NewNATSKVStore(ctx context.Context, js jetstream.JetStream, bucket string, window time.Duration) (*NATSKVStore, error) {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      bucket,
+		Description: "processed message keys",
+		TTL:         window,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATSKVStore{kv: kv}, nil
+}
+
+// Processed reports whether the key was marked processed within the window.
+func (s *NATSKVStore) Processed(ctx context.Context, key string) (bool, error) {
+	if _, err := s.kv.Get(ctx, kvKey(key)); err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// MarkProcessed records the key as processed.
+func (s *NATSKVStore) MarkProcessed(ctx context.Context, key string) error {
+	_, err := s.kv.Put(ctx, kvKey(key), []byte(time.Now().UTC().Format(time.RFC3339Nano)))
+
+	return err
+}
+
+// kvKey encodes the key so keys with characters not allowed in KV keys may be stored.
+func kvKey(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
@@ -0,0 +1,93 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.infratographer.com/x/crdbx"
+)
+
+// DefaultTable is the default name of the processed messages table.
+const DefaultTable = "processed_messages"
+
+// ErrInvalidTable is returned when the processed messages table name is not a valid identifier.
+// It is crdbx.ErrInvalidTableName, shared by the tables of each package.
+var ErrInvalidTable = crdbx.ErrInvalidTableName
+
+var _ Store = (*SQLStore)(nil)
+
+// SQLStore remembers processed keys in a database table, shared by every consumer using the database.
+type SQLStore struct {
+	db     *sql.DB
+	table  string
+	window time.Duration
+}
+
+// Schema returns the statement to create the processed messages table with the provided name.
+// The statement is compatible with both CockroachDB and PostgreSQL and should be included in the service migrations.
+// Expired rows are removed with DeleteExpired, or on CockroachDB by adding row-level TTL to the table.
+func Schema(table string) (string, error) {
+	if err := crdbx.ValidateTableName(table); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	message_key TEXT PRIMARY KEY,
+	processed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);`, table), nil
+}
+
+// NewSQLStore creates a new SQLStore using the table created with Schema, such as with a database opened with crdbx.NewDB.
+// A zero window uses DefaultWindow.
+func NewSQLStore(db *sql.DB, table string, window time.Duration) (*SQLStore, error) {
+	if err := crdbx.ValidateTableName(table); err != nil {
+		return nil, err
+	}
+
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	return &SQLStore{
+		db:     db,
+		table:  table,
+		window: window,
+	}, nil
+}
+
+// Processed reports whether the key was marked processed within the window.
+func (s *SQLStore) Processed(ctx context.Context, key string) (bool, error) {
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE message_key = $1 AND processed_at > $2)", s.table) //nolint:gosec // table is validated
+
+	var processed bool
+
+	if err := s.db.QueryRowContext(ctx, query, key, time.Now().Add(-s.window)).Scan(&processed); err != nil {
+		return false, err
+	}
+
+	return processed, nil
+}
+
+// MarkProcessed records the key as processed.
+func (s *SQLStore) MarkProcessed(ctx context.Context, key string) error {
+	query := fmt.Sprintf(`INSERT INTO %s (message_key, processed_at) VALUES ($1, $2)
+ON CONFLICT (message_key) DO UPDATE SET processed_at = excluded.processed_at`, s.table) //nolint:gosec // table is validated
+
+	_, err := s.db.ExecContext(ctx, query, key, time.Now())
+
+	return err
+}
+
+// DeleteExpired deletes the keys processed before the window, returning the number of keys deleted.
+func (s *SQLStore) DeleteExpired(ctx context.Context) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE processed_at <= $1", s.table) //nolint:gosec // table is validated
+
+	result, err := s.db.ExecContext(ctx, query, time.Now().Add(-s.window))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// InProgressMessage is implemented by messages which may signal they are still being processed, such as Message.
+type InProgressMessage interface {
+	InProgress() error
+}
+
+// KeepInProgress calls InProgress on the message every interval until the context is done or the returned stop function is called,
+// preventing the message from being redelivered while a handler runs longer than the consumer AckWait.
+// The interval should be less than the AckWait, such as half of it. Errors signaling progress are passed to onError when not nil.
+// The stop function must be called before the message is acked, naked or terminated.
+func KeepInProgress(ctx context.Context, msg InProgressMessage, interval time.Duration, onError func(err error)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := msg.InProgress(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// InProgressMiddleware signals the handled message is still being processed every interval while the handler runs.
+// Use it for handlers which may run longer than the consumer AckWait, with an interval less than the AckWait.
+func InProgressMiddleware(interval time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, delivery Delivery) error {
+			msg, ok := delivery.Message.(InProgressMessage)
+			if !ok {
+				return next(ctx, delivery)
+			}
+
+			stop := KeepInProgress(ctx, msg, interval, nil)
+
+			err := next(ctx, delivery)
+
+			stop()
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestKeepInProgress(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{
+		Enabled:    true,
+		QueueGroup: "testing-in-progress",
+		AckWait:    time.Millisecond * 200,
+	})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, "create.test")
+	require.NoError(t, err)
+
+	_, err = conn.PublishChange(ctx, "test", testCreateChange())
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+
+	stop := events.KeepInProgress(ctx, receivedMsg, time.Millisecond*50, func(err error) {
+		assert.NoError(t, err)
+	})
+
+	// the message is not redelivered while processing exceeds the ack wait.
+	_, err = getSingleMessage(messages, time.Millisecond*500)
+	require.ErrorIs(t, err, errTimeout)
+
+	stop()
+
+	require.NoError(t, receivedMsg.Ack())
+	require.ErrorIs(t, receivedMsg.InProgress(), events.ErrMemoryMessageAlreadyAcked)
+
+	_, err = getSingleMessage(messages, time.Millisecond*300)
+	require.ErrorIs(t, err, errTimeout)
+}
+
+func TestNATSSubscriberAckConfig(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	natsCfg := nats.Config.NATS
+	natsCfg.QueueGroup = "testing-ack-config"
+	natsCfg.SubscriberAckWait = time.Millisecond * 500
+	natsCfg.SubscriberMaxAckPending = 10
+
+	conn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, "create.test")
+	require.NoError(t, err)
+
+	durable := events.NATSConsumerDurableName(natsCfg.QueueGroup, eventtools.Prefix+".changes.create.test")
+
+	consumer, err := nats.JetStream.ConsumerInfo("events-tests", durable)
+	require.NoError(t, err)
+	assert.Equal(t, time.Millisecond*500, consumer.Config.AckWait)
+	assert.Equal(t, 10, consumer.Config.MaxAckPending)
+
+	_, err = conn.PublishChange(ctx, "test", testCreateChange())
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+
+	handler := events.InProgressMiddleware(time.Millisecond * 100)(func(context.Context, events.Delivery) error {
+		time.Sleep(time.Second)
+
+		return nil
+	})
+
+	require.NoError(t, handler(ctx, events.Delivery{Message: receivedMsg}))
+	require.NoError(t, receivedMsg.Ack())
+
+	// the message was not redelivered while the handler ran.
+	_, err = getSingleMessage(messages, time.Millisecond*700)
+	require.ErrorIs(t, err, errTimeout)
+
+	natsCfg.SubscriberAckWait = -time.Second
+
+	_, err = events.NewNATSConnection(natsCfg)
+	require.ErrorIs(t, err, events.ErrNATSInvalidAckWait)
+}
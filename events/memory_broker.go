@@ -0,0 +1,608 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const memoryInboxPrefix = "_INBOX."
+
+var (
+	memoryBrokersMu sync.Mutex
+	memoryBrokers   = map[string]*memoryBroker{}
+)
+
+// MemoryMsg is the raw message routed by the in-memory provider.
+type MemoryMsg struct {
+	// Subject is the subject the message was published to.
+	Subject string
+	// Reply is the subject a response should be published to.
+	Reply string
+	// Header contains the message headers.
+	Header Headers
+	// Data is the encoded message payload.
+	Data []byte
+	// Sequence is the stream sequence of the message, requests are not stored and have no sequence.
+	Sequence uint64
+	// Timestamp is the time the message was published.
+	Timestamp time.Time
+}
+
+// memoryBroker stores published messages and routes them to consumers and request subscribers.
+// Stream messages are retained up to maxMessages so consumers created after a publish still receive them,
+// similar to a JetStream stream with a deliver all policy.
+type memoryBroker struct {
+	mu sync.Mutex
+
+	maxMessages int
+	lastSeq     uint64
+	stream      []*MemoryMsg
+
+	consumers map[string]*memoryConsumer
+	coreSubs  map[*memoryCoreSub]struct{}
+	inboxes   map[string]chan *MemoryMsg
+	scheduled map[string]*time.Timer
+
+	nextID uint64
+
+	// refs is the number of connections using the broker, guarded by memoryBrokersMu.
+	refs int
+}
+
+func newMemoryBroker(maxMessages int) *memoryBroker {
+	return &memoryBroker{
+		maxMessages: maxMessages,
+		consumers:   make(map[string]*memoryConsumer),
+		coreSubs:    make(map[*memoryCoreSub]struct{}),
+		inboxes:     make(map[string]chan *MemoryMsg),
+		scheduled:   make(map[string]*time.Timer),
+	}
+}
+
+// getMemoryBroker returns the shared broker for the provided name, creating one if needed.
+// An empty name always returns a new private broker. Each broker returned must be released with releaseMemoryBroker.
+func getMemoryBroker(name string, maxMessages int) *memoryBroker {
+	memoryBrokersMu.Lock()
+	defer memoryBrokersMu.Unlock()
+
+	broker, ok := memoryBrokers[name]
+	if !ok || name == "" {
+		broker = newMemoryBroker(maxMessages)
+
+		if name != "" {
+			memoryBrokers[name] = broker
+		}
+	}
+
+	broker.refs++
+
+	return broker
+}
+
+// releaseMemoryBroker releases a broker returned by getMemoryBroker. Once the last connection using the broker
+// releases it, the broker is removed from the registry and its scheduled messages are canceled.
+func releaseMemoryBroker(name string, broker *memoryBroker) {
+	memoryBrokersMu.Lock()
+	defer memoryBrokersMu.Unlock()
+
+	broker.refs--
+
+	if broker.refs > 0 {
+		return
+	}
+
+	if memoryBrokers[name] == broker {
+		delete(memoryBrokers, name)
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for id, timer := range broker.scheduled {
+		timer.Stop()
+
+		delete(broker.scheduled, id)
+	}
+}
+
+// publish stores the message in the stream and notifies all consumers matching the subject.
+func (b *memoryBroker) publish(subject string, header Headers, data []byte) *MemoryMsg {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastSeq++
+
+	msg := &MemoryMsg{
+		Subject:   subject,
+		Header:    header,
+		Data:      data,
+		Sequence:  b.lastSeq,
+		Timestamp: time.Now(),
+	}
+
+	b.stream = append(b.stream, msg)
+
+	if len(b.stream) > b.maxMessages {
+		b.stream = b.stream[len(b.stream)-b.maxMessages:]
+	}
+
+	for _, consumer := range b.consumers {
+		if subjectMatches(consumer.filter, subject) {
+			consumer.signal()
+		}
+	}
+
+	return msg
+}
+
+// schedule publishes the message at deliverAt, replacing any message already scheduled with the id.
+// Scheduled messages are only held in memory and are lost when the process exits.
+func (b *memoryBroker) schedule(id string, deliverAt time.Time, subject string, header Headers, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if timer, ok := b.scheduled[id]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+
+	timer = time.AfterFunc(time.Until(deliverAt), func() {
+		b.mu.Lock()
+
+		// the message was canceled or replaced while the timer fired.
+		if b.scheduled[id] != timer {
+			b.mu.Unlock()
+
+			return
+		}
+
+		delete(b.scheduled, id)
+
+		b.mu.Unlock()
+
+		b.publish(subject, header, data)
+	})
+
+	b.scheduled[id] = timer
+}
+
+// cancelScheduled cancels the message scheduled with the id, returning false if no message is pending.
+func (b *memoryBroker) cancelScheduled(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	timer, ok := b.scheduled[id]
+	if !ok {
+		return false
+	}
+
+	timer.Stop()
+
+	delete(b.scheduled, id)
+
+	return true
+}
+
+// streamMessage returns the retained message for the sequence and the first sequence still retained.
+func (b *memoryBroker) streamMessage(seq uint64) (*MemoryMsg, uint64) {
+	if len(b.stream) == 0 {
+		return nil, b.lastSeq + 1
+	}
+
+	first := b.stream[0].Sequence
+
+	if seq < first || seq > b.lastSeq {
+		return nil, first
+	}
+
+	return b.stream[seq-first], first
+}
+
+// consumer returns the consumer for the provided name, creating it if it doesn't exist.
+// Ephemeral consumers are created when name is empty and are removed once their last subscriber leaves.
+func (b *memoryBroker) consumer(name, filter string, ackWait time.Duration) *memoryConsumer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ephemeral := name == ""
+
+	if ephemeral {
+		b.nextID++
+
+		name = "ephemeral-" + strconv.FormatUint(b.nextID, base10)
+	}
+
+	consumer, ok := b.consumers[name]
+	if !ok {
+		consumer = &memoryConsumer{
+			broker:     b,
+			name:       name,
+			filter:     filter,
+			ephemeral:  ephemeral,
+			ackWait:    ackWait,
+			nextSeq:    1,
+			delivered:  make(map[uint64]uint64),
+			notify:     make(chan struct{}, 1),
+			deliveries: make(chan *memoryDelivery),
+		}
+
+		b.consumers[name] = consumer
+	}
+
+	consumer.subscribers++
+
+	if consumer.subscribers == 1 {
+		consumer.stop = make(chan struct{})
+
+		go consumer.run(consumer.stop)
+	}
+
+	return consumer
+}
+
+// release removes a subscriber from the consumer, stopping delivery when no subscribers remain.
+func (b *memoryBroker) release(consumer *memoryConsumer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	consumer.subscribers--
+
+	if consumer.subscribers > 0 {
+		return
+	}
+
+	close(consumer.stop)
+
+	if consumer.ephemeral {
+		delete(b.consumers, consumer.name)
+	}
+}
+
+// memoryCoreSub is a subscription which receives messages as they are published without being stored.
+type memoryCoreSub struct {
+	filter string
+	queue  string
+	ch     chan *MemoryMsg
+	done   <-chan struct{}
+}
+
+func (b *memoryBroker) coreSubscribe(filter, queue string, bufferSize int, done <-chan struct{}) *memoryCoreSub {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &memoryCoreSub{
+		filter: filter,
+		queue:  queue,
+		ch:     make(chan *MemoryMsg, bufferSize),
+		done:   done,
+	}
+
+	b.coreSubs[sub] = struct{}{}
+
+	return sub
+}
+
+func (b *memoryBroker) coreUnsubscribe(sub *memoryCoreSub) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.coreSubs, sub)
+}
+
+// request publishes a message to the subscribers matching subject and waits for a reply.
+// Subscribers sharing a queue receive the message only once.
+func (b *memoryBroker) request(ctx context.Context, subject string, header Headers, data []byte) (*MemoryMsg, error) {
+	b.mu.Lock()
+
+	b.nextID++
+
+	inbox := memoryInboxPrefix + strconv.FormatUint(b.nextID, base10)
+	respCh := make(chan *MemoryMsg, 1)
+
+	b.inboxes[inbox] = respCh
+
+	targets := b.coreTargets(subject)
+
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.inboxes, inbox)
+		b.mu.Unlock()
+	}()
+
+	if len(targets) == 0 {
+		return nil, ErrRequestNoResponders
+	}
+
+	msg := &MemoryMsg{
+		Subject:   subject,
+		Reply:     inbox,
+		Header:    header,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+
+	for _, sub := range targets {
+		select {
+		case sub.ch <- msg:
+		case <-sub.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	select {
+	case resp := <-respCh:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// coreTargets returns the subscriptions which should receive a message for subject.
+// Must be called while holding the broker lock.
+func (b *memoryBroker) coreTargets(subject string) []*memoryCoreSub {
+	var (
+		targets []*memoryCoreSub
+		queued  = map[string]bool{}
+	)
+
+	for sub := range b.coreSubs {
+		if !subjectMatches(sub.filter, subject) {
+			continue
+		}
+
+		if sub.queue != "" {
+			if queued[sub.queue] {
+				continue
+			}
+
+			queued[sub.queue] = true
+		}
+
+		targets = append(targets, sub)
+	}
+
+	return targets
+}
+
+// respond delivers a reply to the inbox waiting on it, replies to abandoned inboxes are dropped.
+func (b *memoryBroker) respond(reply string, header Headers, data []byte) *MemoryMsg {
+	msg := &MemoryMsg{
+		Subject:   reply,
+		Header:    header,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+
+	b.mu.Lock()
+	respCh, ok := b.inboxes[reply]
+	b.mu.Unlock()
+
+	if ok {
+		select {
+		case respCh <- msg:
+		default:
+		}
+	}
+
+	return msg
+}
+
+// memoryConsumer tracks the delivery state of stream messages for a durable or ephemeral consumer.
+// Subscribers sharing a consumer receive each message once, load balanced between them.
+type memoryConsumer struct {
+	broker    *memoryBroker
+	name      string
+	filter    string
+	ephemeral bool
+	ackWait   time.Duration
+
+	// guarded by broker.mu
+	nextSeq     uint64
+	redeliver   []uint64
+	delivered   map[uint64]uint64
+	subscribers int
+	stop        chan struct{}
+
+	notify     chan struct{}
+	deliveries chan *memoryDelivery
+}
+
+func (c *memoryConsumer) signal() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers pending messages to the consumer subscribers until stop is closed.
+func (c *memoryConsumer) run(stop <-chan struct{}) {
+	for {
+		delivery, redelivery := c.peek()
+		if delivery == nil {
+			select {
+			case <-c.notify:
+				continue
+			case <-stop:
+				return
+			}
+		}
+
+		select {
+		case c.deliveries <- delivery:
+			c.commit(delivery, redelivery)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// peek returns the next delivery without advancing the consumer.
+func (c *memoryConsumer) peek() (*memoryDelivery, bool) {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+
+	for len(c.redeliver) != 0 {
+		msg, _ := c.broker.streamMessage(c.redeliver[0])
+		if msg != nil {
+			return c.newDelivery(msg), true
+		}
+
+		// message is no longer retained, drop the redelivery.
+		delete(c.delivered, c.redeliver[0])
+		c.redeliver = c.redeliver[1:]
+	}
+
+	for c.nextSeq <= c.broker.lastSeq {
+		msg, first := c.broker.streamMessage(c.nextSeq)
+		if msg == nil {
+			c.nextSeq = first
+
+			continue
+		}
+
+		if subjectMatches(c.filter, msg.Subject) {
+			return c.newDelivery(msg), false
+		}
+
+		c.nextSeq++
+	}
+
+	return nil, false
+}
+
+// newDelivery must be called while holding the broker lock.
+func (c *memoryConsumer) newDelivery(msg *MemoryMsg) *memoryDelivery {
+	return &memoryDelivery{
+		consumer:   c,
+		msg:        msg,
+		deliveries: c.delivered[msg.Sequence] + 1,
+	}
+}
+
+// commit advances the consumer after a delivery was handed to a subscriber.
+func (c *memoryConsumer) commit(delivery *memoryDelivery, redelivery bool) {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+
+	if redelivery {
+		c.redeliver = c.redeliver[1:]
+	} else {
+		c.nextSeq = delivery.msg.Sequence + 1
+	}
+
+	// the subscriber may ack the delivery before it is committed, acked messages are no longer tracked.
+	if !delivery.acked {
+		c.delivered[delivery.msg.Sequence] = delivery.deliveries
+	}
+
+	if !delivery.done {
+		delivery.timer = time.AfterFunc(c.ackWait, delivery.expire)
+	}
+}
+
+// requeue schedules the sequence to be redelivered.
+func (c *memoryConsumer) requeue(seq uint64) {
+	c.broker.mu.Lock()
+	c.redeliver = append(c.redeliver, seq)
+	c.broker.mu.Unlock()
+
+	c.signal()
+}
+
+// memoryDelivery is a single delivery of a stream message to a consumer subscriber.
+type memoryDelivery struct {
+	consumer   *memoryConsumer
+	msg        *MemoryMsg
+	deliveries uint64
+	timer      *time.Timer
+	done       bool
+	acked      bool
+}
+
+// finish marks the delivery as complete, returning false if it was already completed.
+func (d *memoryDelivery) finish() bool {
+	d.consumer.broker.mu.Lock()
+	defer d.consumer.broker.mu.Unlock()
+
+	return d.finishLocked()
+}
+
+// finishLocked marks the delivery as complete, it must be called while holding the broker lock.
+func (d *memoryDelivery) finishLocked() bool {
+	if d.done {
+		return false
+	}
+
+	d.done = true
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+
+	return true
+}
+
+func (d *memoryDelivery) ack() error {
+	d.consumer.broker.mu.Lock()
+	defer d.consumer.broker.mu.Unlock()
+
+	if !d.finishLocked() {
+		return ErrMemoryMessageAlreadyAcked
+	}
+
+	d.acked = true
+
+	delete(d.consumer.delivered, d.msg.Sequence)
+
+	return nil
+}
+
+func (d *memoryDelivery) nak(delay time.Duration) error {
+	if !d.finish() {
+		return ErrMemoryMessageAlreadyAcked
+	}
+
+	if delay <= 0 {
+		d.consumer.requeue(d.msg.Sequence)
+
+		return nil
+	}
+
+	time.AfterFunc(delay, func() {
+		d.consumer.requeue(d.msg.Sequence)
+	})
+
+	return nil
+}
+
+func (d *memoryDelivery) inProgress() error {
+	d.consumer.broker.mu.Lock()
+	defer d.consumer.broker.mu.Unlock()
+
+	if d.done {
+		return ErrMemoryMessageAlreadyAcked
+	}
+
+	if d.timer != nil {
+		d.timer.Reset(d.consumer.ackWait)
+	}
+
+	return nil
+}
+
+func (d *memoryDelivery) term() error {
+	return d.ack()
+}
+
+// expire redelivers the message when it has not been acknowledged within the consumer ack wait.
+func (d *memoryDelivery) expire() {
+	if d.finish() {
+		d.consumer.requeue(d.msg.Sequence)
+	}
+}
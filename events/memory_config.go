@@ -0,0 +1,151 @@
+package events
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+var (
+	// MemoryDefaultSubscriberBufferSize is the default number of messages buffered for each subscription.
+	MemoryDefaultSubscriberBufferSize = 20
+	// MemoryDefaultAckWait is the default duration a delivered message may remain unacknowledged before it is redelivered.
+	MemoryDefaultAckWait = 30 * time.Second
+	// MemoryDefaultMaxMessages is the default number of messages retained by the in-memory stream.
+	MemoryDefaultMaxMessages = 10000
+)
+
+// MemoryConfig defines the in-memory provider configuration.
+// The in-memory provider is intended for unit tests and local single binary development.
+type MemoryConfig struct {
+	Enabled bool
+	// Name identifies a shared in-process broker.
+	// Connections created with the same name exchange messages, an empty name creates a broker private to the connection.
+	// The broker, along with its retained and scheduled messages, is discarded once every connection sharing it is shutdown.
+	Name            string
+	SubscribePrefix string
+	PublishPrefix   string
+	QueueGroup      string
+	Source          string
+	// Codec is the name of the codec published message payloads are marshaled with, defaults to json.
+	Codec string
+
+	// TrustedSigningKeys are the public nkeys of the signers received messages must be signed by.
+	// When set, messages which are not signed by a trusted key are returned with an ErrMessageUnverified error.
+	TrustedSigningKeys []string
+	// SignatureMaxAge is the max age of the signature of received messages, when set messages signed longer ago are
+	// returned with an ErrMessageUnverified error. Dead-lettered and replayed messages keep their original signature.
+	SignatureMaxAge time.Duration
+	// SignatureClockSkew is the clock skew allowed between signers and subscribers, when set messages signed further
+	// in the future, or older than the SignatureMaxAge plus the skew, are returned with an ErrMessageUnverified error.
+	SignatureClockSkew time.Duration
+	// EncryptedTopics are the topics, relative to the PublishPrefix, which published message payloads are encrypted for.
+	// Encryption keys are configured with WithMemoryEncryptionKeys.
+	EncryptedTopics []string
+
+	SubscriberBufferSize int
+	AckWait              time.Duration
+	MaxMessages          int
+
+	logger         *zap.SugaredLogger
+	schemas        *SchemaRegistry
+	signer         MessageSigner
+	encryptionKeys []KeyEncrypter
+}
+
+// Configured checks whether the provider has been configured.
+func (c MemoryConfig) Configured() bool {
+	return c.Enabled
+}
+
+// Validate ensures the configuration is valid.
+func (c MemoryConfig) Validate() error {
+	return validateMessageConfig("", c.Codec, c.TrustedSigningKeys)
+}
+
+// WithDefaults sets default values for the field unset.
+func (c MemoryConfig) WithDefaults() MemoryConfig {
+	if c.logger == nil {
+		c.logger = zap.NewNop().Sugar()
+	}
+
+	if c.schemas == nil {
+		c.schemas = DefaultSchemaRegistry
+	}
+
+	if c.SubscriberBufferSize == 0 {
+		c.SubscriberBufferSize = MemoryDefaultSubscriberBufferSize
+	}
+
+	if c.AckWait == 0 {
+		c.AckWait = MemoryDefaultAckWait
+	}
+
+	if c.MaxMessages == 0 {
+		c.MaxMessages = MemoryDefaultMaxMessages
+	}
+
+	return c
+}
+
+// MemoryOption defines an in-memory provider configuration option.
+type MemoryOption func(c *MemoryConfig) error
+
+// WithMemoryLogger sets the logger for the in-memory connection.
+func WithMemoryLogger(logger *zap.SugaredLogger) MemoryOption {
+	return func(c *MemoryConfig) error {
+		c.logger = logger
+
+		return nil
+	}
+}
+
+// WithMemorySchemaRegistry sets the schema registry used to version and validate messages, defaults to DefaultSchemaRegistry.
+func WithMemorySchemaRegistry(registry *SchemaRegistry) MemoryOption {
+	return func(c *MemoryConfig) error {
+		c.schemas = registry
+
+		return nil
+	}
+}
+
+// WithMemorySigner signs published messages with the signer.
+func WithMemorySigner(signer MessageSigner) MemoryOption {
+	return func(c *MemoryConfig) error {
+		c.signer = signer
+
+		return nil
+	}
+}
+
+// WithMemoryEncryptionKeys sets the keys used to encrypt messages published to the EncryptedTopics and decrypt received messages.
+// Messages are encrypted with the first key, all keys are used to decrypt messages so keys may be rotated.
+func WithMemoryEncryptionKeys(keys ...KeyEncrypter) MemoryOption {
+	return func(c *MemoryConfig) error {
+		c.encryptionKeys = append(c.encryptionKeys, keys...)
+
+		return nil
+	}
+}
+
+// MustViperFlagsForMemory returns the cobra flags and viper config for the in-memory provider.
+func MustViperFlagsForMemory(v *viper.Viper, _ *pflag.FlagSet, appName string) {
+	v.MustBindEnv("events.memory.enabled")
+	v.MustBindEnv("events.memory.name")
+	v.MustBindEnv("events.memory.subscribePrefix")
+	v.MustBindEnv("events.memory.publishPrefix")
+	v.MustBindEnv("events.memory.queueGroup")
+	v.MustBindEnv("events.memory.source")
+	v.MustBindEnv("events.memory.codec")
+	v.MustBindEnv("events.memory.trustedSigningKeys")
+	v.MustBindEnv("events.memory.signatureMaxAge")
+	v.MustBindEnv("events.memory.signatureClockSkew")
+	v.MustBindEnv("events.memory.encryptedTopics")
+	v.MustBindEnv("events.memory.subscriberBufferSize")
+	v.MustBindEnv("events.memory.ackWait")
+	v.MustBindEnv("events.memory.maxMessages")
+
+	v.SetDefault("events.memory.source", appName)
+}
@@ -0,0 +1,182 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const memoryTracerName = tracerName + ":memory"
+
+var _ Connection = (*MemoryConnection)(nil)
+
+// MemoryConnection implements Connection using an in-process broker.
+type MemoryConnection struct {
+	logger   *zap.SugaredLogger
+	tracer   trace.Tracer
+	broker   *memoryBroker
+	cfg      MemoryConfig
+	security *messageSecurity
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	wg        sync.WaitGroup
+}
+
+// Shutdown closes all subscriptions created by the connection and waits for them to complete.
+// The broker is discarded once the last connection sharing it is shutdown.
+func (c *MemoryConnection) Shutdown(ctx context.Context) error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		releaseMemoryBroker(c.cfg.Name, c.broker)
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		c.wg.Wait()
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Source returns the underlying in-memory broker.
+func (c *MemoryConnection) Source() any {
+	return c.broker
+}
+
+// runTyped runs the typed message operation with the connection.
+func (c *MemoryConnection) runTyped(ctx context.Context, op typedOperation) (any, error) {
+	return op.memory(ctx, c)
+}
+
+// HealthCheck returns ErrMemoryConnectionClosed if the connection has been shutdown.
+func (c *MemoryConnection) HealthCheck(_ context.Context) error {
+	if c.isClosed() {
+		return ErrMemoryConnectionClosed
+	}
+
+	return nil
+}
+
+// CancelScheduled cancels the pending scheduled message with the provided message id.
+// Scheduled messages are held by the broker, so messages scheduled by any connection sharing the broker may be canceled.
+func (c *MemoryConnection) CancelScheduled(_ context.Context, id string) error {
+	if !c.broker.cancelScheduled(id) {
+		return ErrScheduledMessageNotFound
+	}
+
+	return nil
+}
+
+func (c *MemoryConnection) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *MemoryConnection) durableName(topic string) string {
+	return NATSConsumerDurableName(c.cfg.QueueGroup, topic)
+}
+
+func (c *MemoryConnection) buildSubscribeSubject(parts ...string) string {
+	return buildSubject(c.cfg.SubscribePrefix, parts...)
+}
+
+func (c *MemoryConnection) buildPublishSubject(parts ...string) string {
+	return buildSubject(c.cfg.PublishPrefix, parts...)
+}
+
+func newMemoryMessage[T any](ctx context.Context, conn *MemoryConnection, subject string, message T) (*MemoryMessage[T], error) {
+	codec, err := codecByName(conn.cfg.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := codec.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	msgID, err := messageID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := publishHeaders(ctx, conn.cfg.Source, message)
+	headers.Set(HeaderMessageID, msgID)
+
+	if !isJSONCodec(codec.Name()) {
+		headers.Set(HeaderCodec, codec.Name())
+	}
+
+	if schema := schemaFor[T](conn.cfg.schemas); schema != nil {
+		if err := schema.validatePublish(codec, data); err != nil {
+			return nil, err
+		}
+
+		headers.Set(HeaderSchemaVersion, schema.version())
+	}
+
+	data, err = conn.security.seal(subject, headers, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemoryMessage[T]{
+		conn: conn,
+		source: &MemoryMsg{
+			Subject: subject,
+			Header:  headers,
+			Data:    data,
+		},
+		message: message,
+	}, nil
+}
+
+// NewMemoryConnection creates a new in-memory connection.
+func NewMemoryConnection(config MemoryConfig, options ...MemoryOption) (*MemoryConnection, error) {
+	mc := config.WithDefaults()
+
+	if err := mc.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, opt := range options {
+		if err := opt(&mc); err != nil {
+			return nil, err
+		}
+	}
+
+	if mc.QueueGroup == "" {
+		mc.logger.Warn("Memory QueueGroup is not set. Subscriptions will not be durable.")
+	}
+
+	security, err := newMessageSecurity(mc.signer, mc.TrustedSigningKeys, mc.SignatureMaxAge, mc.SignatureClockSkew, mc.encryptionKeys, mc.PublishPrefix, mc.EncryptedTopics)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemoryConnection{
+		logger:   mc.logger,
+		tracer:   otel.GetTracerProvider().Tracer(memoryTracerName),
+		broker:   getMemoryBroker(mc.Name, mc.MaxMessages),
+		cfg:      mc,
+		security: security,
+		closed:   make(chan struct{}),
+	}, nil
+}
@@ -0,0 +1,17 @@
+package events
+
+import "errors"
+
+var (
+	// ErrMemoryConnectionClosed is returned when the in-memory connection has been shutdown.
+	ErrMemoryConnectionClosed = errors.New("memory connection closed")
+
+	// ErrMemoryMessageAlreadyAcked is returned when a message is acked, nacked or terminated more than once.
+	ErrMemoryMessageAlreadyAcked = errors.New("memory message already acknowledged")
+
+	// ErrMemoryMessageNotAckable is returned when acknowledging a message which was not delivered from a stream, such as a request.
+	ErrMemoryMessageNotAckable = errors.New("memory message was not delivered from a stream and cannot be acknowledged")
+
+	// ErrMemoryMessageNoReplySubject is returned when replying to a request which has no reply subject defined.
+	ErrMemoryMessageNoReplySubject = errors.New("unable to reply to request, no reply subject specified")
+)
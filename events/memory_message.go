@@ -0,0 +1,209 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func memoryDecodeMessage[T any](conn *MemoryConnection, mMsg *MemoryMsg, delivery *memoryDelivery) *MemoryMessage[T] {
+	msg := &MemoryMessage[T]{
+		conn:     conn,
+		source:   mMsg,
+		delivery: delivery,
+	}
+
+	payload, err := conn.security.open(mMsg.Subject, mMsg.Header.Get, mMsg.Header.Keys(), mMsg.Data)
+	if err == nil {
+		err = decodePayload(mMsg.Header.Get, mMsg.Header.Keys(), payload, &msg.message, schemaFor[T](conn.cfg.schemas))
+	}
+
+	if err != nil {
+		msg.err = err
+	}
+
+	applyHeaderTraceContext(mMsg.Header, &msg.message)
+
+	return msg
+}
+
+var _ Message[any] = (*MemoryMessage[any])(nil)
+
+// MemoryMessage implements Message
+type MemoryMessage[T any] struct {
+	conn     *MemoryConnection
+	source   *MemoryMsg
+	delivery *memoryDelivery
+	process  *processSpan
+	message  T
+	err      error
+}
+
+// Connection returns the underlying Connection.
+func (m *MemoryMessage[T]) Connection() Connection {
+	return m.conn
+}
+
+// ID returns the stream sequence of the message.
+// Scheduled messages which are not yet stored return the id the message was scheduled with instead.
+func (m *MemoryMessage[T]) ID() string {
+	if m.source.Sequence == 0 && m.source.Header.Get(HeaderMessageID) != "" {
+		return m.source.Header.Get(HeaderMessageID)
+	}
+
+	return strconv.FormatUint(m.source.Sequence, base10)
+}
+
+// Topic returns the subject the message was published to.
+func (m *MemoryMessage[T]) Topic() string {
+	return m.source.Subject
+}
+
+// Message returns the decoded message object.
+func (m *MemoryMessage[T]) Message() T {
+	return m.message
+}
+
+// Headers returns the message headers.
+func (m *MemoryMessage[T]) Headers() Headers {
+	if m.source.Header == nil {
+		m.source.Header = Headers{}
+	}
+
+	return m.source.Header
+}
+
+// Ack acks the message.
+func (m *MemoryMessage[T]) Ack() error {
+	if m.delivery == nil {
+		return ErrMemoryMessageNotAckable
+	}
+
+	return m.process.end(ackActionAck, m.delivery.ack())
+}
+
+// Nak nacks the message, redelivering it after the provided delay.
+func (m *MemoryMessage[T]) Nak(delay time.Duration) error {
+	if m.delivery == nil {
+		return ErrMemoryMessageNotAckable
+	}
+
+	return m.process.end(ackActionNak, m.delivery.nak(delay))
+}
+
+// Term terminates the message from being processed again.
+func (m *MemoryMessage[T]) Term() error {
+	if m.delivery == nil {
+		return ErrMemoryMessageNotAckable
+	}
+
+	return m.process.end(ackActionTerm, m.delivery.term())
+}
+
+// InProgress resets the redelivery timer of the message, extending the time to process the message by the AckWait.
+func (m *MemoryMessage[T]) InProgress() error {
+	if m.delivery == nil {
+		return ErrMemoryMessageNotAckable
+	}
+
+	return m.delivery.inProgress()
+}
+
+// Context returns the context of the process span started when the message was delivered to a subscription.
+// The span is ended when the message is acked, naked or terminated.
+// Messages which were not delivered to a subscription return a background context.
+func (m *MemoryMessage[T]) Context() context.Context {
+	return m.process.context()
+}
+
+// Timestamp returns the time the message was published.
+func (m *MemoryMessage[T]) Timestamp() time.Time {
+	return m.source.Timestamp
+}
+
+// Deliveries returns the number of times the message was delivered.
+func (m *MemoryMessage[T]) Deliveries() uint64 {
+	if m.delivery == nil {
+		return 0
+	}
+
+	return m.delivery.deliveries
+}
+
+// Error returns any error with the message.
+func (m *MemoryMessage[T]) Error() error {
+	if m.err != nil {
+		return m.err
+	}
+
+	return nil
+}
+
+// Source returns the underlying *MemoryMsg.
+func (m *MemoryMessage[T]) Source() any {
+	return m.source
+}
+
+func (m *MemoryMessage[T]) publish(ctx context.Context) error {
+	if m.conn.isClosed() {
+		return ErrMemoryConnectionClosed
+	}
+
+	if deliverAt, ok := scheduledDeliverAt(ctx); ok {
+		id := m.source.Header.Get(HeaderMessageID)
+
+		m.conn.broker.schedule(id, deliverAt, m.source.Subject, m.source.Header, m.source.Data)
+
+		return nil
+	}
+
+	m.source = m.conn.broker.publish(m.source.Subject, m.source.Header, m.source.Data)
+
+	return nil
+}
+
+func (m *MemoryMessage[T]) request(ctx context.Context) (Message[AuthRelationshipResponse], error) {
+	return memoryRequest[AuthRelationshipResponse](ctx, m)
+}
+
+var _ Request[AuthRelationshipRequest, AuthRelationshipResponse] = (*MemoryAuthRelationshipRequest)(nil)
+
+// MemoryAuthRelationshipRequest implements Request for AuthRelationshipRequest / AuthRelationshipResponse
+type MemoryAuthRelationshipRequest struct {
+	*MemoryMessage[AuthRelationshipRequest]
+}
+
+// Reply responds to an AuthRelationshipRequest with an AuthRelationshipResponse.
+func (r *MemoryAuthRelationshipRequest) Reply(ctx context.Context, message AuthRelationshipResponse) (Message[AuthRelationshipResponse], error) {
+	ctx, span := r.conn.tracer.Start(ctx, "events.Reply")
+
+	defer span.End()
+
+	if r.source.Reply == "" {
+		span.RecordError(ErrMemoryMessageNoReplySubject)
+		span.SetStatus(codes.Error, ErrMemoryMessageNoReplySubject.Error())
+
+		return nil, ErrMemoryMessageNoReplySubject
+	}
+
+	if err := message.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	respMsg, err := newMemoryMessage(ctx, r.conn, r.source.Reply, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	respMsg.source = r.conn.broker.respond(r.source.Reply, respMsg.source.Header, respMsg.source.Data)
+
+	return respMsg, nil
+}
@@ -0,0 +1,188 @@
+package events
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.infratographer.com/x/echojwtx"
+	"go.infratographer.com/x/gidx"
+)
+
+// PublishAuthRelationshipRequest publishes an AuthRelationshipRequest message and blocks until an AuthRelationshipResponse is provided.
+func (c *MemoryConnection) PublishAuthRelationshipRequest(ctx context.Context, topic string, message AuthRelationshipRequest) (Message[AuthRelationshipResponse], error) {
+	ctx, span := c.tracer.Start(ctx, "events.memory.PublishAuthRelationshipRequest", trace.WithAttributes(
+		attribute.String("events.subject_type", topic),
+		attribute.String("events.subject_id", message.ObjectID.String()),
+		attribute.String("events.event_type", string(message.Action)),
+	))
+
+	defer span.End()
+
+	if err := message.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	topic = c.buildPublishSubject("auth", "relationships", string(message.Action), topic)
+
+	reqMsg, err := newMemoryMessage(ctx, c, topic, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	c.logger.Debugf("publishing auth relation request message to topic %s", topic)
+
+	respMsg, err := reqMsg.request(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	return respMsg, nil
+}
+
+// PublishChange publishes a ChangeMessage.
+func (c *MemoryConnection) PublishChange(ctx context.Context, topic string, message ChangeMessage) (Message[ChangeMessage], error) {
+	ctx, span := c.tracer.Start(ctx, "events.memory.PublishChange", trace.WithAttributes(
+		attribute.String("events.subject_type", topic),
+		attribute.String("events.subject_id", message.SubjectID.String()),
+		attribute.String("events.event_type", message.EventType),
+		attribute.String("events.source", message.Source),
+	))
+
+	defer span.End()
+
+	if err := message.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	topic = c.buildPublishSubject("changes", message.EventType, topic)
+
+	message.Source = c.cfg.Source
+
+	if message.ActorID == gidx.NullPrefixedID {
+		id, ok := ctx.Value(echojwtx.ActorCtxKey).(string)
+		if ok {
+			message.ActorID = gidx.PrefixedID(id)
+		} else {
+			message.ActorID = "unknown-actor"
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String(
+			"events.actor_id",
+			message.ActorID.String(),
+		),
+	)
+
+	msg, err := newMemoryMessage(ctx, c, topic, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	c.logger.Debugf("publishing change message to topic %s", topic)
+
+	if err = msg.publish(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return msg, err
+	}
+
+	return msg, nil
+}
+
+// PublishEvent publishes an EventMessage.
+func (c *MemoryConnection) PublishEvent(ctx context.Context, topic string, message EventMessage) (Message[EventMessage], error) {
+	ctx, span := c.tracer.Start(ctx, "events.memory.PublishEvent", trace.WithAttributes(
+		attribute.String("events.subject_type", topic),
+		attribute.String("events.subject_id", message.SubjectID.String()),
+		attribute.String("events.event_type", message.EventType),
+		attribute.String("events.source", message.Source),
+	))
+
+	defer span.End()
+
+	if err := message.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	topic = c.buildPublishSubject("events", message.EventType, topic)
+
+	msg, err := newMemoryMessage(ctx, c, topic, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	c.logger.Debugf("publishing event message to topic %s", topic)
+
+	if err = msg.publish(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return msg, err
+	}
+
+	return msg, nil
+}
+
+// memoryPublish publishes a message of a custom message family.
+func memoryPublish[T Validator](ctx context.Context, c *MemoryConnection, family, topic string, message T) (Message[T], error) {
+	ctx, span := c.tracer.Start(ctx, "events.memory.Publish", trace.WithAttributes(
+		attribute.String("events.family", family),
+		attribute.String("events.subject_type", topic),
+	))
+
+	defer span.End()
+
+	if err := message.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	topic = c.buildPublishSubject(typedSubjectParts(family, topic, message)...)
+
+	msg, err := newMemoryMessage(ctx, c, topic, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	c.logger.Debugf("publishing %s message to topic %s", family, topic)
+
+	if err = msg.publish(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return msg, err
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// memoryRequest sends the message to the request subscribers and waits for the response, decoding it as TResp.
+func memoryRequest[TResp, TReq any](ctx context.Context, m *MemoryMessage[TReq]) (Message[TResp], error) {
+	if m.conn.isClosed() {
+		return nil, ErrMemoryConnectionClosed
+	}
+
+	mMsg, err := m.conn.broker.request(ctx, m.source.Subject, m.source.Header, m.source.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return memoryDecodeMessage[TResp](m.conn, mMsg, nil), nil
+}
+
+// memoryPublishRequest publishes a request of a custom message family and waits for the response.
+func memoryPublishRequest[TReq, TResp any](ctx context.Context, c *MemoryConnection, family, topic string, message TReq) (Message[TResp], error) {
+	ctx, span := c.tracer.Start(ctx, "events.memory.Request", trace.WithAttributes(
+		attribute.String("events.family", family),
+		attribute.String("events.subject_type", topic),
+	))
+
+	defer span.End()
+
+	topic = c.buildPublishSubject(requestSubjectParts(family, topic, message)...)
+
+	reqMsg, err := newMemoryMessage(ctx, c, topic, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	c.logger.Debugf("publishing %s request to topic %s", family, topic)
+
+	respMsg, err := memoryRequest[TResp](ctx, reqMsg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	return respMsg, nil
+}
+
+// memorySubscribeRequests subscribes to requests of a custom message family.
+// Requests are load balanced between the connections sharing the QueueGroup.
+func memorySubscribeRequests[TReq, TResp any](ctx context.Context, c *MemoryConnection, family, topic string) (<-chan Request[TReq, TResp], error) {
+	topic = c.buildSubscribeSubject(requestSubjectToken, family, topic)
+
+	memCh, err := c.coreSubscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debugf("subscribing to %s requests on topic %s", family, topic)
+
+	msgCh := make(chan Request[TReq, TResp], c.cfg.SubscriberBufferSize)
+
+	go func() {
+		defer close(msgCh)
+
+		for mMsg := range memCh {
+			req := &MemoryRequest[TReq, TResp]{
+				MemoryMessage: memoryDecodeMessage[TReq](c, mMsg, nil),
+			}
+
+			select {
+			case msgCh <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgCh, nil
+}
+
+var _ Request[any, any] = (*MemoryRequest[any, any])(nil)
+
+// MemoryRequest implements Request for requests of a custom message family.
+type MemoryRequest[TReq, TResp any] struct {
+	*MemoryMessage[TReq]
+}
+
+// Reply responds to the request with the response message.
+// Responses implementing Validator are validated before the reply is sent.
+func (r *MemoryRequest[TReq, TResp]) Reply(ctx context.Context, message TResp) (Message[TResp], error) {
+	ctx, span := r.conn.tracer.Start(ctx, "events.Reply")
+
+	defer span.End()
+
+	if r.source.Reply == "" {
+		span.RecordError(ErrMemoryMessageNoReplySubject)
+		span.SetStatus(codes.Error, ErrMemoryMessageNoReplySubject.Error())
+
+		return nil, ErrMemoryMessageNoReplySubject
+	}
+
+	if err := validateReply(ctx, message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	respMsg, err := newMemoryMessage(ctx, r.conn, r.source.Reply, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	respMsg.source = r.conn.broker.respond(r.source.Reply, respMsg.source.Header, respMsg.source.Data)
+
+	return respMsg, nil
+}
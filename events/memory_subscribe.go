@@ -0,0 +1,174 @@
+package events
+
+import (
+	"context"
+)
+
+func (c *MemoryConnection) coreSubscribe(ctx context.Context, subject string) (<-chan *MemoryMsg, error) {
+	if c.isClosed() {
+		return nil, ErrMemoryConnectionClosed
+	}
+
+	sub := c.broker.coreSubscribe(subject, NATSConsumerDurableName(c.cfg.QueueGroup, subject), c.cfg.SubscriberBufferSize, c.closed)
+
+	msgCh := make(chan *MemoryMsg, c.cfg.SubscriberBufferSize)
+
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+		defer close(msgCh)
+		defer c.broker.coreUnsubscribe(sub)
+
+		for {
+			select {
+			case mMsg := <-sub.ch:
+				select {
+				case msgCh <- mMsg:
+				case <-ctx.Done():
+					return
+				case <-c.closed:
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-c.closed:
+				return
+			}
+		}
+	}()
+
+	return msgCh, nil
+}
+
+func (c *MemoryConnection) streamSubscribe(ctx context.Context, subject string) (<-chan *memoryDelivery, error) {
+	if c.isClosed() {
+		return nil, ErrMemoryConnectionClosed
+	}
+
+	consumer := c.broker.consumer(c.durableName(subject), subject, c.cfg.AckWait)
+
+	deliveryCh := make(chan *memoryDelivery, c.cfg.SubscriberBufferSize)
+
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+		defer close(deliveryCh)
+		defer c.broker.release(consumer)
+
+		for {
+			select {
+			case delivery := <-consumer.deliveries:
+				select {
+				case deliveryCh <- delivery:
+				case <-ctx.Done():
+					// return the message to the consumer so another subscriber may receive it.
+					_ = delivery.nak(0)
+
+					return
+				case <-c.closed:
+					_ = delivery.nak(0)
+
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-c.closed:
+				return
+			}
+		}
+	}()
+
+	return deliveryCh, nil
+}
+
+func memorySubscriptionMessageChan[T any](ctx context.Context, conn *MemoryConnection, subject string, bufferSize int, deliveryCh <-chan *memoryDelivery) chan Message[T] {
+	msgCh := make(chan Message[T], bufferSize)
+
+	go func() {
+		defer close(msgCh)
+
+		for delivery := range deliveryCh {
+			msg := memoryDecodeMessage[T](conn, delivery.msg, delivery)
+			msg.process = startProcessSpan(ctx, conn.tracer, processSpanConfig{
+				system:       "memory",
+				subject:      delivery.msg.Subject,
+				subscription: subject,
+				group:        conn.cfg.QueueGroup,
+				messageID:    msg.ID(),
+				deliveries:   msg.Deliveries(),
+				headers:      delivery.msg.Header,
+				err:          msg.err,
+			}, msg.message)
+
+			select {
+			case msgCh <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgCh
+}
+
+func memorySubscriptionAuthRelationshipRequestChan(ctx context.Context, conn *MemoryConnection, bufferSize int, memCh <-chan *MemoryMsg) chan Request[AuthRelationshipRequest, AuthRelationshipResponse] {
+	msgCh := make(chan Request[AuthRelationshipRequest, AuthRelationshipResponse], bufferSize)
+
+	go func() {
+		defer close(msgCh)
+
+		for mMsg := range memCh {
+			req := &MemoryAuthRelationshipRequest{
+				MemoryMessage: memoryDecodeMessage[AuthRelationshipRequest](conn, mMsg, nil),
+			}
+
+			select {
+			case msgCh <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgCh
+}
+
+// SubscribeAuthRelationshipRequests creates a new subscription parsing incoming messages as AuthRelationshipRequest messages and returning a new Message channel.
+func (c *MemoryConnection) SubscribeAuthRelationshipRequests(ctx context.Context, topic string) (<-chan Request[AuthRelationshipRequest, AuthRelationshipResponse], error) {
+	topic = c.buildSubscribeSubject("auth", "relationships", topic)
+
+	memCh, err := c.coreSubscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debugf("subscribing to auth relation request message on topic %s", topic)
+
+	return memorySubscriptionAuthRelationshipRequestChan(ctx, c, c.cfg.SubscriberBufferSize, memCh), nil
+}
+
+// SubscribeChanges creates a new subscription parsing incoming messages as ChangeMessage messages and returning a new Message channel.
+func (c *MemoryConnection) SubscribeChanges(ctx context.Context, topic string) (<-chan Message[ChangeMessage], error) {
+	return memorySubscribe[ChangeMessage](ctx, c, "changes", topic)
+}
+
+// SubscribeEvents creates a new subscription parsing incoming messages as EventMessage messages and returning a new Message channel.
+func (c *MemoryConnection) SubscribeEvents(ctx context.Context, topic string) (<-chan Message[EventMessage], error) {
+	return memorySubscribe[EventMessage](ctx, c, "events", topic)
+}
+
+// memorySubscribe creates a new subscription parsing incoming messages of the family as T.
+func memorySubscribe[T any](ctx context.Context, c *MemoryConnection, family, topic string) (<-chan Message[T], error) {
+	topic = c.buildSubscribeSubject(family, topic)
+
+	deliveryCh, err := c.streamSubscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debugf("subscribing to %s message on topic %s", family, topic)
+
+	return memorySubscriptionMessageChan[T](ctx, c, topic, c.cfg.SubscriberBufferSize, deliveryCh), nil
+}
@@ -0,0 +1,290 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestMemoryPublishAndSubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name       string
+		queueGroup string
+	}{
+		{
+			name:       "with ephemeral consumer",
+			queueGroup: "",
+		},
+		{
+			name:       "with durable consumer",
+			queueGroup: "testing-durable",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, err := events.NewMemoryConnection(events.MemoryConfig{
+				Enabled:         true,
+				QueueGroup:      tc.queueGroup,
+				PublishPrefix:   "com.infratographer.testing",
+				SubscribePrefix: "com.infratographer.testing",
+			})
+			require.NoError(t, err)
+
+			defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+			change := testCreateChange()
+
+			msg, err := conn.PublishChange(ctx, "test", change)
+			require.NoError(t, err)
+			require.Equal(t, change, msg.Message())
+			assert.Equal(t, "com.infratographer.testing.changes.create.test", msg.Topic())
+
+			change2 := testCreateChange()
+			change2.ActorID = ""
+
+			msg, err = conn.PublishChange(ctx, "test", change2)
+			require.NoError(t, err)
+			require.NotEqual(t, change2, msg.Message())
+
+			_, err = conn.PublishEvent(ctx, "test", events.EventMessage{
+				SubjectID: gidx.MustNewID("testing"),
+				EventType: "ignored",
+			})
+			require.NoError(t, err)
+
+			messages, err := conn.SubscribeChanges(ctx, ">")
+			require.NoError(t, err)
+
+			receivedMsg, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, receivedMsg.Error())
+			assert.EqualValues(t, change, receivedMsg.Message())
+			assert.Equal(t, uint64(1), receivedMsg.Deliveries())
+			assert.NoError(t, receivedMsg.Ack())
+			assert.ErrorIs(t, receivedMsg.Ack(), events.ErrMemoryMessageAlreadyAcked)
+
+			receivedMsg, err = getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, receivedMsg.Error())
+			assert.Equal(t, "unknown-actor", receivedMsg.Message().ActorID.String())
+			assert.NoError(t, receivedMsg.Ack())
+
+			_, err = getSingleMessage(messages, time.Millisecond*100)
+			require.ErrorIs(t, err, errTimeout)
+		})
+	}
+}
+
+func TestMemoryRedelivery(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{
+		Enabled:    true,
+		QueueGroup: "testing-redelivery",
+		AckWait:    time.Millisecond * 200,
+	})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, "create.test")
+	require.NoError(t, err)
+
+	change := testCreateChange()
+
+	_, err = conn.PublishChange(ctx, "test", change)
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receivedMsg.Deliveries())
+	require.NoError(t, receivedMsg.Nak(0))
+
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), receivedMsg.Deliveries())
+	assert.EqualValues(t, change, receivedMsg.Message())
+
+	// let the ack wait expire
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), receivedMsg.Deliveries())
+	require.NoError(t, receivedMsg.Term())
+
+	_, err = getSingleMessage(messages, time.Millisecond*300)
+	require.ErrorIs(t, err, errTimeout)
+}
+
+func TestMemoryQueueGroup(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := events.MemoryConfig{
+		Enabled:    true,
+		Name:       "testing-queue-group",
+		QueueGroup: "testing-queue",
+	}
+
+	conn1, err := events.NewMemoryConnection(cfg)
+	require.NoError(t, err)
+
+	defer conn1.Shutdown(ctx) //nolint:errcheck // within test
+
+	conn2, err := events.NewMemoryConnection(cfg)
+	require.NoError(t, err)
+
+	defer conn2.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages1, err := conn1.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	messages2, err := conn2.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	const total = 10
+
+	for i := 0; i < total; i++ {
+		_, err := conn1.PublishChange(ctx, "test", testCreateChange())
+		require.NoError(t, err)
+	}
+
+	received := map[string]bool{}
+
+	for len(received) < total {
+		select {
+		case msg := <-messages1:
+			received[msg.ID()] = true
+
+			require.NoError(t, msg.Ack())
+		case msg := <-messages2:
+			received[msg.ID()] = true
+
+			require.NoError(t, msg.Ack())
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for messages, received %d", len(received))
+		}
+	}
+
+	select {
+	case msg := <-messages1:
+		t.Fatalf("unexpected duplicate message %s", msg.ID())
+	case msg := <-messages2:
+		t.Fatalf("unexpected duplicate message %s", msg.ID())
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestMemorySharedBrokerShutdown(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := events.MemoryConfig{
+		Enabled: true,
+		Name:    "testing-shared-shutdown",
+	}
+
+	conn1, err := events.NewMemoryConnection(cfg)
+	require.NoError(t, err)
+
+	conn2, err := events.NewMemoryConnection(cfg)
+	require.NoError(t, err)
+
+	assert.Same(t, conn1.Source(), conn2.Source())
+
+	_, err = conn1.PublishChange(ctx, "test", testCreateChange())
+	require.NoError(t, err)
+
+	require.NoError(t, conn1.Shutdown(ctx))
+
+	// the broker is kept while a connection sharing it remains.
+	messages, err := conn2.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	_, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+
+	require.NoError(t, conn2.Shutdown(ctx))
+
+	// the broker is discarded once the last connection is shutdown.
+	conn3, err := events.NewMemoryConnection(cfg)
+	require.NoError(t, err)
+
+	defer conn3.Shutdown(ctx) //nolint:errcheck // within test
+
+	assert.NotSame(t, conn2.Source(), conn3.Source())
+
+	messages, err = conn3.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	_, err = getSingleMessage(messages, time.Millisecond*100)
+	require.ErrorIs(t, err, errTimeout)
+}
+
+func TestMemoryRequestReply(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := events.NewConnection(events.Config{
+		Memory: events.MemoryConfig{
+			Enabled: true,
+		},
+	})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	authRequest := events.AuthRelationshipRequest{
+		Action:   events.WriteAuthRelationshipAction,
+		ObjectID: gidx.PrefixedID("prntobj-abc123"),
+		Relations: []events.AuthRelationshipRelation{
+			{
+				Relation:  "owner",
+				SubjectID: gidx.PrefixedID("chldobj-abc123"),
+			},
+		},
+		TraceContext: map[string]string{},
+	}
+
+	authResponse := events.AuthRelationshipResponse{
+		TraceID:      "some-id",
+		TraceContext: map[string]string{},
+	}
+
+	resp, err := conn.PublishAuthRelationshipRequest(ctx, "test", authRequest)
+	require.ErrorIs(t, err, events.ErrRequestNoResponders)
+	require.Nil(t, resp)
+
+	subCtx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	requests, err := conn.SubscribeAuthRelationshipRequests(subCtx, "*.test")
+	require.NoError(t, err)
+
+	go func() {
+		reqMsg, ok := <-requests
+		if !ok {
+			return
+		}
+
+		assert.EqualValues(t, authRequest, reqMsg.Message())
+
+		_, err := reqMsg.Reply(ctx, authResponse)
+		assert.NoError(t, err)
+	}()
+
+	reqCtx, reqCancel := context.WithTimeout(ctx, time.Second*2)
+
+	defer reqCancel()
+
+	resp, err = conn.PublishAuthRelationshipRequest(reqCtx, "test", authRequest)
+	require.NoError(t, err)
+	require.NoError(t, resp.Error())
+	assert.EqualValues(t, authResponse, resp.Message())
+}
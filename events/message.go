@@ -30,6 +30,19 @@ import (
 	"go.infratographer.com/x/gidx"
 )
 
+// NakWithErrorMessage is implemented by messages which record the error a nak was caused by, such as the NATS and Redis
+// messages, which record it when the nak dead-letters the message. The Router naks with the handler error when supported.
+type NakWithErrorMessage interface {
+	NakWithError(delay time.Duration, cause error) error
+}
+
+// SequencedMessage is implemented by messages stored in a stream, such as the NATS message, returning the name of the
+// stream and the stream sequence of the message. An empty stream is returned when the message is not stored in a stream.
+type SequencedMessage interface {
+	Stream() string
+	Sequence() uint64
+}
+
 // Message contains a message which has been published or received from a subscription.
 type Message[T any] interface {
 	// Connection returns the underlying connection the message was received on.
@@ -41,12 +54,16 @@ type Message[T any] interface {
 	Topic() string
 	// Message returns the decoded message object.
 	Message() T
+	// Headers returns the message headers.
+	Headers() Headers
 	// Ack acks the message.
 	Ack() error
 	// Nak nacks the message.
 	Nak(delay time.Duration) error
 	// Term terminates the message.
 	Term() error
+	// InProgress resets the redelivery timer of the message, signaling the message is still being processed.
+	InProgress() error
 	// Timestamp returns the time the message was submitted.
 	Timestamp() time.Time
 	// Deliveries returns the number of times the message was delivered.
@@ -55,6 +72,11 @@ type Message[T any] interface {
 	// Error returns any error encountered while decoding the message
 	Error() error
 
+	// Context returns the context for processing the message.
+	// Messages delivered to a subscription carry a consumer process span, parented to the producer trace,
+	// which is ended when the message is acked, naked or terminated.
+	Context() context.Context
+
 	// Source returns the underlying message object.
 	Source() any
 }
@@ -0,0 +1,45 @@
+package events
+
+import (
+	"context"
+
+	"go.infratographer.com/x/gidx"
+)
+
+// MessageIDPrefix is the gidx prefix of generated message ids.
+const MessageIDPrefix = "evntmsg"
+
+type messageIDCtxKey struct{}
+
+// ContextWithMessageID returns a context which publishes messages with the provided message id.
+// Providers which support de-duplication, such as NATS JetStream, drop messages published with
+// an id which was already published, allowing publishes to be retried safely.
+//
+// The id applies to every message published with the returned context and any context derived from it,
+// so a different message published with the same context is dropped as a duplicate. Derive the context for
+// the single publish being retried only, or clear the id for derived contexts with an empty id,
+// which publishes messages with a generated id.
+func ContextWithMessageID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, messageIDCtxKey{}, id)
+}
+
+// MessageIDFromContext returns the message id set with ContextWithMessageID or an empty string if none was set.
+func MessageIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(messageIDCtxKey{}).(string)
+
+	return id
+}
+
+// messageID returns the message id from the context or generates a new one.
+func messageID(ctx context.Context) (string, error) {
+	if id := MessageIDFromContext(ctx); id != "" {
+		return id, nil
+	}
+
+	id, err := gidx.NewID(MessageIDPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	return id.String(), nil
+}
@@ -0,0 +1,265 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+const (
+	meterName = "go.infratographer.com/x/events"
+
+	metricResultSuccess   = "success"
+	metricResultDuplicate = "duplicate"
+	metricResultError     = "error"
+
+	ackActionAck  = "ack"
+	ackActionNak  = "nak"
+	ackActionTerm = "term"
+)
+
+// messageMetrics holds the instruments recording message publishing and consumption.
+// Attributes are limited to the messaging system, message family, event type, topic and subscription
+// so the cardinality is bounded by the topics and subscriptions used by the service.
+type messageMetrics struct {
+	system attribute.KeyValue
+
+	publishes       metric.Int64Counter
+	publishDuration metric.Float64Histogram
+	deliveries      metric.Int64Counter
+	redeliveries    metric.Int64Counter
+	acks            metric.Int64Counter
+	processDuration metric.Float64Histogram
+	decodeErrors    metric.Int64Counter
+	fetchDuration   metric.Float64Histogram
+
+	registration metric.Registration
+
+	mu         sync.Mutex
+	nextID     int
+	subscribed map[int]bufferedSubscription
+}
+
+type bufferedSubscription struct {
+	attrs attribute.Set
+	depth func() int
+}
+
+// newMessageMetrics creates the instruments using the global MeterProvider.
+// If any instrument fails to be created, the error is returned along with metrics which record nothing.
+func newMessageMetrics(system string) (*messageMetrics, error) {
+	m, err := buildMessageMetrics(otel.GetMeterProvider().Meter(meterName), system)
+	if err != nil {
+		m, _ = buildMessageMetrics(noop.NewMeterProvider().Meter(meterName), system)
+
+		return m, err
+	}
+
+	return m, nil
+}
+
+func buildMessageMetrics(meter metric.Meter, system string) (*messageMetrics, error) {
+	var err, instErr error
+
+	m := &messageMetrics{
+		system:     attribute.String("messaging.system", system),
+		subscribed: make(map[int]bufferedSubscription),
+	}
+
+	m.publishes, instErr = meter.Int64Counter("events.publish.messages",
+		metric.WithDescription("Messages published, by family, event type, topic and result."),
+		metric.WithUnit("{message}"),
+	)
+	err = errors.Join(err, instErr)
+
+	m.publishDuration, instErr = meter.Float64Histogram("events.publish.duration",
+		metric.WithDescription("Time publishing a message to the server, including waiting for the acknowledgement or request response."),
+		metric.WithUnit("s"),
+	)
+	err = errors.Join(err, instErr)
+
+	m.deliveries, instErr = meter.Int64Counter("events.consumer.deliveries",
+		metric.WithDescription("Messages delivered to subscriptions."),
+		metric.WithUnit("{message}"),
+	)
+	err = errors.Join(err, instErr)
+
+	m.redeliveries, instErr = meter.Int64Counter("events.consumer.redeliveries",
+		metric.WithDescription("Messages delivered to subscriptions which were previously delivered."),
+		metric.WithUnit("{message}"),
+	)
+	err = errors.Join(err, instErr)
+
+	m.acks, instErr = meter.Int64Counter("events.consumer.acks",
+		metric.WithDescription("Messages acknowledged by subscribers, by action ack, nak or term."),
+		metric.WithUnit("{message}"),
+	)
+	err = errors.Join(err, instErr)
+
+	m.processDuration, instErr = meter.Float64Histogram("events.consumer.process.duration",
+		metric.WithDescription("Time from a message being delivered to a subscription until the subscriber handled it with an ack, nak or term."),
+		metric.WithUnit("s"),
+	)
+	err = errors.Join(err, instErr)
+
+	m.decodeErrors, instErr = meter.Int64Counter("events.consumer.decode_errors",
+		metric.WithDescription("Messages delivered to subscriptions which failed to decode."),
+		metric.WithUnit("{message}"),
+	)
+	err = errors.Join(err, instErr)
+
+	m.fetchDuration, instErr = meter.Float64Histogram("events.consumer.fetch.duration",
+		metric.WithDescription("Time waiting to receive the next message from the server."),
+		metric.WithUnit("s"),
+	)
+	err = errors.Join(err, instErr)
+
+	buffered, instErr := meter.Int64ObservableGauge("events.consumer.buffered",
+		metric.WithDescription("Messages buffered in subscription channels waiting to be received by the subscriber."),
+		metric.WithUnit("{message}"),
+	)
+	err = errors.Join(err, instErr)
+
+	// instruments are shared by connections, so each connection registers its own callback.
+	if instErr == nil {
+		m.registration, instErr = meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+			m.observeBuffered(observer, buffered)
+
+			return nil
+		}, buffered)
+		err = errors.Join(err, instErr)
+	}
+
+	return m, err
+}
+
+// close stops reporting the subscription channel depths.
+func (m *messageMetrics) close() error {
+	if m.registration == nil {
+		return nil
+	}
+
+	return m.registration.Unregister()
+}
+
+// publishAttributes returns the attributes recorded for messages published to the topic.
+func (m *messageMetrics) publishAttributes(family, eventType, topic string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		m.system,
+		attribute.String("events.family", family),
+		attribute.String("events.event_type", eventType),
+		attribute.String("events.topic", topic),
+	}
+}
+
+// subscriptionAttributes returns the attributes recorded for messages delivered to the subscription subject.
+func (m *messageMetrics) subscriptionAttributes(subscription string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		m.system,
+		attribute.String("events.subscription", subscription),
+	}
+}
+
+// recordPublish records a published message with the result of the publish.
+func (m *messageMetrics) recordPublish(ctx context.Context, attrs []attribute.KeyValue, duplicate bool, err error) {
+	result := metricResultSuccess
+
+	switch {
+	case err != nil:
+		result = metricResultError
+	case duplicate:
+		result = metricResultDuplicate
+	}
+
+	m.publishes.Add(ctx, 1, metric.WithAttributes(attrs...), metric.WithAttributes(attribute.String("events.result", result)))
+}
+
+// recordPublishDuration records the time spent publishing a message to the server since start.
+func (m *messageMetrics) recordPublishDuration(ctx context.Context, attrs []attribute.KeyValue, start time.Time, err error) {
+	m.publishDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...), metric.WithAttributes(attribute.String("events.result", metricResult(err))))
+}
+
+// recordDelivery records a message delivered to a subscription.
+func (m *messageMetrics) recordDelivery(ctx context.Context, attrs []attribute.KeyValue, deliveries uint64, err error) {
+	opt := metric.WithAttributes(attrs...)
+
+	m.deliveries.Add(ctx, 1, opt)
+
+	if deliveries > 1 {
+		m.redeliveries.Add(ctx, 1, opt)
+	}
+
+	if err != nil {
+		m.decodeErrors.Add(ctx, 1, opt)
+	}
+}
+
+// recordAck records a successful ack, nak or term of a message delivered to the subscription at deliveredAt.
+// The process duration includes the time the message was buffered in the subscription channel, see events.consumer.buffered.
+func (m *messageMetrics) recordAck(ctx context.Context, attrs []attribute.KeyValue, action string, deliveredAt time.Time) {
+	actionOpt := metric.WithAttributes(attribute.String("events.ack.action", action))
+
+	m.acks.Add(ctx, 1, metric.WithAttributes(attrs...), actionOpt)
+	m.processDuration.Record(ctx, time.Since(deliveredAt).Seconds(), metric.WithAttributes(attrs...), actionOpt)
+}
+
+// recordFetch records the time spent waiting for a message since start.
+func (m *messageMetrics) recordFetch(ctx context.Context, attrs []attribute.KeyValue, start time.Time, err error) {
+	m.fetchDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...), metric.WithAttributes(attribute.String("events.result", metricResult(err))))
+}
+
+// metricResult returns the result attribute value for err.
+func metricResult(err error) string {
+	if err != nil {
+		return metricResultError
+	}
+
+	return metricResultSuccess
+}
+
+// observeDepth reports the depth of a subscription channel until the returned func is called.
+func (m *messageMetrics) observeDepth(attrs []attribute.KeyValue, depth func() int) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.nextID
+	m.nextID++
+
+	m.subscribed[id] = bufferedSubscription{
+		attrs: attribute.NewSet(attrs...),
+		depth: depth,
+	}
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		delete(m.subscribed, id)
+	}
+}
+
+func (m *messageMetrics) observeBuffered(observer metric.Observer, gauge metric.Int64ObservableGauge) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// subscriptions on the same subject are reported together.
+	depths := make(map[attribute.Distinct]int64)
+	sets := make(map[attribute.Distinct]attribute.Set)
+
+	for _, sub := range m.subscribed {
+		key := sub.attrs.Equivalent()
+
+		depths[key] += int64(sub.depth())
+		sets[key] = sub.attrs
+	}
+
+	for key, depth := range depths {
+		observer.ObserveInt64(gauge, depth, metric.WithAttributeSet(sets[key]))
+	}
+}
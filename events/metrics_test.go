@@ -0,0 +1,158 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	nc "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestNATSMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	reader := sdkmetric.NewManualReader()
+
+	prevProvider := otel.GetMeterProvider()
+
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	defer otel.SetMeterProvider(prevProvider)
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	conn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	_, err = conn.PublishChange(ctx, "test", testCreateChange())
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, receivedMsg.Nak(0))
+
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, receivedMsg.Ack())
+
+	// invalid payloads are counted as decode errors.
+	_, err = conn.Source().(*nc.Conn).Request(eventtools.Prefix+".changes.create.test", []byte(`not json`), time.Second)
+	require.NoError(t, err)
+
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.Error(t, receivedMsg.Error())
+	require.NoError(t, receivedMsg.Term())
+
+	var rm metricdata.ResourceMetrics
+
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	subscription := attribute.String("events.subscription", eventtools.Prefix+".changes.>")
+
+	assert.Equal(t, int64(1), metricSum(t, rm, "events.publish.messages",
+		attribute.String("events.family", "changes"),
+		attribute.String("events.event_type", "create"),
+		attribute.String("events.topic", "test"),
+		attribute.String("events.result", "success"),
+	))
+	assert.Equal(t, int64(3), metricSum(t, rm, "events.consumer.deliveries", subscription))
+	assert.Equal(t, int64(1), metricSum(t, rm, "events.consumer.redeliveries", subscription))
+	assert.Equal(t, int64(1), metricSum(t, rm, "events.consumer.decode_errors", subscription))
+	assert.Equal(t, int64(1), metricSum(t, rm, "events.consumer.acks", subscription, attribute.String("events.ack.action", "ack")))
+	assert.Equal(t, int64(1), metricSum(t, rm, "events.consumer.acks", subscription, attribute.String("events.ack.action", "nak")))
+	assert.Equal(t, int64(1), metricSum(t, rm, "events.consumer.acks", subscription, attribute.String("events.ack.action", "term")))
+
+	assert.Equal(t, uint64(1), metricCount(t, rm, "events.publish.duration",
+		attribute.String("events.topic", "test"),
+		attribute.String("events.result", "success"),
+	))
+	assert.Equal(t, uint64(1), metricCount(t, rm, "events.consumer.process.duration", subscription, attribute.String("events.ack.action", "ack")))
+	assert.Equal(t, uint64(1), metricCount(t, rm, "events.consumer.process.duration", subscription, attribute.String("events.ack.action", "nak")))
+	assert.Equal(t, uint64(1), metricCount(t, rm, "events.consumer.process.duration", subscription, attribute.String("events.ack.action", "term")))
+
+	assert.GreaterOrEqual(t, metricCount(t, rm, "events.consumer.fetch.duration", subscription, attribute.String("events.result", "success")), uint64(3))
+
+	buffered := findMetric(t, rm, "events.consumer.buffered").Data.(metricdata.Gauge[int64])
+	require.Len(t, buffered.DataPoints, 1)
+	assert.Equal(t, int64(0), buffered.DataPoints[0].Value)
+}
+
+func findMetric(t *testing.T, rm metricdata.ResourceMetrics, name string) metricdata.Metrics {
+	t.Helper()
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+
+	require.Failf(t, "metric not found", "metric %s was not recorded", name)
+
+	return metricdata.Metrics{}
+}
+
+// metricSum returns the sum of the counter data points which have all the provided attributes.
+func metricSum(t *testing.T, rm metricdata.ResourceMetrics, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+
+	sum, ok := findMetric(t, rm, name).Data.(metricdata.Sum[int64])
+	require.True(t, ok, "metric %s is not an int64 sum", name)
+
+	var total int64
+
+	for _, dp := range sum.DataPoints {
+		if hasAttributes(dp.Attributes, attrs) {
+			total += dp.Value
+		}
+	}
+
+	return total
+}
+
+// metricCount returns the number of recordings of the histogram data points which have all the provided attributes.
+func metricCount(t *testing.T, rm metricdata.ResourceMetrics, name string, attrs ...attribute.KeyValue) uint64 {
+	t.Helper()
+
+	histogram, ok := findMetric(t, rm, name).Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "metric %s is not a float64 histogram", name)
+
+	var total uint64
+
+	for _, dp := range histogram.DataPoints {
+		if hasAttributes(dp.Attributes, attrs) {
+			total += dp.Count
+		}
+	}
+
+	return total
+}
+
+func hasAttributes(set attribute.Set, attrs []attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if value, ok := set.Value(attr.Key); !ok || value != attr.Value {
+			return false
+		}
+	}
+
+	return true
+}
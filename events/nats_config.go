@@ -1,9 +1,11 @@
 package events
 
 import (
+	"strings"
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"go.uber.org/multierr"
@@ -23,55 +25,205 @@ var (
 	NATSDefaultSubscriberFetchBackoff = 5 * time.Second
 	// NATSDefaultShutdownTimeout is the timeout for a shutdown to complete.
 	NATSDefaultShutdownTimeout = 5 * time.Second
+	// NATSMaxSubscriberHeartbeat is the max default idle heartbeat interval for subscriptions.
+	NATSMaxSubscriberHeartbeat = 30 * time.Second
+	// NATSDefaultDeadLetterSubject is the subject token dead-lettered messages are published under.
+	NATSDefaultDeadLetterSubject = "deadletter"
+	// NATSDefaultScheduleSubject is the subject token scheduled messages are held under until they are delivered.
+	NATSDefaultScheduleSubject = "scheduled"
 )
 
 // NATSConfig defines the NATS connection configuration.
 type NATSConfig struct {
-	URL             string
+	// URL is the server to connect to, ignored when URLs are set.
+	URL string
+	// URLs are the servers to connect to, such as the seed servers of a cluster.
+	// When set, URL is ignored so the events-nats-url flag default isn't connected to alongside them.
+	URLs            []string
 	SubscribePrefix string
 	PublishPrefix   string
 	QueueGroup      string
-	Token           string
-	CredsFile       string
 	Source          string
 
-	ConnectTimeout           time.Duration
-	ShutdownTimeout          time.Duration
+	// Token, CredsFile, NKeySeedFile and User are mutually exclusive authentication methods.
+	Token     string
+	CredsFile string
+	// NKeySeedFile is the path to a file containing the nkey seed the connection authenticates with.
+	NKeySeedFile string
+	// User and Password authenticate the connection with a username and password.
+	User     string
+	Password string
+
+	// TLSCAFile is the path to the PEM encoded CA certificates used to verify the server certificate.
+	TLSCAFile string
+	// TLSCertFile and TLSKeyFile are the paths to the PEM encoded client certificate and key presented to the server.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// InboxPrefix is the prefix of the inbox subjects replies to requests are received on, defaults to _INBOX.
+	InboxPrefix string
+
+	ConnectTimeout  time.Duration
+	ShutdownTimeout time.Duration
+
+	// MaxReconnects is the number of attempts to reconnect to the server, zero uses the nats default and -1 reconnects indefinitely.
+	MaxReconnects int
+	// ReconnectWait is the delay between reconnect attempts to the same server, zero uses the nats default.
+	ReconnectWait time.Duration
+	// ReconnectJitter is the max random delay added to the ReconnectWait, zero uses the nats default.
+	ReconnectJitter time.Duration
+	// ReconnectJitterTLS is the max random delay added to the ReconnectWait for TLS connections, zero uses the nats default.
+	ReconnectJitterTLS time.Duration
+	// ReconnectBufferSize is the size in bytes of the buffer holding published messages while reconnecting,
+	// zero uses the nats default and -1 disables buffering so publishes fail while disconnected.
+	ReconnectBufferSize int
+
+	// SubscriberFetchBatchSize is the max number of messages buffered by a subscription.
 	SubscriberFetchBatchSize int
-	SubscriberFetchTimeout   time.Duration
-	SubscriberFetchBackoff   time.Duration
-	SubscriberNoAckExplicit  bool
-	SubscriberNoManualAck    bool
+	// SubscriberFetchTimeout is the expiry of each pull request made by a subscription.
+	// Messages are delivered as soon as they are available, the timeout does not delay delivery.
+	SubscriberFetchTimeout time.Duration
+	// SubscriberFetchBackoff is the delay before a subscription retries after an error.
+	SubscriberFetchBackoff time.Duration
+	// SubscriberHeartbeat is the idle heartbeat interval used to detect stalled subscriptions.
+	// Defaults to half of SubscriberFetchTimeout, capped at 30 seconds.
+	SubscriberHeartbeat     time.Duration
+	SubscriberNoAckExplicit bool
+	// SubscriberAckWait is the duration the server waits for an ack before redelivering a message to a subscription, zero uses the server default.
+	// Handlers running longer than the AckWait should signal progress with Message.InProgress, see KeepInProgress.
+	SubscriberAckWait time.Duration
+	// SubscriberMaxAckPending is the max number of messages delivered to a subscription which are not yet acked, zero uses the server default and -1 is unlimited.
+	SubscriberMaxAckPending int
+	// Deprecated: SubscriberNoManualAck has no effect, messages are only acked by the subscriber.
+	// A warning is logged when the connection is created with it set.
+	SubscriberNoManualAck bool
 
 	SubscriberDeliveryPolicy string
 	SubscriberStartSequence  uint64
 	SubscriberStartTime      time.Time
 
+	// DeadLetterMaxDeliveries is the number of deliveries after which a message is dead-lettered, zero disables dead-lettering.
+	DeadLetterMaxDeliveries int
+	// DeadLetterSubject is the subject token, following the SubscribePrefix, dead-lettered messages are published under.
+	DeadLetterSubject string
+
+	// ScheduleSubject is the subject token, following the PublishPrefix, scheduled messages are held under until they are delivered.
+	// A stream must store the schedule subject along with the subjects messages are scheduled for.
+	// When the stream allows message schedules the server delivers scheduled messages, otherwise RunScheduler must be running.
+	ScheduleSubject string
+
+	// Encoding is the encoding published messages use, one of json (default), cloudevents-binary or cloudevents-structured.
+	// Received messages are decoded regardless of the encoding they were published with.
+	Encoding string
+	// Codec is the name of the codec published message payloads are marshaled with, defaults to json.
+	// Received messages are decoded with the codec declared in their headers, see Codec.
+	Codec string
+
+	// TrustedSigningKeys are the public nkeys of the signers received messages must be signed by.
+	// When set, messages which are not signed by a trusted key are returned with an ErrMessageUnverified error.
+	TrustedSigningKeys []string
+	// SignatureMaxAge is the max age of the signature of received messages, when set messages signed longer ago are
+	// returned with an ErrMessageUnverified error. Dead-lettered and replayed messages keep their original signature.
+	SignatureMaxAge time.Duration
+	// SignatureClockSkew is the clock skew allowed between signers and subscribers, when set messages signed further
+	// in the future, or older than the SignatureMaxAge plus the skew, are returned with an ErrMessageUnverified error.
+	SignatureClockSkew time.Duration
+	// EncryptedTopics are the topics, relative to the PublishPrefix, which published message payloads are encrypted for.
+	// Encryption keys are configured with WithNATSEncryptionKeys.
+	EncryptedTopics []string
+
+	// LegacyTraceContext also writes the trace context to the TraceContext field of published messages.
+	// The trace context is always propagated through the message headers, enable this while consumers
+	// which only read the trace context from the message body are migrated.
+	LegacyTraceContext bool
+
+	// HealthCheckProvisioned checks the configured Streams and Consumers exist when calling HealthCheck.
+	HealthCheckProvisioned bool
+	// HealthCheckMaxConsumerLag fails HealthCheck when any of the configured Consumers has more pending messages, zero disables the check.
+	HealthCheckMaxConsumerLag uint64
+
+	// Streams are the jetstream streams created or updated when connecting.
+	Streams []NATSStreamConfig
+	// Consumers are the durable jetstream consumers created or updated when connecting.
+	Consumers []NATSConsumerConfig
+
 	logger           *zap.SugaredLogger
 	connectOptions   []nats.Option
-	jetStreamOptions []nats.JSOpt
-	subscribeOptions []nats.SubOpt
+	jetStreamOptions []jetstream.JetStreamOpt
+	consumerOptions  []NATSConsumerOption
+	pullOptions      []jetstream.PullMessagesOpt
+	ignoredOptions   []string
+	schemas          *SchemaRegistry
+	signer           MessageSigner
+	encryptionKeys   []KeyEncrypter
+
+	disconnectHandlers []func(err error)
+	reconnectHandlers  []func()
+	errorHandlers      []func(err error)
 }
 
 // Configured checks whether the provider has been configured.
 func (c NATSConfig) Configured() bool {
-	return c.URL != ""
+	return c.URL != "" || len(c.URLs) != 0
+}
+
+// servers returns the comma separated list of server urls to connect to, the URLs replace the URL when set.
+func (c NATSConfig) servers() string {
+	if len(c.URLs) != 0 {
+		return strings.Join(c.URLs, ",")
+	}
+
+	return c.URL
 }
 
 // Validate ensures the configuration is valid.
 func (c NATSConfig) Validate() error {
 	var err error
 
-	if c.Token != "" && c.CredsFile != "" {
+	authMethods := 0
+
+	for _, method := range []string{c.Token, c.CredsFile, c.NKeySeedFile, c.User} {
+		if method != "" {
+			authMethods++
+		}
+	}
+
+	if authMethods > 1 {
+		err = multierr.Append(err, ErrNATSInvalidAuthConfiguration)
+	}
+
+	if c.Password != "" && c.User == "" {
 		err = multierr.Append(err, ErrNATSInvalidAuthConfiguration)
 	}
 
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		err = multierr.Append(err, ErrNATSInvalidTLSConfiguration)
+	}
+
+	if c.MaxReconnects < -1 || c.ReconnectWait < 0 || c.ReconnectJitter < 0 || c.ReconnectJitterTLS < 0 || c.ReconnectBufferSize < -1 {
+		err = multierr.Append(err, ErrNATSInvalidReconnectConfiguration)
+	}
+
+	if c.SubscriberAckWait < 0 {
+		err = multierr.Append(err, ErrNATSInvalidAckWait)
+	}
+
 	switch c.SubscriberDeliveryPolicy {
 	case "", "all", "last", "last-per-subject", "new", "start-sequence", "start-time":
 	default:
 		err = multierr.Append(err, ErrNATSInvalidDeliveryPolicy)
 	}
 
+	err = multierr.Append(err, validateMessageConfig(c.Encoding, c.Codec, c.TrustedSigningKeys))
+
+	for _, stream := range c.Streams {
+		err = multierr.Append(err, stream.validate())
+	}
+
+	for _, consumer := range c.Consumers {
+		err = multierr.Append(err, consumer.validate(c.QueueGroup))
+	}
+
 	return err
 }
 
@@ -81,6 +233,10 @@ func (c NATSConfig) WithDefaults() NATSConfig {
 		c.logger = zap.NewNop().Sugar()
 	}
 
+	if c.schemas == nil {
+		c.schemas = DefaultSchemaRegistry
+	}
+
 	if c.SubscriberFetchBatchSize == 0 {
 		c.SubscriberFetchBatchSize = NATSDefaultSubscriberFetchBatchSize
 	}
@@ -93,27 +249,16 @@ func (c NATSConfig) WithDefaults() NATSConfig {
 		c.SubscriberFetchBackoff = NATSDefaultSubscriberFetchBackoff
 	}
 
-	if !c.SubscriberNoAckExplicit {
-		c.subscribeOptions = append(c.subscribeOptions, nats.AckExplicit())
+	if c.SubscriberHeartbeat == 0 {
+		c.SubscriberHeartbeat = min(c.SubscriberFetchTimeout/2, NATSMaxSubscriberHeartbeat) //nolint:mnd // half the pull expiry
 	}
 
-	if !c.SubscriberNoManualAck {
-		c.subscribeOptions = append(c.subscribeOptions, nats.ManualAck())
+	if c.DeadLetterSubject == "" {
+		c.DeadLetterSubject = NATSDefaultDeadLetterSubject
 	}
 
-	switch c.SubscriberDeliveryPolicy {
-	case "all":
-		c.subscribeOptions = append(c.subscribeOptions, nats.DeliverAll())
-	case "last":
-		c.subscribeOptions = append(c.subscribeOptions, nats.DeliverLast())
-	case "last-per-subject":
-		c.subscribeOptions = append(c.subscribeOptions, nats.DeliverLastPerSubject())
-	case "new":
-		c.subscribeOptions = append(c.subscribeOptions, nats.DeliverNew())
-	case "start-sequence":
-		c.subscribeOptions = append(c.subscribeOptions, nats.StartSequence(c.SubscriberStartSequence))
-	case "start-time":
-		c.subscribeOptions = append(c.subscribeOptions, nats.StartTime(c.SubscriberStartTime))
+	if c.ScheduleSubject == "" {
+		c.ScheduleSubject = NATSDefaultScheduleSubject
 	}
 
 	if c.ShutdownTimeout == 0 {
@@ -134,6 +279,52 @@ func (c NATSConfig) WithDefaults() NATSConfig {
 		c.connectOptions = append(c.connectOptions, nats.UserCredentials(c.CredsFile))
 	}
 
+	if c.NKeySeedFile != "" {
+		c.connectOptions = append(c.connectOptions, natsNKeySeedFile(c.NKeySeedFile))
+	}
+
+	if c.User != "" {
+		c.connectOptions = append(c.connectOptions, nats.UserInfo(c.User, c.Password))
+	}
+
+	if c.TLSCAFile != "" {
+		c.connectOptions = append(c.connectOptions, nats.RootCAs(c.TLSCAFile))
+	}
+
+	if c.TLSCertFile != "" && c.TLSKeyFile != "" {
+		c.connectOptions = append(c.connectOptions, nats.ClientCert(c.TLSCertFile, c.TLSKeyFile))
+	}
+
+	if c.InboxPrefix != "" {
+		c.connectOptions = append(c.connectOptions, nats.CustomInboxPrefix(c.InboxPrefix))
+	}
+
+	if c.MaxReconnects != 0 {
+		c.connectOptions = append(c.connectOptions, nats.MaxReconnects(c.MaxReconnects))
+	}
+
+	if c.ReconnectWait != 0 {
+		c.connectOptions = append(c.connectOptions, nats.ReconnectWait(c.ReconnectWait))
+	}
+
+	if c.ReconnectJitter != 0 || c.ReconnectJitterTLS != 0 {
+		jitter, jitterTLS := c.ReconnectJitter, c.ReconnectJitterTLS
+
+		if jitter == 0 {
+			jitter = nats.DefaultReconnectJitter
+		}
+
+		if jitterTLS == 0 {
+			jitterTLS = nats.DefaultReconnectJitterTLS
+		}
+
+		c.connectOptions = append(c.connectOptions, nats.ReconnectJitter(jitter, jitterTLS))
+	}
+
+	if c.ReconnectBufferSize != 0 {
+		c.connectOptions = append(c.connectOptions, nats.ReconnectBufSize(c.ReconnectBufferSize))
+	}
+
 	if c.Source != "" {
 		c.connectOptions = append(c.connectOptions, nats.Name(c.Source))
 	}
@@ -141,6 +332,18 @@ func (c NATSConfig) WithDefaults() NATSConfig {
 	return c
 }
 
+// natsNKeySeedFile authenticates with the nkey seed read from the file when connecting.
+func natsNKeySeedFile(file string) nats.Option {
+	return func(o *nats.Options) error {
+		opt, err := nats.NkeyOptionFromSeed(file)
+		if err != nil {
+			return err
+		}
+
+		return opt(o)
+	}
+}
+
 // NATSOption defines a nats configuration option.
 type NATSOption func(c *NATSConfig) error
 
@@ -162,8 +365,8 @@ func WithNATSConnectOptions(options ...nats.Option) NATSOption {
 	}
 }
 
-// WithNATSJetStreamOptions configures the jetstream connection options.
-func WithNATSJetStreamOptions(options ...nats.JSOpt) NATSOption {
+// WithNATSJetStreamAPIOptions configures the jetstream connection options.
+func WithNATSJetStreamAPIOptions(options ...jetstream.JetStreamOpt) NATSOption {
 	return func(c *NATSConfig) error {
 		c.jetStreamOptions = append(c.jetStreamOptions, options...)
 
@@ -171,10 +374,109 @@ func WithNATSJetStreamOptions(options ...nats.JSOpt) NATSOption {
 	}
 }
 
-// WithNATSSubscribeOptions configures the subscribe options for new subscriptions.
+// WithNATSJetStreamOptions configures the legacy jetstream context options.
+//
+// Deprecated: the legacy jetstream context is no longer used and the options are ignored, a warning is logged when
+// options are provided. Use WithNATSJetStreamAPIOptions instead.
+func WithNATSJetStreamOptions(options ...nats.JSOpt) NATSOption {
+	return func(c *NATSConfig) error {
+		if len(options) != 0 {
+			c.ignoredOptions = append(c.ignoredOptions, "WithNATSJetStreamOptions")
+		}
+
+		return nil
+	}
+}
+
+// WithNATSSchemaRegistry sets the schema registry used to version and validate messages, defaults to DefaultSchemaRegistry.
+func WithNATSSchemaRegistry(registry *SchemaRegistry) NATSOption {
+	return func(c *NATSConfig) error {
+		c.schemas = registry
+
+		return nil
+	}
+}
+
+// WithNATSSigner signs published messages with the signer.
+func WithNATSSigner(signer MessageSigner) NATSOption {
+	return func(c *NATSConfig) error {
+		c.signer = signer
+
+		return nil
+	}
+}
+
+// WithNATSEncryptionKeys sets the keys used to encrypt messages published to the EncryptedTopics and decrypt received messages.
+// Messages are encrypted with the first key, all keys are used to decrypt messages so keys may be rotated.
+func WithNATSEncryptionKeys(keys ...KeyEncrypter) NATSOption {
+	return func(c *NATSConfig) error {
+		c.encryptionKeys = append(c.encryptionKeys, keys...)
+
+		return nil
+	}
+}
+
+// WithNATSDisconnectHandler adds a handler called when the connection to the server is lost.
+// Disconnects are always logged, err is nil if the connection was closed.
+func WithNATSDisconnectHandler(handler func(err error)) NATSOption {
+	return func(c *NATSConfig) error {
+		c.disconnectHandlers = append(c.disconnectHandlers, handler)
+
+		return nil
+	}
+}
+
+// WithNATSReconnectHandler adds a handler called when the connection to the server is restored.
+// Reconnects are always logged.
+func WithNATSReconnectHandler(handler func()) NATSOption {
+	return func(c *NATSConfig) error {
+		c.reconnectHandlers = append(c.reconnectHandlers, handler)
+
+		return nil
+	}
+}
+
+// WithNATSErrorHandler adds a handler called for asynchronous errors, such as slow consumers or permission violations.
+// Errors are always logged.
+func WithNATSErrorHandler(handler func(err error)) NATSOption {
+	return func(c *NATSConfig) error {
+		c.errorHandlers = append(c.errorHandlers, handler)
+
+		return nil
+	}
+}
+
+// NATSConsumerOption modifies the configuration of consumers created for subscriptions.
+type NATSConsumerOption func(cfg *jetstream.ConsumerConfig)
+
+// WithNATSConsumerOptions configures the consumer options for new subscriptions.
+// Options are only applied when a consumer is created, existing durable consumers are not modified.
+func WithNATSConsumerOptions(options ...NATSConsumerOption) NATSOption {
+	return func(c *NATSConfig) error {
+		c.consumerOptions = append(c.consumerOptions, options...)
+
+		return nil
+	}
+}
+
+// WithNATSSubscribeOptions configures the legacy subscribe options for new subscriptions.
+//
+// Deprecated: subscriptions no longer use the legacy jetstream context and the options are ignored, a warning is
+// logged when options are provided. Use WithNATSConsumerOptions and WithNATSPullOptions instead.
 func WithNATSSubscribeOptions(options ...nats.SubOpt) NATSOption {
 	return func(c *NATSConfig) error {
-		c.subscribeOptions = append(c.subscribeOptions, options...)
+		if len(options) != 0 {
+			c.ignoredOptions = append(c.ignoredOptions, "WithNATSSubscribeOptions")
+		}
+
+		return nil
+	}
+}
+
+// WithNATSPullOptions configures the pull options used by subscriptions to receive messages.
+func WithNATSPullOptions(options ...jetstream.PullMessagesOpt) NATSOption {
+	return func(c *NATSConfig) error {
+		c.pullOptions = append(c.pullOptions, options...)
 
 		return nil
 	}
@@ -185,22 +487,50 @@ func MustViperFlagsForNATS(v *viper.Viper, flags *pflag.FlagSet, appName string)
 	flags.String("events-nats-url", "nats://nats:4222", "nats server connection url")
 	viperx.MustBindFlag(v, "events.nats.url", flags.Lookup("events-nats-url"))
 
+	v.MustBindEnv("events.nats.urls")
 	v.MustBindEnv("events.nats.subscribePrefix")
 	v.MustBindEnv("events.nats.publishPrefix")
 	v.MustBindEnv("events.nats.queueGroup")
 	v.MustBindEnv("events.nats.token")
 	v.MustBindEnv("events.nats.credsFile")
+	v.MustBindEnv("events.nats.nkeySeedFile")
+	v.MustBindEnv("events.nats.user")
+	v.MustBindEnv("events.nats.password")
+	v.MustBindEnv("events.nats.tlsCAFile")
+	v.MustBindEnv("events.nats.tlsCertFile")
+	v.MustBindEnv("events.nats.tlsKeyFile")
+	v.MustBindEnv("events.nats.inboxPrefix")
 	v.MustBindEnv("events.nats.source")
 	v.MustBindEnv("events.nats.connectTimeout")
 	v.MustBindEnv("events.nats.shutdownTimeout")
+	v.MustBindEnv("events.nats.maxReconnects")
+	v.MustBindEnv("events.nats.reconnectWait")
+	v.MustBindEnv("events.nats.reconnectJitter")
+	v.MustBindEnv("events.nats.reconnectJitterTLS")
+	v.MustBindEnv("events.nats.reconnectBufferSize")
 	v.MustBindEnv("events.nats.subscriberFetchBatchSize")
 	v.MustBindEnv("events.nats.subscriberFetchTimeout")
 	v.MustBindEnv("events.nats.subscriberFetchBackoff")
+	v.MustBindEnv("events.nats.subscriberHeartbeat")
 	v.MustBindEnv("events.nats.subscriberNoAckExplicit")
+	v.MustBindEnv("events.nats.subscriberAckWait")
+	v.MustBindEnv("events.nats.subscriberMaxAckPending")
 	v.MustBindEnv("events.nats.subscriberNoManualAck")
 	v.MustBindEnv("events.nats.subscriberDeliveryPolicy")
 	v.MustBindEnv("events.nats.subscriberStartSequence")
 	v.MustBindEnv("events.nats.subscriberStartTime")
+	v.MustBindEnv("events.nats.deadLetterMaxDeliveries")
+	v.MustBindEnv("events.nats.deadLetterSubject")
+	v.MustBindEnv("events.nats.scheduleSubject")
+	v.MustBindEnv("events.nats.encoding")
+	v.MustBindEnv("events.nats.codec")
+	v.MustBindEnv("events.nats.trustedSigningKeys")
+	v.MustBindEnv("events.nats.signatureMaxAge")
+	v.MustBindEnv("events.nats.signatureClockSkew")
+	v.MustBindEnv("events.nats.encryptedTopics")
+	v.MustBindEnv("events.nats.legacyTraceContext")
+	v.MustBindEnv("events.nats.healthCheckProvisioned")
+	v.MustBindEnv("events.nats.healthCheckMaxConsumerLag")
 
 	v.SetDefault("events.nats.connectTimeout", defaultTimeout)
 	v.SetDefault("events.nats.source", appName)
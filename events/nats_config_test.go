@@ -0,0 +1,411 @@
+package events_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestNATSConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      events.NATSConfig
+		expectedErr error
+	}{
+		{
+			name:   "single auth method",
+			config: events.NATSConfig{URL: "nats://localhost", User: "user", Password: "pass"},
+		},
+		{
+			name:        "token and creds file",
+			config:      events.NATSConfig{URL: "nats://localhost", Token: "token", CredsFile: "creds"},
+			expectedErr: events.ErrNATSInvalidAuthConfiguration,
+		},
+		{
+			name:        "nkey and user",
+			config:      events.NATSConfig{URL: "nats://localhost", NKeySeedFile: "seed", User: "user"},
+			expectedErr: events.ErrNATSInvalidAuthConfiguration,
+		},
+		{
+			name:        "password without user",
+			config:      events.NATSConfig{URL: "nats://localhost", Password: "pass"},
+			expectedErr: events.ErrNATSInvalidAuthConfiguration,
+		},
+		{
+			name:        "tls cert without key",
+			config:      events.NATSConfig{URL: "nats://localhost", TLSCertFile: "cert.pem"},
+			expectedErr: events.ErrNATSInvalidTLSConfiguration,
+		},
+		{
+			name:   "unlimited reconnects",
+			config: events.NATSConfig{URL: "nats://localhost", MaxReconnects: -1, ReconnectBufferSize: -1},
+		},
+		{
+			name:        "negative reconnect wait",
+			config:      events.NATSConfig{URL: "nats://localhost", ReconnectWait: -time.Second},
+			expectedErr: events.ErrNATSInvalidReconnectConfiguration,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+
+			if tc.expectedErr == nil {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+
+	assert.True(t, events.NATSConfig{URLs: []string{"nats://localhost"}}.Configured())
+	assert.False(t, events.NATSConfig{}.Configured())
+}
+
+func TestNATSConnectionAuth(t *testing.T) {
+	userKey, err := nkeys.CreateUser()
+	require.NoError(t, err)
+
+	publicKey, err := userKey.PublicKey()
+	require.NoError(t, err)
+
+	seed, err := userKey.Seed()
+	require.NoError(t, err)
+
+	seedFile := filepath.Join(t.TempDir(), "user.nk")
+
+	require.NoError(t, os.WriteFile(seedFile, seed, 0o600))
+
+	srv, err := server.NewServer(&server.Options{
+		Host:   "127.0.0.1",
+		Port:   server.RANDOM_PORT,
+		NoSigs: true,
+		Users:  []*server.User{{Username: "user", Password: "pass"}},
+		Nkeys:  []*server.NkeyUser{{Nkey: publicKey}},
+	})
+	require.NoError(t, err)
+
+	go srv.Start()
+
+	defer srv.Shutdown()
+
+	require.True(t, srv.ReadyForConnections(time.Second*2))
+
+	testCases := []struct {
+		name   string
+		config events.NATSConfig
+		errMsg string
+	}{
+		{
+			name:   "user and password",
+			config: events.NATSConfig{URL: srv.ClientURL(), User: "user", Password: "pass"},
+		},
+		{
+			name:   "nkey seed file",
+			config: events.NATSConfig{URL: srv.ClientURL(), NKeySeedFile: seedFile},
+		},
+		{
+			name:   "seed urls",
+			config: events.NATSConfig{URLs: []string{"nats://127.0.0.1:1", srv.ClientURL()}, User: "user", Password: "pass"},
+		},
+		{
+			name:   "invalid password",
+			config: events.NATSConfig{URL: srv.ClientURL(), User: "user", Password: "wrong"},
+			errMsg: "Authorization Violation",
+		},
+		{
+			name:   "missing nkey seed file",
+			config: events.NATSConfig{URL: srv.ClientURL(), NKeySeedFile: filepath.Join(t.TempDir(), "missing.nk")},
+			errMsg: "no such file",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.InboxPrefix = "_INBOX_TESTING"
+			tc.config.MaxReconnects = -1
+			tc.config.ReconnectWait = time.Millisecond * 100
+
+			conn, err := events.NewNATSConnection(tc.config)
+			if tc.errMsg != "" {
+				require.ErrorContains(t, err, tc.errMsg)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			nc, ok := conn.Source().(*nats.Conn)
+			require.True(t, ok)
+
+			defer nc.Close()
+
+			assert.Equal(t, srv.ClientURL(), nc.ConnectedUrl())
+			assert.True(t, strings.HasPrefix(nc.NewRespInbox(), "_INBOX_TESTING."))
+			assert.Equal(t, -1, nc.Opts.MaxReconnect)
+			assert.Equal(t, time.Millisecond*100, nc.Opts.ReconnectWait)
+		})
+	}
+}
+
+// writeTestCertificate writes a certificate and key signed by the parent, or self-signed when parent is nil, to the directory.
+func writeTestCertificate(
+	t *testing.T,
+	dir, name string,
+	template *x509.Certificate,
+	parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+"-key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func TestNATSConnectionTLS(t *testing.T) {
+	dir := t.TempDir()
+
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := time.Now().Add(time.Hour)
+
+	ca, caKey := writeTestCertificate(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "testing ca"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+
+	writeTestCertificate(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}, ca, caKey)
+
+	writeTestCertificate(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	tlsConfig, err := server.GenTLSConfig(&server.TLSConfigOpts{
+		CertFile: filepath.Join(dir, "server.pem"),
+		KeyFile:  filepath.Join(dir, "server-key.pem"),
+		CaFile:   filepath.Join(dir, "ca.pem"),
+		Verify:   true,
+	})
+	require.NoError(t, err)
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		NoSigs:    true,
+		TLS:       true,
+		TLSVerify: true,
+		TLSConfig: tlsConfig,
+	})
+	require.NoError(t, err)
+
+	go srv.Start()
+
+	defer srv.Shutdown()
+
+	require.True(t, srv.ReadyForConnections(time.Second*2))
+
+	testCases := []struct {
+		name   string
+		config events.NATSConfig
+		errMsg string
+	}{
+		{
+			name: "client certificate",
+			config: events.NATSConfig{
+				TLSCAFile:   filepath.Join(dir, "ca.pem"),
+				TLSCertFile: filepath.Join(dir, "client.pem"),
+				TLSKeyFile:  filepath.Join(dir, "client-key.pem"),
+			},
+		},
+		{
+			name:   "missing client certificate",
+			config: events.NATSConfig{TLSCAFile: filepath.Join(dir, "ca.pem")},
+			errMsg: "certificate required",
+		},
+		{
+			name: "untrusted server",
+			config: events.NATSConfig{
+				TLSCAFile:   filepath.Join(dir, "client.pem"),
+				TLSCertFile: filepath.Join(dir, "client.pem"),
+				TLSKeyFile:  filepath.Join(dir, "client-key.pem"),
+			},
+			errMsg: "certificate signed by unknown authority",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.URL = srv.ClientURL()
+			tc.config.InboxPrefix = "_INBOX_TLS"
+			tc.config.MaxReconnects = -1
+
+			conn, err := events.NewNATSConnection(tc.config)
+			if tc.errMsg != "" {
+				require.ErrorContains(t, err, tc.errMsg)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			nc, ok := conn.Source().(*nats.Conn)
+			require.True(t, ok)
+
+			defer nc.Close()
+
+			state, err := nc.TLSConnectionState()
+			require.NoError(t, err)
+			require.NotEmpty(t, state.PeerCertificates)
+			assert.Equal(t, "127.0.0.1", state.PeerCertificates[0].Subject.CommonName)
+			assert.True(t, strings.HasPrefix(nc.NewRespInbox(), "_INBOX_TLS."))
+		})
+	}
+}
+
+func TestNATSDeprecatedOptions(t *testing.T) {
+	srv, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer srv.Close()
+
+	core, logs := observer.New(zap.WarnLevel)
+
+	cfg := srv.Config.NATS
+	cfg.SubscriberNoManualAck = true
+
+	conn, err := events.NewNATSConnection(cfg,
+		events.WithNATSLogger(zap.New(core).Sugar()),
+		events.WithNATSJetStreamOptions(nats.PublishAsyncMaxPending(1)),
+		events.WithNATSSubscribeOptions(nats.ManualAck()),
+	)
+	require.NoError(t, err)
+
+	conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	assert.Equal(t, 1, logs.FilterMessageSnippet("SubscriberNoManualAck is deprecated").Len())
+
+	ignored := logs.FilterMessage("ignoring deprecated nats option")
+	require.Equal(t, 2, ignored.Len())
+	assert.Equal(t, "WithNATSJetStreamOptions", ignored.All()[0].ContextMap()["option"])
+	assert.Equal(t, "WithNATSSubscribeOptions", ignored.All()[1].ContextMap()["option"])
+}
+
+func TestMustViperFlagsForNATS(t *testing.T) {
+	t.Setenv("EVENTS_NATS_URLS", "nats://one:4222,nats://two:4222")
+	t.Setenv("EVENTS_NATS_NKEYSEEDFILE", "/etc/nats/user.nk")
+	t.Setenv("EVENTS_NATS_MAXRECONNECTS", "-1")
+	t.Setenv("EVENTS_NATS_RECONNECTWAIT", "3s")
+
+	v := viper.New()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	events.MustViperFlagsForNATS(v, pflag.NewFlagSet("test", pflag.ContinueOnError), "testing")
+
+	var config struct {
+		Events events.Config
+	}
+
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Equal(t, []string{"nats://one:4222", "nats://two:4222"}, config.Events.NATS.URLs)
+	assert.Equal(t, "/etc/nats/user.nk", config.Events.NATS.NKeySeedFile)
+	assert.Equal(t, -1, config.Events.NATS.MaxReconnects)
+	assert.Equal(t, time.Second*3, config.Events.NATS.ReconnectWait)
+	assert.Equal(t, "testing", config.Events.NATS.Source)
+}
+
+func TestMustViperFlagsForNATSURLs(t *testing.T) {
+	srv, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer srv.Close()
+
+	t.Setenv("EVENTS_NATS_URLS", srv.Config.NATS.URL)
+
+	v := viper.New()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	events.MustViperFlagsForNATS(v, pflag.NewFlagSet("test", pflag.ContinueOnError), "testing")
+
+	var config struct {
+		Events events.Config
+	}
+
+	require.NoError(t, v.Unmarshal(&config))
+
+	// the url flag default is set, but only the configured urls are connected to.
+	assert.Equal(t, "nats://nats:4222", config.Events.NATS.URL)
+
+	conn, err := events.NewNATSConnection(config.Events.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	nc, ok := conn.Source().(*nats.Conn)
+	require.True(t, ok)
+
+	assert.Equal(t, []string{srv.Config.NATS.URL}, nc.Servers())
+	assert.Equal(t, srv.Config.NATS.URL, nc.ConnectedUrl())
+}
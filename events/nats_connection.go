@@ -4,17 +4,18 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
-	"encoding/json"
-	"strings"
+	"sync"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 const (
-	base10 = 10
+	base10    = 10
+	bitSize64 = 64
 
 	natsTracerName = tracerName + ":nats"
 )
@@ -26,12 +27,21 @@ type NATSConnection struct {
 	logger    *zap.SugaredLogger
 	tracer    trace.Tracer
 	conn      *nats.Conn
-	jetstream nats.JetStreamContext
+	jetstream jetstream.JetStream
+	metrics   *messageMetrics
+	security  *messageSecurity
 	cfg       NATSConfig
+
+	scheduleMu sync.Mutex
+	schedule   *natsScheduleStream
 }
 
 // Shutdown gracefully drains the connection.
 func (c *NATSConnection) Shutdown(ctx context.Context) error {
+	if err := c.metrics.close(); err != nil {
+		c.logger.Warnw("failed to unregister nats metrics", "error", err)
+	}
+
 	ctx, cancelTimeout := context.WithTimeout(ctx, c.cfg.ShutdownTimeout)
 	ctx, cancel := context.WithCancelCause(ctx)
 
@@ -63,46 +73,77 @@ func (c *NATSConnection) Source() any {
 	return c.conn
 }
 
+// runTyped runs the typed message operation with the connection.
+func (c *NATSConnection) runTyped(ctx context.Context, op typedOperation) (any, error) {
+	return op.nats(ctx, c)
+}
+
 func (c *NATSConnection) durableName(topic string) string {
 	return NATSConsumerDurableName(c.cfg.QueueGroup, topic)
 }
 
 func (c *NATSConnection) buildSubscribeSubject(parts ...string) string {
-	var subjectParts []string
+	return buildSubject(c.cfg.SubscribePrefix, parts...)
+}
+
+func (c *NATSConnection) buildPublishSubject(parts ...string) string {
+	return buildSubject(c.cfg.PublishPrefix, parts...)
+}
 
-	if c.cfg.SubscribePrefix != "" {
-		subjectParts = append(subjectParts, c.cfg.SubscribePrefix)
+func newNATSMessage[T any](ctx context.Context, conn *NATSConnection, subject string, message T) (*NATSMessage[T], error) {
+	codec, err := codecByName(conn.cfg.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := codec.Marshal(message)
+	if err != nil {
+		return nil, err
 	}
 
-	subjectParts = append(subjectParts, parts...)
+	schema := schemaFor[T](conn.cfg.schemas)
 
-	return strings.Join(subjectParts, ".")
-}
+	if schema != nil {
+		if err := schema.validatePublish(codec, data); err != nil {
+			return nil, err
+		}
+	}
 
-func (c *NATSConnection) buildPublishSubject(parts ...string) string {
-	var subjectParts []string
+	msgID, err := messageID(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	if c.cfg.PublishPrefix != "" {
-		subjectParts = append(subjectParts, c.cfg.PublishPrefix)
+	payload, headers, err := encodePayload(conn.cfg.Encoding, codec, msgID, conn.cfg.Source, message, data)
+	if err != nil {
+		return nil, err
 	}
 
-	subjectParts = append(subjectParts, parts...)
+	nMsg := nats.NewMsg(subject)
+	nMsg.Data = payload
+	nMsg.Header = nats.Header(publishHeaders(ctx, conn.cfg.Source, message))
+	nMsg.Header.Set(nats.MsgIdHdr, msgID)
 
-	return strings.Join(subjectParts, ".")
-}
+	if !isJSONCodec(codec.Name()) {
+		nMsg.Header.Set(HeaderCodec, codec.Name())
+	}
+
+	if schema != nil {
+		nMsg.Header.Set(HeaderSchemaVersion, schema.version())
+	}
+
+	for key, value := range headers {
+		nMsg.Header.Set(key, value)
+	}
 
-func newNATSMessage[T any](conn *NATSConnection, subject string, message T) (*NATSMessage[T], error) {
-	data, err := json.Marshal(message)
+	nMsg.Data, err = conn.security.seal(subject, Headers(nMsg.Header), nMsg.Data)
 	if err != nil {
 		return nil, err
 	}
 
 	return &NATSMessage[T]{
-		conn: conn,
-		source: &nats.Msg{
-			Subject: subject,
-			Data:    data,
-		},
+		conn:    conn,
+		source:  nMsg,
 		message: message,
 	}, nil
 }
@@ -125,25 +166,78 @@ func NewNATSConnection(config NATSConfig, options ...NATSOption) (*NATSConnectio
 		nc.logger.Warn("NATS QueueGroup is not set. Subscriptions will not be durable.")
 	}
 
-	conn, err := nats.Connect(config.URL, nc.connectOptions...)
+	if nc.SubscriberNoManualAck {
+		nc.logger.Warn("NATS SubscriberNoManualAck is deprecated and has no effect. Messages are only acked by the subscriber.")
+	}
+
+	for _, option := range nc.ignoredOptions {
+		nc.logger.Warnw("ignoring deprecated nats option", "option", option)
+	}
+
+	security, err := newMessageSecurity(nc.signer, nc.TrustedSigningKeys, nc.SignatureMaxAge, nc.SignatureClockSkew, nc.encryptionKeys, nc.PublishPrefix, nc.EncryptedTopics)
 	if err != nil {
 		return nil, err
 	}
 
-	js, err := conn.JetStream(nc.jetStreamOptions...)
+	// connection handlers are added first so handlers provided with WithNATSConnectOptions take precedence.
+	connectOptions := append(nc.handlerOptions(), nc.connectOptions...)
+
+	conn, err := nats.Connect(nc.servers(), connectOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn, nc.jetStreamOptions...)
 	if err != nil {
 		conn.Close()
 
 		return nil, err
 	}
 
-	return &NATSConnection{
+	metrics, err := newMessageMetrics("nats")
+	if err != nil {
+		nc.logger.Warnw("failed to create nats metrics, metrics will not be recorded", "error", err)
+	}
+
+	c := &NATSConnection{
 		logger:    nc.logger,
 		tracer:    otel.GetTracerProvider().Tracer(natsTracerName),
 		conn:      conn,
 		jetstream: js,
+		metrics:   metrics,
+		security:  security,
 		cfg:       nc,
-	}, nil
+	}
+
+	if len(nc.Streams) != 0 || len(nc.Consumers) != 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), nc.ConnectTimeout)
+
+		defer cancel()
+
+		drifts, err := c.Provision(ctx)
+		if err != nil {
+			if err := metrics.close(); err != nil {
+				nc.logger.Warnw("failed to unregister nats metrics", "error", err)
+			}
+
+			conn.Close()
+
+			return nil, err
+		}
+
+		for _, drift := range drifts {
+			nc.logger.Warnw("nats resource configuration drift detected",
+				"nats.kind", drift.Kind,
+				"nats.name", drift.Name,
+				"nats.field", drift.Field,
+				"nats.expected", drift.Expected,
+				"nats.actual", drift.Actual,
+				"nats.updated", drift.Updated,
+			)
+		}
+	}
+
+	return c, nil
 }
 
 // NATSConsumerDurableName is the generator function to create a new durable consumer name.
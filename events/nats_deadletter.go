@@ -0,0 +1,256 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// NATSHeaderDeadLetterError is the header containing the error which caused the message to be dead-lettered.
+	NATSHeaderDeadLetterError = "Events-Dead-Letter-Error"
+	// NATSHeaderDeadLetterTopic is the header containing the subject the message was originally published to.
+	NATSHeaderDeadLetterTopic = "Events-Dead-Letter-Topic"
+	// NATSHeaderDeadLetterConsumer is the header containing the consumer which dead-lettered the message.
+	NATSHeaderDeadLetterConsumer = "Events-Dead-Letter-Consumer"
+	// NATSHeaderDeadLetterDeliveries is the header containing the number of times the message was delivered.
+	NATSHeaderDeadLetterDeliveries = "Events-Dead-Letter-Deliveries"
+	// NATSHeaderDeadLetterTimestamp is the header containing the time the message was dead-lettered.
+	NATSHeaderDeadLetterTimestamp = "Events-Dead-Letter-Timestamp"
+)
+
+var natsDeadLetterHeaders = []string{
+	NATSHeaderDeadLetterError,
+	NATSHeaderDeadLetterTopic,
+	NATSHeaderDeadLetterConsumer,
+	NATSHeaderDeadLetterDeliveries,
+	NATSHeaderDeadLetterTimestamp,
+}
+
+// natsMoveMsgID moves the jetstream message id to the HeaderMessageID header, so the republished message is not
+// de-duplicated while the id its signature covers is kept.
+func natsMoveMsgID(header nats.Header) {
+	if id := header.Get(nats.MsgIdHdr); id != "" {
+		header.Set(HeaderMessageID, id)
+	}
+
+	header.Del(nats.MsgIdHdr)
+}
+
+// signedSubject returns the subject the message was signed for. Dead-lettered messages were signed for their original topic.
+// The dead-letter topic header isn't signed, so it's only trusted when it's the topic DeadLetter publishes to the subject
+// the message was received on. A message may not be moved to the dead-letter subject of another topic.
+func (c *NATSConnection) signedSubject(subject string, header nats.Header) string {
+	topic := header.Get(NATSHeaderDeadLetterTopic)
+	if topic == "" {
+		return subject
+	}
+
+	relative, ok := strings.CutPrefix(topic, c.cfg.SubscribePrefix+subjectSeparator)
+	if !ok || c.deadLetterSubject(relative) != subject {
+		return subject
+	}
+
+	return topic
+}
+
+// deadLetterSubject returns the dead-letter subject of the topic, relative to the SubscribePrefix.
+func (c *NATSConnection) deadLetterSubject(topic string) string {
+	return c.buildSubscribeSubject(c.cfg.DeadLetterSubject, topic)
+}
+
+// DeadLetter describes a message which was routed to the dead-letter subject.
+type DeadLetter struct {
+	// Stream is the name of the stream the dead-lettered message is stored in.
+	Stream string
+	// Sequence is the stream sequence of the dead-lettered message.
+	Sequence uint64
+	// Subject is the dead-letter subject the message was published to.
+	Subject string
+	// Topic is the subject the message was originally published to.
+	Topic string
+	// Consumer is the consumer which dead-lettered the message.
+	Consumer string
+	// Error is the error recorded when the message was dead-lettered.
+	Error string
+	// Deliveries is the number of times the message was delivered before being dead-lettered.
+	Deliveries uint64
+	// Timestamp is the time the message was dead-lettered.
+	Timestamp time.Time
+	// Data is the original message payload.
+	Data []byte
+	// Header contains the original message headers.
+	Header nats.Header
+}
+
+// deadLetterExceeded reports whether the message has reached the configured max deliveries.
+func (m *NATSMessage[T]) deadLetterExceeded(offset uint64) bool {
+	maxDeliveries := m.conn.cfg.DeadLetterMaxDeliveries
+
+	return maxDeliveries > 0 && m.Deliveries()+offset > uint64(maxDeliveries)
+}
+
+// DeadLetter republishes the original message to the dead-letter subject recording the provided error and terminates the message.
+func (m *NATSMessage[T]) DeadLetter(cause error) error {
+	if cause == nil {
+		cause = ErrNATSMaxDeliveriesExceeded
+	}
+
+	metadata := m.metadata()
+
+	subject := m.conn.deadLetterSubject(strings.TrimPrefix(m.source.Subject, m.conn.cfg.SubscribePrefix+subjectSeparator))
+
+	dlMsg := nats.NewMsg(subject)
+	dlMsg.Data = m.source.Data
+
+	for key, values := range m.source.Header {
+		dlMsg.Header[key] = values
+	}
+
+	// the original message id would cause the dead-lettered and redriven messages to be dropped as duplicates.
+	// the id is kept in the message id header, as message signatures cover the id.
+	natsMoveMsgID(dlMsg.Header)
+
+	dlMsg.Header.Set(NATSHeaderDeadLetterError, cause.Error())
+	dlMsg.Header.Set(NATSHeaderDeadLetterTopic, m.source.Subject)
+	dlMsg.Header.Set(NATSHeaderDeadLetterConsumer, metadata.Consumer)
+	dlMsg.Header.Set(NATSHeaderDeadLetterDeliveries, strconv.FormatUint(metadata.NumDelivered, base10))
+	dlMsg.Header.Set(NATSHeaderDeadLetterTimestamp, time.Now().UTC().Format(time.RFC3339Nano))
+
+	// the jetstream default timeout applies as the context has no deadline.
+	if _, err := m.conn.jetstream.PublishMsg(context.Background(), dlMsg); err != nil {
+		return err
+	}
+
+	m.conn.logger.Warnw("message dead-lettered",
+		"nats.subject", m.source.Subject,
+		"nats.dead_letter_subject", subject,
+		"nats.consumer", metadata.Consumer,
+		"nats.deliveries", metadata.NumDelivered,
+		"error", cause,
+	)
+
+	return m.Term()
+}
+
+// ListDeadLetters returns all dead-lettered messages matching the provided topic.
+// The topic is relative to the dead-letter subject, for example "changes.>".
+func (c *NATSConnection) ListDeadLetters(ctx context.Context, topic string) ([]DeadLetter, error) {
+	subject := c.buildSubscribeSubject(c.cfg.DeadLetterSubject, topic)
+
+	stream, err := c.jetstream.StreamNameBySubject(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	// ordered consumers replay the stream without acks and are removed by the server once inactive.
+	consumer, err := c.jetstream.OrderedConsumer(ctx, stream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{subject},
+		DeliverPolicy:  jetstream.DeliverAllPolicy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		deadLetters []DeadLetter
+		remaining   = consumer.CachedInfo().NumPending
+	)
+
+	for remaining > 0 {
+		batch, err := consumer.FetchNoWait(c.cfg.SubscriberFetchBatchSize)
+		if err != nil {
+			return deadLetters, err
+		}
+
+		received := 0
+
+		for msg := range batch.Messages() {
+			metadata, err := msg.Metadata()
+			if err != nil {
+				return deadLetters, err
+			}
+
+			deadLetters = append(deadLetters, natsDeadLetterFromMsg(metadata.Stream, metadata.Sequence.Stream, msg.Subject(), msg.Headers(), msg.Data()))
+
+			remaining = metadata.NumPending
+			received++
+		}
+
+		if err := batch.Error(); err != nil {
+			return deadLetters, err
+		}
+
+		// pending messages may have been removed since the consumer was created.
+		if received == 0 {
+			break
+		}
+	}
+
+	return deadLetters, nil
+}
+
+// RedriveDeadLetter republishes a dead-lettered message to its original topic and removes it from the dead-letter subject.
+func (c *NATSConnection) RedriveDeadLetter(ctx context.Context, deadLetter DeadLetter) error {
+	stream, err := c.jetstream.Stream(ctx, deadLetter.Stream)
+	if err != nil {
+		return err
+	}
+
+	rawMsg, err := stream.GetMsg(ctx, deadLetter.Sequence)
+	if err != nil {
+		return err
+	}
+
+	stored := natsDeadLetterFromMsg(deadLetter.Stream, rawMsg.Sequence, rawMsg.Subject, rawMsg.Header, rawMsg.Data)
+	if stored.Topic == "" {
+		return ErrNATSMessageNotDeadLettered
+	}
+
+	msg := nats.NewMsg(stored.Topic)
+	msg.Data = stored.Data
+	msg.Header = stored.Header
+
+	if _, err := c.jetstream.PublishMsg(ctx, msg); err != nil {
+		return err
+	}
+
+	c.logger.Infow("dead-lettered message redriven", "nats.subject", msg.Subject, "nats.stream_sequence", deadLetter.Sequence)
+
+	if err := stream.DeleteMsg(ctx, deadLetter.Sequence); err != nil && !errors.Is(err, jetstream.ErrMsgNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+func natsDeadLetterFromMsg(stream string, seq uint64, subject string, header nats.Header, data []byte) DeadLetter {
+	deadLetter := DeadLetter{
+		Stream:   stream,
+		Sequence: seq,
+		Subject:  subject,
+		Topic:    header.Get(NATSHeaderDeadLetterTopic),
+		Consumer: header.Get(NATSHeaderDeadLetterConsumer),
+		Error:    header.Get(NATSHeaderDeadLetterError),
+		Data:     data,
+		Header:   nats.Header{},
+	}
+
+	deadLetter.Deliveries, _ = strconv.ParseUint(header.Get(NATSHeaderDeadLetterDeliveries), base10, bitSize64)
+	deadLetter.Timestamp, _ = time.Parse(time.RFC3339Nano, header.Get(NATSHeaderDeadLetterTimestamp))
+
+	for key, values := range header {
+		deadLetter.Header[key] = values
+	}
+
+	for _, key := range natsDeadLetterHeaders {
+		deadLetter.Header.Del(key)
+	}
+
+	return deadLetter
+}
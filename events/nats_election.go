@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// LeaderCallbacks are called by NATSLeaderElection.Run as leadership is elected and revoked.
+type LeaderCallbacks struct {
+	// OnElected is called in a new goroutine when leadership is acquired, with the fencing token of the lease.
+	// The context is canceled when leadership is revoked, work started by OnElected must stop when it is done.
+	OnElected func(ctx context.Context, token uint64)
+	// OnRevoked is called when leadership is revoked, after OnElected has returned.
+	OnRevoked func()
+}
+
+// NewLeaderElection returns a leader election for the named lock, the leader is the holder of the lock lease.
+// Replicas running the election with the same name and bucket elect a single leader between them.
+func (c *NATSConnection) NewLeaderElection(ctx context.Context, name string, callbacks LeaderCallbacks, options ...LockOption) (*NATSLeaderElection, error) {
+	mutex, err := c.NewMutex(ctx, name, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATSLeaderElection{
+		mutex:     mutex,
+		callbacks: callbacks,
+	}, nil
+}
+
+// NATSLeaderElection elects a leader between replicas by acquiring a NATSMutex lease.
+type NATSLeaderElection struct {
+	mutex     *NATSMutex
+	callbacks LeaderCallbacks
+}
+
+// IsLeader reports whether the election is currently the leader.
+func (e *NATSLeaderElection) IsLeader() bool {
+	return e.mutex.Held()
+}
+
+// Token returns the fencing token of the leader lease, zero when not the leader.
+func (e *NATSLeaderElection) Token() uint64 {
+	return e.mutex.Token()
+}
+
+// Run campaigns for leadership until the context is canceled.
+// When elected, OnElected is called and the lease is renewed until it is lost, after which OnRevoked is called
+// and the election campaigns again. When the context is canceled, leadership is revoked and the lease is released
+// so another replica is elected without waiting for the lease to expire.
+func (e *NATSLeaderElection) Run(ctx context.Context) error {
+	logger := e.mutex.conn.logger.With("lock", e.mutex.name, "lock.holder", e.mutex.cfg.holder)
+
+	for {
+		lease, err := e.mutex.Lock(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			logger.Warnw("failed to campaign for leadership, retrying", "error", err)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(e.mutex.cfg.retryInterval):
+			}
+
+			continue
+		}
+
+		logger.Infow("elected leader", "lock.token", lease.Token)
+
+		e.lead(ctx, lease)
+
+		logger.Infow("leadership revoked", "lock.token", lease.Token)
+
+		if ctx.Err() != nil {
+			e.resign(ctx)
+
+			return nil
+		}
+	}
+}
+
+// lead runs the OnElected callback until the lease is lost or the context is canceled, then calls OnRevoked.
+// The lease captured on acquisition is observed, so a lease lost right after it was acquired revokes leadership.
+func (e *NATSLeaderElection) lead(ctx context.Context, lease *NATSLease) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+
+	elected := make(chan struct{})
+
+	go func() {
+		defer close(elected)
+
+		if e.callbacks.OnElected != nil {
+			e.callbacks.OnElected(leaderCtx, lease.Token)
+		}
+	}()
+
+	select {
+	case <-lease.Lost:
+	case <-ctx.Done():
+	}
+
+	cancel()
+
+	<-elected
+
+	if e.callbacks.OnRevoked != nil {
+		e.callbacks.OnRevoked()
+	}
+}
+
+// resign releases the leader lease after the election context was canceled.
+func (e *NATSLeaderElection) resign(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.mutex.cfg.renewInterval)
+	defer cancel()
+
+	if err := e.mutex.Unlock(ctx); err != nil && !errors.Is(err, ErrNATSLockNotHeld) {
+		e.mutex.conn.logger.Warnw("failed to release leadership", "lock", e.mutex.name, "lock.holder", e.mutex.cfg.holder, "error", err)
+	}
+}
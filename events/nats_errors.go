@@ -3,12 +3,67 @@ package events
 import "errors"
 
 var (
-	// ErrNATSInvalidAuthConfiguration is returned when the config has both Tokena nd CredsFile specified.
-	ErrNATSInvalidAuthConfiguration = errors.New("invalid nats confinguration, both token and creds file are specified")
+	// ErrNATSInvalidAuthConfiguration is returned when the config has more than one of Token, CredsFile, NKeySeedFile and User specified,
+	// or a Password without a User.
+	ErrNATSInvalidAuthConfiguration = errors.New("invalid nats configuration, only one of token, creds file, nkey seed file or user may be specified")
+
+	// ErrNATSInvalidTLSConfiguration is returned when only one of TLSCertFile and TLSKeyFile is specified.
+	ErrNATSInvalidTLSConfiguration = errors.New("invalid nats configuration, tls cert file and key file must be specified together")
+
+	// ErrNATSInvalidReconnectConfiguration is returned when a reconnect option is negative.
+	ErrNATSInvalidReconnectConfiguration = errors.New("invalid nats configuration, reconnect options must not be negative")
 
 	// ErrNATSInvalidDeliveryPolicy is returned when an incorrect delivery policy is provided.
 	ErrNATSInvalidDeliveryPolicy = errors.New("invalid delivery policy, expected all|last|last-per-subject|new|start-sequence|start-time")
 
+	// ErrNATSInvalidAckWait is returned when a negative subscriber ack wait is provided.
+	ErrNATSInvalidAckWait = errors.New("invalid subscriber ack wait, must not be negative")
+
 	// ErrNATSMessageNoReplySubject is returned when calling ReplyAuthRelationshipRequest when the request has no reply subject defined.
 	ErrNATSMessageNoReplySubject = errors.New("unable to reply to auth relationship request, no reply subject specified")
+
+	// ErrNATSMaxDeliveriesExceeded is recorded on dead-lettered messages which exceeded the configured max deliveries.
+	ErrNATSMaxDeliveriesExceeded = errors.New("message exceeded max deliveries")
+
+	// ErrNATSMessageNotDeadLettered is returned when redriving a message which has no dead-letter topic recorded.
+	ErrNATSMessageNotDeadLettered = errors.New("message is not a dead-lettered message")
+
+	// ErrNATSInvalidStreamConfig is returned when a provisioned stream configuration is invalid.
+	ErrNATSInvalidStreamConfig = errors.New("invalid nats stream configuration")
+
+	// ErrNATSInvalidConsumerConfig is returned when a provisioned consumer configuration is invalid.
+	ErrNATSInvalidConsumerConfig = errors.New("invalid nats consumer configuration")
+
+	// ErrNATSProvisionFailed is returned when provisioning streams or consumers fails.
+	ErrNATSProvisionFailed = errors.New("failed to provision nats resources")
+
+	// ErrNATSNotConnected is returned by HealthCheck when the connection to the server is not established.
+	ErrNATSNotConnected = errors.New("nats connection not connected")
+
+	// ErrNATSJetStreamUnavailable is returned by HealthCheck when jetstream is not available on the server.
+	ErrNATSJetStreamUnavailable = errors.New("nats jetstream unavailable")
+
+	// ErrNATSStreamMissing is returned by HealthCheck when a configured stream does not exist.
+	ErrNATSStreamMissing = errors.New("nats stream missing")
+
+	// ErrNATSConsumerMissing is returned by HealthCheck when a configured consumer does not exist.
+	ErrNATSConsumerMissing = errors.New("nats consumer missing")
+
+	// ErrNATSConsumerLagExceeded is returned by HealthCheck when a configured consumer has more pending messages than allowed.
+	ErrNATSConsumerLagExceeded = errors.New("nats consumer lag exceeded")
+
+	// ErrNATSInvalidLockName is returned when a lock name contains characters not allowed in KV keys.
+	ErrNATSInvalidLockName = errors.New("invalid nats lock name")
+
+	// ErrNATSInvalidLockConfig is returned when a lock TTL, renew or retry interval is invalid.
+	ErrNATSInvalidLockConfig = errors.New("invalid nats lock configuration")
+
+	// ErrNATSLockTTLMismatch is returned when an existing lock bucket has a different TTL than the lock.
+	ErrNATSLockTTLMismatch = errors.New("nats lock bucket ttl mismatch")
+
+	// ErrNATSLockHeld is returned when acquiring a lock which is already held by the mutex.
+	ErrNATSLockHeld = errors.New("nats lock already held")
+
+	// ErrNATSLockNotHeld is returned when releasing a lock which is not held, including when its lease was lost.
+	ErrNATSLockNotHeld = errors.New("nats lock not held")
 )
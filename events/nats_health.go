@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// HealthCheck returns an error if the connection to the server is not established or jetstream is not available.
+// When HealthCheckProvisioned is enabled, the configured streams and consumers must exist.
+// When HealthCheckMaxConsumerLag is set, the configured consumers must not have more pending messages than allowed.
+func (c *NATSConnection) HealthCheck(ctx context.Context) error {
+	if !c.conn.IsConnected() {
+		err := fmt.Errorf("%w: %s", ErrNATSNotConnected, c.conn.Status())
+
+		if lastErr := c.conn.LastError(); lastErr != nil {
+			err = fmt.Errorf("%w: %w", err, lastErr)
+		}
+
+		return err
+	}
+
+	if _, err := c.jetstream.AccountInfo(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrNATSJetStreamUnavailable, err)
+	}
+
+	if c.cfg.HealthCheckProvisioned {
+		for _, stream := range c.cfg.Streams {
+			if _, err := c.jetstream.Stream(ctx, stream.Name); err != nil {
+				return healthResourceError(ErrNATSStreamMissing, stream.Name, err, jetstream.ErrStreamNotFound)
+			}
+		}
+	}
+
+	if !c.cfg.HealthCheckProvisioned && c.cfg.HealthCheckMaxConsumerLag == 0 {
+		return nil
+	}
+
+	for _, consumer := range c.cfg.Consumers {
+		durable := c.consumerConfig(c.buildSubscribeSubject(consumer.Topic), consumer).Durable
+
+		cons, err := c.jetstream.Consumer(ctx, consumer.Stream, durable)
+		if err != nil {
+			if !c.cfg.HealthCheckProvisioned && errors.Is(err, jetstream.ErrConsumerNotFound) {
+				continue
+			}
+
+			return healthResourceError(ErrNATSConsumerMissing, durable, err, jetstream.ErrConsumerNotFound)
+		}
+
+		if c.cfg.HealthCheckMaxConsumerLag == 0 {
+			continue
+		}
+
+		if pending := cons.CachedInfo().NumPending; pending > c.cfg.HealthCheckMaxConsumerLag {
+			return fmt.Errorf("%w: %s has %d pending messages, max %d", ErrNATSConsumerLagExceeded, durable, pending, c.cfg.HealthCheckMaxConsumerLag)
+		}
+	}
+
+	return nil
+}
+
+// healthResourceError returns missing if err is notFound, otherwise the error from the server.
+func healthResourceError(missing error, name string, err, notFound error) error {
+	if errors.Is(err, notFound) {
+		return fmt.Errorf("%w: %s", missing, name)
+	}
+
+	return fmt.Errorf("%w: %s: %w", ErrNATSJetStreamUnavailable, name, err)
+}
+
+// handlerOptions returns the connection options which log connection events and call the configured handlers.
+func (c NATSConfig) handlerOptions() []nats.Option {
+	logger := c.logger
+
+	return []nats.Option{
+		nats.DisconnectErrHandler(func(conn *nats.Conn, err error) {
+			if err != nil {
+				logger.Warnw("nats connection lost", "nats.url", conn.ConnectedUrlRedacted(), "error", err)
+			} else {
+				logger.Infow("nats connection disconnected", "nats.url", conn.ConnectedUrlRedacted())
+			}
+
+			for _, handler := range c.disconnectHandlers {
+				handler(err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.Infow("nats connection restored", "nats.url", conn.ConnectedUrlRedacted(), "nats.reconnects", conn.Stats().Reconnects)
+
+			for _, handler := range c.reconnectHandlers {
+				handler()
+			}
+		}),
+		nats.ErrorHandler(func(_ *nats.Conn, sub *nats.Subscription, err error) {
+			if sub != nil {
+				logger.Errorw("nats async error", "nats.subject", sub.Subject, "error", err)
+			} else {
+				logger.Errorw("nats async error", "error", err)
+			}
+
+			for _, handler := range c.errorHandlers {
+				handler(err)
+			}
+		}),
+	}
+}
@@ -0,0 +1,91 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestNATSHealthCheck(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	disconnected := make(chan error, 1)
+
+	natsCfg := nats.Config.NATS
+	natsCfg.HealthCheckProvisioned = true
+	natsCfg.HealthCheckMaxConsumerLag = 1
+	natsCfg.Streams = []events.NATSStreamConfig{
+		{
+			Name:     "health-tests",
+			Subjects: []string{"health.>"},
+		},
+	}
+	natsCfg.Consumers = []events.NATSConsumerConfig{
+		{
+			Stream:  "health-tests",
+			Topic:   "health.>",
+			Durable: "health-consumer",
+		},
+	}
+
+	conn, err := events.NewNATSConnection(natsCfg, events.WithNATSDisconnectHandler(func(err error) {
+		disconnected <- err
+	}))
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	var check func(ctx context.Context) error = conn.HealthCheck
+
+	require.NoError(t, check(ctx))
+
+	// consumer lag
+	for range 2 {
+		_, err = events.Publish(ctx, conn, "health", "test", testInvoice{ID: "invoice"})
+		require.NoError(t, err)
+	}
+
+	require.ErrorIs(t, check(ctx), events.ErrNATSConsumerLagExceeded)
+
+	// missing consumers and streams
+	require.NoError(t, nats.JetStream.DeleteConsumer("health-tests", "health-consumer"))
+	require.ErrorIs(t, check(ctx), events.ErrNATSConsumerMissing)
+
+	require.NoError(t, nats.JetStream.DeleteStream("health-tests"))
+	require.ErrorIs(t, check(ctx), events.ErrNATSStreamMissing)
+
+	// disconnects
+	nats.Server.Shutdown()
+
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		require.Fail(t, "disconnect handler not called")
+	}
+
+	require.ErrorIs(t, check(ctx), events.ErrNATSNotConnected)
+}
+
+func TestMemoryHealthCheck(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+	require.NoError(t, err)
+
+	require.NoError(t, conn.HealthCheck(ctx))
+
+	require.NoError(t, conn.Shutdown(ctx))
+
+	assert.ErrorIs(t, conn.HealthCheck(ctx), events.ErrMemoryConnectionClosed)
+}
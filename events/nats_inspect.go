@@ -0,0 +1,289 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"golang.org/x/time/rate"
+)
+
+// natsStreamRange selects the messages read from a stream, the zero value selects all messages.
+type natsStreamRange struct {
+	// FromSequence is the first stream sequence to read.
+	FromSequence uint64
+	// ToSequence is the last stream sequence to read.
+	ToSequence uint64
+	// Since is the time to start reading from.
+	Since time.Time
+	// Until is the time to stop reading at.
+	Until time.Time
+}
+
+func (r natsStreamRange) orderedConsumerConfig(subject string) jetstream.OrderedConsumerConfig {
+	cfg := jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{subject},
+		DeliverPolicy:  jetstream.DeliverAllPolicy,
+	}
+
+	switch {
+	case r.FromSequence != 0:
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = r.FromSequence
+	case !r.Since.IsZero():
+		since := r.Since
+
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &since
+	}
+
+	return cfg
+}
+
+// after reports whether the message is after the end of the range.
+func (r natsStreamRange) after(metadata *jetstream.MsgMetadata) bool {
+	if r.ToSequence != 0 && metadata.Sequence.Stream > r.ToSequence {
+		return true
+	}
+
+	return !r.Until.IsZero() && metadata.Timestamp.After(r.Until)
+}
+
+// orderedConsumer creates an ordered consumer reading the messages on the topic, relative to the SubscribePrefix,
+// from the stream which captures them, returning the last sequence of the stream when the consumer was created.
+// Ordered consumers are ephemeral and do not affect the delivery of messages to other consumers.
+func (c *NATSConnection) orderedConsumer(ctx context.Context, topic string, cfg func(subject string) jetstream.OrderedConsumerConfig) (jetstream.Consumer, uint64, error) {
+	subject := c.buildSubscribeSubject(topic)
+
+	streamName, err := c.jetstream.StreamNameBySubject(ctx, subject)
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding stream for %s: %w", subject, err)
+	}
+
+	stream, err := c.jetstream.Stream(ctx, streamName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	consumer, err := c.jetstream.OrderedConsumer(ctx, streamName, cfg(subject))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return consumer, stream.CachedInfo().State.LastSeq, nil
+}
+
+// tail writes the messages on the topic to out as they're received until the context is canceled or count messages were written.
+// By default only new messages are written, the range selects where to start from.
+func (c *NATSConnection) tail(ctx context.Context, topic string, start natsStreamRange, count int, headers bool, out io.Writer) error {
+	consumer, _, err := c.orderedConsumer(ctx, topic, func(subject string) jetstream.OrderedConsumerConfig {
+		cfg := start.orderedConsumerConfig(subject)
+
+		if start.FromSequence == 0 && start.Since.IsZero() {
+			cfg.DeliverPolicy = jetstream.DeliverNewPolicy
+		}
+
+		return cfg
+	})
+	if err != nil {
+		return err
+	}
+
+	iter, err := consumer.Messages()
+	if err != nil {
+		return err
+	}
+
+	defer iter.Stop()
+
+	// Stopping the iterator releases any pending Next call.
+	stop := context.AfterFunc(ctx, iter.Stop)
+
+	defer stop()
+
+	for written := 0; count == 0 || written < count; written++ {
+		msg, err := iter.Next()
+		if err != nil {
+			if errors.Is(err, jetstream.ErrMsgIteratorClosed) && ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := c.writeMessage(out, msg, headers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeMessage pretty prints the message, decoding change and event messages so any encoding is printed the same.
+func (c *NATSConnection) writeMessage(out io.Writer, msg jetstream.Msg, headers bool) error {
+	header := msg.Subject()
+
+	if metadata, err := msg.Metadata(); err == nil {
+		header += fmt.Sprintf(" seq=%d time=%s", metadata.Sequence.Stream, metadata.Timestamp.UTC().Format(time.RFC3339Nano))
+	}
+
+	if _, err := fmt.Fprintf(out, "--- %s\n", header); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(msg.Headers()))
+
+	for key := range msg.Headers() {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	if headers {
+		for _, key := range keys {
+			if _, err := fmt.Fprintf(out, "%s: %s\n", key, strings.Join(msg.Headers().Values(key), ", ")); err != nil {
+				return err
+			}
+		}
+	}
+
+	var message any
+
+	relative := strings.TrimPrefix(msg.Subject(), c.cfg.SubscribePrefix+subjectSeparator)
+
+	switch {
+	case strings.HasPrefix(relative, "changes"+subjectSeparator):
+		message = new(ChangeMessage)
+	case strings.HasPrefix(relative, "events"+subjectSeparator):
+		message = new(EventMessage)
+	default:
+		message = new(map[string]any)
+	}
+
+	var body []byte
+
+	payload, err := c.security.open(c.signedSubject(msg.Subject(), msg.Headers()), msg.Headers().Get, keys, msg.Data())
+	if err == nil {
+		err = decodePayload(msg.Headers().Get, keys, payload, message, nil)
+	}
+
+	if err != nil {
+		// messages which fail to decode are printed as received.
+		body = msg.Data()
+	} else {
+		body, err = json.MarshalIndent(message, "", "  ")
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(out, "%s\n", body)
+
+	return err
+}
+
+// replay republishes the messages in the range on the topic, relative to the SubscribePrefix, returning the number of messages replayed.
+// Messages are published to the target, relative to the PublishPrefix, or to their original subject if no target is provided.
+// Messages stored after the replay started are not replayed, so replaying into a subject read by the replay completes.
+// A limit greater than zero limits the number of messages replayed per second.
+// Signed and encrypted messages are bound to their original subject, so messages replayed to another target fail verification.
+func (c *NATSConnection) replay(ctx context.Context, topic string, replayRange natsStreamRange, target string, limit float64) (int, error) {
+	consumer, lastSeq, err := c.orderedConsumer(ctx, topic, replayRange.orderedConsumerConfig)
+	if err != nil {
+		return 0, err
+	}
+
+	if replayRange.ToSequence == 0 || replayRange.ToSequence > lastSeq {
+		replayRange.ToSequence = lastSeq
+	}
+
+	limiter := rate.NewLimiter(rate.Inf, 1)
+
+	if limit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(limit), 1)
+	}
+
+	var (
+		replayed  int
+		remaining = consumer.CachedInfo().NumPending
+	)
+
+	for remaining > 0 {
+		batch, err := consumer.FetchNoWait(c.cfg.SubscriberFetchBatchSize)
+		if err != nil {
+			return replayed, err
+		}
+
+		received := 0
+
+		for msg := range batch.Messages() {
+			received++
+
+			metadata, err := msg.Metadata()
+			if err != nil {
+				return replayed, err
+			}
+
+			if replayRange.after(metadata) {
+				return replayed, nil
+			}
+
+			if err := c.replayMessage(ctx, limiter, msg, metadata, target); err != nil {
+				return replayed, err
+			}
+
+			replayed++
+
+			remaining = metadata.NumPending
+		}
+
+		if err := batch.Error(); err != nil {
+			return replayed, err
+		}
+
+		// pending messages may have been removed since the consumer was created.
+		if received == 0 {
+			break
+		}
+	}
+
+	return replayed, nil
+}
+
+func (c *NATSConnection) replayMessage(ctx context.Context, limiter *rate.Limiter, msg jetstream.Msg, metadata *jetstream.MsgMetadata, target string) error {
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	subject := msg.Subject()
+
+	if target != "" {
+		subject = c.buildPublishSubject(target)
+	}
+
+	nMsg := nats.NewMsg(subject)
+	nMsg.Data = msg.Data()
+
+	for key, values := range msg.Headers() {
+		nMsg.Header[key] = slices.Clone(values)
+	}
+
+	// replayed messages must not be discarded as duplicates of the original.
+	natsMoveMsgID(nMsg.Header)
+
+	// replayed messages keep the id of the original, which their signature covers, the header lets subscribers
+	// de-duplicating by message id process replayed messages again.
+	nMsg.Header.Set(HeaderReplayedFrom, metadata.Stream+subjectSeparator+strconv.FormatUint(metadata.Sequence.Stream, base10))
+
+	_, err := c.jetstream.PublishMsg(ctx, nMsg)
+
+	return err
+}
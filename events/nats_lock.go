@@ -0,0 +1,427 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var (
+	// NATSDefaultLockBucket is the default KV bucket lock leases are held in.
+	NATSDefaultLockBucket = "locks"
+	// NATSDefaultLockTTL is the default time a lock lease is held for without being renewed.
+	NATSDefaultLockTTL = 15 * time.Second
+
+	natsLockNameRe = regexp.MustCompile(`^[-/_=\.a-zA-Z0-9]+$`)
+)
+
+// LockOption configures a NATSMutex or NATSLeaderElection.
+type LockOption func(cfg *lockConfig)
+
+type lockConfig struct {
+	bucket        string
+	ttl           time.Duration
+	renewInterval time.Duration
+	retryInterval time.Duration
+	holder        string
+}
+
+// WithLockBucket sets the KV bucket the lock lease is held in, defaults to NATSDefaultLockBucket.
+func WithLockBucket(bucket string) LockOption {
+	return func(cfg *lockConfig) {
+		cfg.bucket = bucket
+	}
+}
+
+// WithLockTTL sets the time the lease is held for without being renewed, defaults to NATSDefaultLockTTL.
+// The TTL is set on the bucket when it is created, all locks sharing a bucket must use the same TTL.
+func WithLockTTL(ttl time.Duration) LockOption {
+	return func(cfg *lockConfig) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithLockRenewInterval sets how often a held lease is renewed, defaults to a third of the TTL.
+func WithLockRenewInterval(interval time.Duration) LockOption {
+	return func(cfg *lockConfig) {
+		cfg.renewInterval = interval
+	}
+}
+
+// WithLockRetryInterval sets how often Lock retries to acquire a lease held by another holder, defaults to a third of the TTL.
+func WithLockRetryInterval(interval time.Duration) LockOption {
+	return func(cfg *lockConfig) {
+		cfg.retryInterval = interval
+	}
+}
+
+// WithLockHolder sets the holder id recorded in the lease, defaults to a random id.
+func WithLockHolder(holder string) LockOption {
+	return func(cfg *lockConfig) {
+		cfg.holder = holder
+	}
+}
+
+// natsLease is the value stored in the KV bucket for a held lock.
+type natsLease struct {
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at"`
+}
+
+// lockBucket returns the lock KV bucket, creating it with the TTL when it does not exist.
+// An existing bucket must have the same TTL, otherwise leases would expire earlier or later than expected.
+func (c *NATSConnection) lockBucket(ctx context.Context, bucket string, ttl time.Duration) (jetstream.KeyValue, error) {
+	kv, err := c.jetstream.KeyValue(ctx, bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		kv, err = c.jetstream.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "lock leases",
+			TTL:         ttl,
+		})
+
+		// another connection may have created the bucket concurrently.
+		if errors.Is(err, jetstream.ErrBucketExists) {
+			kv, err = c.jetstream.KeyValue(ctx, bucket)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := kv.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.TTL() != ttl {
+		return nil, fmt.Errorf("%w: bucket %s has ttl %s, expected %s", ErrNATSLockTTLMismatch, bucket, status.TTL(), ttl)
+	}
+
+	return kv, nil
+}
+
+// NewMutex returns a distributed mutex for the named lock, held as a lease in a KV bucket.
+// The lease expires when it is not renewed within the TTL, so a lock held by a crashed holder is released after the TTL.
+func (c *NATSConnection) NewMutex(ctx context.Context, name string, options ...LockOption) (*NATSMutex, error) {
+	if !natsLockNameRe.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", ErrNATSInvalidLockName, name)
+	}
+
+	cfg := lockConfig{
+		bucket: NATSDefaultLockBucket,
+		ttl:    NATSDefaultLockTTL,
+		holder: uuid.NewString(),
+	}
+
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	if cfg.ttl <= 0 {
+		return nil, fmt.Errorf("%w: ttl must be positive", ErrNATSInvalidLockConfig)
+	}
+
+	if cfg.renewInterval == 0 {
+		cfg.renewInterval = cfg.ttl / 3 //nolint:mnd
+	}
+
+	if cfg.retryInterval == 0 {
+		cfg.retryInterval = cfg.ttl / 3 //nolint:mnd
+	}
+
+	if cfg.renewInterval <= 0 || cfg.renewInterval >= cfg.ttl {
+		return nil, fmt.Errorf("%w: renew interval must be positive and less than the ttl", ErrNATSInvalidLockConfig)
+	}
+
+	if cfg.retryInterval <= 0 {
+		return nil, fmt.Errorf("%w: retry interval must be positive", ErrNATSInvalidLockConfig)
+	}
+
+	kv, err := c.lockBucket(ctx, cfg.bucket, cfg.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATSMutex{
+		conn: c,
+		kv:   kv,
+		name: name,
+		cfg:  cfg,
+	}, nil
+}
+
+// NATSLease is a lease acquired by a NATSMutex.
+// The lease is captured when it is acquired, so it remains valid to observe after the lease is lost.
+type NATSLease struct {
+	// Token is the fencing token of the lease.
+	Token uint64
+	// Lost is closed when the lease is lost or released.
+	Lost <-chan struct{}
+}
+
+// NATSMutex is a distributed lock held as a lease in a NATS KV bucket.
+// While held, the lease is renewed in the background. Leases which fail to renew before the TTL are lost,
+// which is signaled by closing the channel returned by Lost.
+//
+// Each acquired lease has a fencing token, greater than the token of any previous lease of the lock.
+// Resources protected by the lock should reject writes with a token lower than the last token seen,
+// as a holder may not observe a lost lease before another holder acquires it.
+type NATSMutex struct {
+	conn *NATSConnection
+	kv   jetstream.KeyValue
+	name string
+	cfg  lockConfig
+
+	mu         sync.Mutex
+	held       bool
+	token      uint64
+	revision   uint64
+	acquiredAt time.Time
+	lost       chan struct{}
+	stop       context.CancelFunc
+	done       chan struct{}
+}
+
+// Name returns the name of the lock.
+func (m *NATSMutex) Name() string {
+	return m.name
+}
+
+// Holder returns the holder id recorded in leases acquired by the mutex.
+func (m *NATSMutex) Holder() string {
+	return m.cfg.holder
+}
+
+// Token returns the fencing token of the held lease, zero when the lock is not held.
+func (m *NATSMutex) Token() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.held {
+		return 0
+	}
+
+	return m.token
+}
+
+// Held reports whether the lease is held and has not been lost.
+func (m *NATSMutex) Held() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.held
+}
+
+// Lost returns a channel closed when the held lease is lost or released.
+// Nil is returned when the lock is not held, including when the lease was already lost, use the
+// NATSLease returned when the lease was acquired to observe a lease which may be lost at any time.
+func (m *NATSMutex) Lost() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.held {
+		return nil
+	}
+
+	return m.lost
+}
+
+// TryLock attempts to acquire the lease once, returning the acquired lease or nil when it is held by another holder.
+// ErrNATSLockHeld is returned when the lease is already held by the mutex.
+func (m *NATSMutex) TryLock(ctx context.Context) (*NATSLease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.held {
+		return nil, ErrNATSLockHeld
+	}
+
+	now := time.Now().UTC()
+
+	value, err := json.Marshal(natsLease{Holder: m.cfg.holder, AcquiredAt: now, RenewedAt: now})
+	if err != nil {
+		return nil, err
+	}
+
+	// create only succeeds when the key does not exist, was deleted when released or expired with the bucket ttl.
+	revision, err := m.kv.Create(ctx, m.name, value)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	m.conn.logger.Debugw("lock acquired", "lock", m.name, "lock.holder", m.cfg.holder, "lock.token", revision)
+
+	renewCtx, stop := context.WithCancel(context.Background())
+
+	m.held = true
+	m.token = revision
+	m.revision = revision
+	m.acquiredAt = now
+	m.lost = make(chan struct{})
+	m.stop = stop
+	m.done = make(chan struct{})
+
+	go m.renew(renewCtx, m.done, now)
+
+	return &NATSLease{Token: revision, Lost: m.lost}, nil
+}
+
+// Lock acquires the lease, retrying every retry interval until it is acquired or the context is canceled.
+func (m *NATSMutex) Lock(ctx context.Context) (*NATSLease, error) {
+	ticker := time.NewTicker(m.cfg.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		lease, err := m.TryLock(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if lease != nil {
+			return lease, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Unlock stops renewing the lease and releases it, allowing other holders to acquire the lock.
+// ErrNATSLockNotHeld is returned when the lock is not held, including when the lease was lost.
+func (m *NATSMutex) Unlock(ctx context.Context) error {
+	m.mu.Lock()
+
+	if !m.held {
+		m.mu.Unlock()
+
+		return ErrNATSLockNotHeld
+	}
+
+	stop, done := m.stop, m.done
+
+	m.mu.Unlock()
+
+	// wait for any in flight renewal, so the released revision is current.
+	stop()
+	<-done
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.held {
+		return ErrNATSLockNotHeld
+	}
+
+	m.release()
+
+	// deleting with the last revision ensures a lease acquired by another holder is not removed.
+	if err := m.kv.Delete(ctx, m.name, jetstream.LastRevision(m.revision)); err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return fmt.Errorf("%w: %w", ErrNATSLockNotHeld, err)
+		}
+
+		return err
+	}
+
+	m.conn.logger.Debugw("lock released", "lock", m.name, "lock.holder", m.cfg.holder, "lock.token", m.token)
+
+	return nil
+}
+
+// release marks the lease as no longer held, the mutex lock must be held.
+func (m *NATSMutex) release() {
+	if !m.held {
+		return
+	}
+
+	m.held = false
+
+	close(m.lost)
+}
+
+// renew renews the lease every renew interval until stopped.
+// The lease is lost when it was modified by another holder or could not be renewed before the TTL.
+func (m *NATSMutex) renew(ctx context.Context, done chan struct{}, renewedAt time.Time) {
+	defer close(done)
+
+	ticker := time.NewTicker(m.cfg.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := m.renewOnce(ctx)
+		if err == nil {
+			renewedAt = time.Now()
+
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		expired := time.Since(renewedAt) >= m.cfg.ttl
+
+		if !errors.Is(err, jetstream.ErrKeyExists) && !errors.Is(err, jetstream.ErrKeyNotFound) && !expired {
+			m.conn.logger.Warnw("failed to renew lock, retrying", "lock", m.name, "lock.holder", m.cfg.holder, "error", err)
+
+			continue
+		}
+
+		m.conn.logger.Warnw("lock lost", "lock", m.name, "lock.holder", m.cfg.holder, "lock.token", m.token, "error", err)
+
+		m.mu.Lock()
+		m.release()
+		m.mu.Unlock()
+
+		return
+	}
+}
+
+// renewOnce updates the lease, resetting its age in the bucket.
+func (m *NATSMutex) renewOnce(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.renewInterval)
+	defer cancel()
+
+	m.mu.Lock()
+	revision, acquiredAt := m.revision, m.acquiredAt
+	m.mu.Unlock()
+
+	value, err := json.Marshal(natsLease{Holder: m.cfg.holder, AcquiredAt: acquiredAt, RenewedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	// the update fails when the lease expired or was acquired by another holder since the last revision.
+	revision, err = m.kv.Update(ctx, m.name, value, revision)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.revision = revision
+	m.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,364 @@
+package events_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestNATSMutex(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	conn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	opts := []events.LockOption{
+		events.WithLockTTL(time.Second),
+		events.WithLockRetryInterval(time.Millisecond * 50),
+	}
+
+	first, err := conn.NewMutex(ctx, "reconciler", append(opts, events.WithLockHolder("first"))...)
+	require.NoError(t, err)
+
+	second, err := conn.NewMutex(ctx, "reconciler", append(opts, events.WithLockHolder("second"))...)
+	require.NoError(t, err)
+
+	firstLease, err := first.TryLock(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, firstLease)
+
+	firstToken := firstLease.Token
+	assert.NotZero(t, firstToken)
+	assert.Equal(t, firstToken, first.Token())
+
+	_, err = first.TryLock(ctx)
+	require.ErrorIs(t, err, events.ErrNATSLockHeld)
+
+	secondLease, err := second.TryLock(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, secondLease)
+	assert.Zero(t, second.Token())
+	assert.Nil(t, second.Lost())
+
+	// the lease is renewed while held, so it does not expire after the ttl.
+	time.Sleep(time.Second * 2)
+
+	require.True(t, first.Held())
+
+	secondLease, err = second.TryLock(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, secondLease)
+
+	lost := first.Lost()
+	assert.Equal(t, firstLease.Lost, lost)
+
+	locked := make(chan error, 1)
+
+	go func() {
+		_, err := second.Lock(ctx)
+
+		locked <- err
+	}()
+
+	require.NoError(t, first.Unlock(ctx))
+	require.ErrorIs(t, first.Unlock(ctx), events.ErrNATSLockNotHeld)
+
+	select {
+	case <-lost:
+	default:
+		t.Fatal("expected lost channel to be closed when released")
+	}
+
+	select {
+	case err := <-locked:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for lock")
+	}
+
+	assert.Greater(t, second.Token(), firstToken, "fencing token increases with each lease")
+
+	require.NoError(t, second.Unlock(ctx))
+
+	lockCtx, cancel := context.WithTimeout(ctx, time.Millisecond*100)
+	defer cancel()
+
+	_, err = first.Lock(lockCtx)
+	require.NoError(t, err)
+	require.NoError(t, first.Unlock(ctx))
+}
+
+func TestNATSMutexLeaseExpiry(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	conn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	mutex, err := conn.NewMutex(ctx, "reconciler",
+		events.WithLockTTL(time.Second),
+		events.WithLockRenewInterval(time.Millisecond*100),
+		events.WithLockRetryInterval(time.Millisecond*50),
+	)
+	require.NoError(t, err)
+
+	js, err := jetstream.New(nats.Conn)
+	require.NoError(t, err)
+
+	kv, err := js.KeyValue(ctx, events.NATSDefaultLockBucket)
+	require.NoError(t, err)
+
+	// a holder which crashed without releasing the lock, its lease is never renewed.
+	crashedToken, err := kv.Create(ctx, "reconciler", []byte(`{"holder":"crashed"}`))
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	lease, err := mutex.Lock(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*500, "expected lock to be acquired once the crashed lease expired")
+	assert.Greater(t, lease.Token, crashedToken)
+
+	lost := lease.Lost
+
+	// another holder taking over the lease, such as after a network partition, is detected on renewal.
+	require.NoError(t, kv.Purge(ctx, "reconciler"))
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for lease to be lost")
+	}
+
+	assert.False(t, mutex.Held())
+	assert.Zero(t, mutex.Token())
+	assert.Nil(t, mutex.Lost())
+	assert.NotZero(t, lease.Token, "the acquired lease is unchanged once lost")
+	require.ErrorIs(t, mutex.Unlock(ctx), events.ErrNATSLockNotHeld)
+}
+
+func TestNATSMutexValidation(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	conn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	_, err = conn.NewMutex(ctx, "invalid lock")
+	require.ErrorIs(t, err, events.ErrNATSInvalidLockName)
+
+	_, err = conn.NewMutex(ctx, "reconciler", events.WithLockTTL(time.Second), events.WithLockRenewInterval(time.Second))
+	require.ErrorIs(t, err, events.ErrNATSInvalidLockConfig)
+
+	_, err = conn.NewMutex(ctx, "reconciler", events.WithLockTTL(time.Second))
+	require.NoError(t, err)
+
+	_, err = conn.NewMutex(ctx, "reconciler", events.WithLockTTL(time.Minute))
+	require.ErrorIs(t, err, events.ErrNATSLockTTLMismatch)
+
+	_, err = conn.NewMutex(ctx, "reconciler", events.WithLockTTL(time.Minute), events.WithLockBucket("long_locks"))
+	require.NoError(t, err)
+}
+
+type leaderRecorder struct {
+	mu      sync.Mutex
+	elected []uint64
+	revoked int
+	leading bool
+}
+
+func (r *leaderRecorder) callbacks() events.LeaderCallbacks {
+	return events.LeaderCallbacks{
+		OnElected: func(ctx context.Context, token uint64) {
+			r.mu.Lock()
+			r.elected = append(r.elected, token)
+			r.leading = true
+			r.mu.Unlock()
+
+			<-ctx.Done()
+		},
+		OnRevoked: func() {
+			r.mu.Lock()
+			r.revoked++
+			r.leading = false
+			r.mu.Unlock()
+		},
+	}
+}
+
+func (r *leaderRecorder) isLeading() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.leading
+}
+
+func TestNATSLeaderElection(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	type replica struct {
+		recorder *leaderRecorder
+		election *events.NATSLeaderElection
+		cancel   context.CancelFunc
+		done     chan error
+	}
+
+	replicas := make([]*replica, 2)
+
+	for i := range replicas {
+		conn, err := events.NewNATSConnection(nats.Config.NATS)
+		require.NoError(t, err)
+
+		defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+		recorder := &leaderRecorder{}
+
+		election, err := conn.NewLeaderElection(ctx, "reconciler", recorder.callbacks(),
+			events.WithLockTTL(time.Second),
+			events.WithLockRetryInterval(time.Millisecond*50),
+		)
+		require.NoError(t, err)
+
+		runCtx, cancel := context.WithCancel(ctx)
+
+		defer cancel()
+
+		r := &replica{recorder: recorder, election: election, cancel: cancel, done: make(chan error, 1)}
+
+		go func() {
+			r.done <- election.Run(runCtx)
+		}()
+
+		replicas[i] = r
+	}
+
+	var leader, follower *replica
+
+	require.Eventually(t, func() bool {
+		for i, r := range replicas {
+			if r.recorder.isLeading() {
+				leader, follower = r, replicas[1-i]
+
+				return true
+			}
+		}
+
+		return false
+	}, time.Second*2, time.Millisecond*10)
+
+	// only a single replica is elected, including after the lease ttl.
+	time.Sleep(time.Second * 2)
+
+	assert.True(t, leader.recorder.isLeading())
+	assert.True(t, leader.election.IsLeader())
+	assert.False(t, follower.recorder.isLeading())
+	assert.False(t, follower.election.IsLeader())
+
+	leaderToken := leader.election.Token()
+
+	leader.cancel()
+
+	require.NoError(t, <-leader.done)
+
+	assert.False(t, leader.recorder.isLeading())
+	assert.Equal(t, 1, leader.recorder.revoked)
+	assert.Equal(t, []uint64{leaderToken}, leader.recorder.elected)
+
+	// the resigned lease is released, so the follower is elected before the ttl.
+	require.Eventually(t, follower.recorder.isLeading, time.Millisecond*500, time.Millisecond*10)
+	assert.Greater(t, follower.election.Token(), leaderToken)
+
+	follower.cancel()
+
+	require.NoError(t, <-follower.done)
+	assert.Equal(t, 1, follower.recorder.revoked)
+}
+
+func TestNATSLeaderElectionLeaseLost(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	conn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	recorder := &leaderRecorder{}
+
+	election, err := conn.NewLeaderElection(ctx, "reconciler", recorder.callbacks(),
+		events.WithLockTTL(time.Second),
+		events.WithLockRenewInterval(time.Millisecond*100),
+		events.WithLockRetryInterval(time.Millisecond*50),
+	)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- election.Run(ctx)
+	}()
+
+	require.Eventually(t, recorder.isLeading, time.Second*2, time.Millisecond*10)
+
+	js, err := jetstream.New(nats.Conn)
+	require.NoError(t, err)
+
+	kv, err := js.KeyValue(ctx, events.NATSDefaultLockBucket)
+	require.NoError(t, err)
+
+	// losing the lease revokes leadership and the election campaigns again.
+	require.NoError(t, kv.Purge(ctx, "reconciler"))
+
+	require.Eventually(t, func() bool {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+
+		return recorder.revoked == 1 && len(recorder.elected) == 2
+	}, time.Second*2, time.Millisecond*10)
+
+	recorder.mu.Lock()
+	assert.Greater(t, recorder.elected[1], recorder.elected[0])
+	recorder.mu.Unlock()
+
+	cancel()
+
+	require.NoError(t, <-done)
+}
@@ -2,26 +2,51 @@ package events
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
-	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/nats-io/nats.go"
-	"go.opentelemetry.io/otel"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/propagation"
 )
 
-func natsSubscriptionMessageChan[T any](ctx context.Context, conn *NATSConnection, batchSize int, natsCh <-chan *nats.Msg) chan Message[T] {
+func natsSubscriptionMessageChan[T any](ctx context.Context, conn *NATSConnection, subject string, batchSize int, jsCh <-chan jetstream.Msg) chan Message[T] {
 	msgCh := make(chan Message[T], batchSize)
 
+	attrs := conn.metrics.subscriptionAttributes(subject)
+
 	go func() {
 		defer close(msgCh)
 
-		for nMsg := range natsCh {
-			msg := natsDecodeMessage[T](conn, nMsg)
+		stopObserving := conn.metrics.observeDepth(attrs, func() int { return len(msgCh) })
+
+		defer stopObserving()
+
+		for jMsg := range jsCh {
+			msg := natsDecodeJetStreamMessage[T](conn, jMsg)
+			msg.metricAttrs = attrs
+			msg.deliveredAt = time.Now()
+			msg.process = startProcessSpan(ctx, conn.tracer, processSpanConfig{
+				system:       "nats",
+				subject:      jMsg.Subject(),
+				subscription: subject,
+				group:        conn.cfg.QueueGroup,
+				messageID:    msg.ID(),
+				deliveries:   msg.Deliveries(),
+				headers:      Headers(jMsg.Headers()),
+				err:          msg.err,
+			}, msg.message)
+
+			conn.metrics.recordDelivery(ctx, attrs, msg.Deliveries(), msg.err)
+
+			if msg.deadLetterExceeded(0) {
+				if err := msg.DeadLetter(msg.err); err != nil {
+					conn.logger.Errorw("failed to dead-letter message", "nats.subject", jMsg.Subject(), "error", err)
+				}
+
+				continue
+			}
 
 			select {
 			case msgCh <- msg:
@@ -44,7 +69,7 @@ func natsSubscriptionAuthRelationshipRequestChan(ctx context.Context, conn *NATS
 			msg := natsDecodeMessage[AuthRelationshipRequest](conn, nMsg)
 
 			req := &NATSAuthRelationshipRequest{
-				NATSMessage: msg.(*NATSMessage[AuthRelationshipRequest]),
+				NATSMessage: msg,
 			}
 
 			select {
@@ -58,26 +83,62 @@ func natsSubscriptionAuthRelationshipRequestChan(ctx context.Context, conn *NATS
 	return msgCh
 }
 
-func natsDecodeMessage[T any](conn *NATSConnection, nMsg *nats.Msg) Message[T] {
+func natsDecodeMessage[T any](conn *NATSConnection, nMsg *nats.Msg) *NATSMessage[T] {
 	msg := &NATSMessage[T]{
 		conn:   conn,
 		source: nMsg,
 	}
 
-	if err := json.Unmarshal(nMsg.Data, &msg.message); err != nil {
+	keys := make([]string, 0, len(nMsg.Header))
+
+	for key := range nMsg.Header {
+		keys = append(keys, key)
+	}
+
+	payload, err := conn.security.open(conn.signedSubject(nMsg.Subject, nMsg.Header), nMsg.Header.Get, keys, nMsg.Data)
+	if err == nil {
+		err = decodePayload(nMsg.Header.Get, keys, payload, &msg.message, schemaFor[T](conn.cfg.schemas))
+	}
+
+	if err != nil {
 		msg.err = err
 	}
 
+	applyHeaderTraceContext(Headers(nMsg.Header), &msg.message)
+
 	return msg
 }
 
-var _ Message[any] = (*NATSMessage[any])(nil)
+// natsDecodeJetStreamMessage decodes a message delivered by a jetstream consumer.
+func natsDecodeJetStreamMessage[T any](conn *NATSConnection, jMsg jetstream.Msg) *NATSMessage[T] {
+	msg := natsDecodeMessage[T](conn, &nats.Msg{
+		Subject: jMsg.Subject(),
+		Reply:   jMsg.Reply(),
+		Header:  jMsg.Headers(),
+		Data:    jMsg.Data(),
+	})
+
+	msg.jsMsg = jMsg
+
+	return msg
+}
+
+var (
+	_ Message[any]        = (*NATSMessage[any])(nil)
+	_ NakWithErrorMessage = (*NATSMessage[any])(nil)
+	_ SequencedMessage    = (*NATSMessage[any])(nil)
+)
 
 // NATSMessage implements Message
 type NATSMessage[T any] struct {
 	conn           *NATSConnection
 	source         *nats.Msg
-	sourceMetadata *nats.MsgMetadata
+	jsMsg          jetstream.Msg
+	pubAck         *jetstream.PubAck
+	sourceMetadata *jetstream.MsgMetadata
+	metricAttrs    []attribute.KeyValue
+	deliveredAt    time.Time
+	process        *processSpan
 	message        T
 	err            error
 }
@@ -87,16 +148,22 @@ func (m *NATSMessage[T]) Connection() Connection {
 	return m.conn
 }
 
-func (m *NATSMessage[T]) metadata() nats.MsgMetadata {
+func (m *NATSMessage[T]) metadata() jetstream.MsgMetadata {
 	if m.sourceMetadata != nil {
 		return *m.sourceMetadata
 	}
 
-	metadata, err := m.source.Metadata()
+	if m.jsMsg == nil {
+		m.conn.logger.Errorw("failed to load metadata for nats message", "nats.subject", m.source.Subject, "error", nats.ErrNotJSMessage)
+
+		return jetstream.MsgMetadata{}
+	}
+
+	metadata, err := m.jsMsg.Metadata()
 	if err != nil {
-		m.conn.logger.Errorw("failed to load metadata for nats message", "nats.subject", m.source.Subject)
+		m.conn.logger.Errorw("failed to load metadata for nats message", "nats.subject", m.source.Subject, "error", err)
 
-		return nats.MsgMetadata{}
+		return jetstream.MsgMetadata{}
 	}
 
 	m.sourceMetadata = metadata
@@ -105,10 +172,52 @@ func (m *NATSMessage[T]) metadata() nats.MsgMetadata {
 }
 
 // ID returns the nats message sequence number for the consumer.
+// Messages which were not delivered by a consumer return the message id instead.
 func (m *NATSMessage[T]) ID() string {
+	if m.jsMsg == nil {
+		return m.source.Header.Get(nats.MsgIdHdr)
+	}
+
 	return strconv.FormatUint(m.metadata().Sequence.Consumer, base10)
 }
 
+// MessageID returns the id the message was published with, used by jetstream to de-duplicate messages.
+// Dead-lettered and replayed messages, which are republished without the jetstream message id, return the original id.
+func (m *NATSMessage[T]) MessageID() string {
+	return signedMessageID(m.source.Header.Get)
+}
+
+// Stream returns the name of the stream the message is stored in.
+func (m *NATSMessage[T]) Stream() string {
+	if m.pubAck != nil {
+		return m.pubAck.Stream
+	}
+
+	if m.jsMsg == nil {
+		return ""
+	}
+
+	return m.metadata().Stream
+}
+
+// Sequence returns the stream sequence of the message.
+func (m *NATSMessage[T]) Sequence() uint64 {
+	if m.pubAck != nil {
+		return m.pubAck.Sequence
+	}
+
+	if m.jsMsg == nil {
+		return 0
+	}
+
+	return m.metadata().Sequence.Stream
+}
+
+// Duplicate reports whether the published message was dropped by the server as a duplicate of a previously published message.
+func (m *NATSMessage[T]) Duplicate() bool {
+	return m.pubAck != nil && m.pubAck.Duplicate
+}
+
 // Topic returns the nats subject.
 func (m *NATSMessage[T]) Topic() string {
 	return m.source.Subject
@@ -119,21 +228,73 @@ func (m *NATSMessage[T]) Message() T {
 	return m.message
 }
 
+// Headers returns the nats message headers.
+func (m *NATSMessage[T]) Headers() Headers {
+	if m.source.Header == nil {
+		m.source.Header = nats.Header{}
+	}
+
+	return Headers(m.source.Header)
+}
+
 // Ack acks the message.
 func (m *NATSMessage[T]) Ack() error {
+	if m.jsMsg != nil {
+		return m.recordAck(ackActionAck, m.jsMsg.Ack())
+	}
+
 	return m.source.Ack()
 }
 
 // Nak calls a Nak with the provided delay.
+// If dead-lettering is enabled and the message has reached the max deliveries, the message is dead-lettered instead,
+// recording the decode error of the message. Use NakWithError to record the error processing the message failed with.
 func (m *NATSMessage[T]) Nak(delay time.Duration) error {
+	return m.NakWithError(delay, m.err)
+}
+
+// NakWithError calls a Nak with the provided delay.
+// If dead-lettering is enabled and the message has reached the max deliveries, the message is dead-lettered instead,
+// recording the provided error.
+func (m *NATSMessage[T]) NakWithError(delay time.Duration, cause error) error {
+	if m.deadLetterExceeded(1) {
+		return m.DeadLetter(cause)
+	}
+
+	if m.jsMsg != nil {
+		return m.recordAck(ackActionNak, m.jsMsg.NakWithDelay(delay))
+	}
+
 	return m.source.NakWithDelay(delay)
 }
 
 // Term terminates the message from being processed again.
 func (m *NATSMessage[T]) Term() error {
+	if m.jsMsg != nil {
+		return m.recordAck(ackActionTerm, m.jsMsg.Term())
+	}
+
 	return m.source.Term()
 }
 
+// InProgress resets the redelivery timer of the message on the server, extending the time to process the message by the consumer AckWait.
+func (m *NATSMessage[T]) InProgress() error {
+	if m.jsMsg != nil {
+		return m.jsMsg.InProgress()
+	}
+
+	return m.source.InProgress()
+}
+
+// recordAck records the ack action for messages delivered to a subscription and ends the process span, returning err.
+func (m *NATSMessage[T]) recordAck(action string, err error) error {
+	if err == nil && m.metricAttrs != nil {
+		m.conn.metrics.recordAck(m.process.context(), m.metricAttrs, action, m.deliveredAt)
+	}
+
+	return m.process.end(action, err)
+}
+
 // Timestamp returns the timestamp of the message.
 func (m *NATSMessage[T]) Timestamp() time.Time {
 	return m.metadata().Timestamp
@@ -153,33 +314,58 @@ func (m *NATSMessage[T]) Error() error {
 	return nil
 }
 
+// Context returns the context of the process span started when the message was delivered to a subscription.
+// The span is ended when the message is acked, naked or terminated.
+// Messages which were not delivered to a subscription return a background context.
+func (m *NATSMessage[T]) Context() context.Context {
+	return m.process.context()
+}
+
 // Source returns the underlying nats message.
+// Messages delivered by a jetstream consumer return the jetstream.Msg, all others return the *nats.Msg.
 func (m *NATSMessage[T]) Source() any {
-	return m.source
-}
+	if m.jsMsg != nil {
+		return m.jsMsg
+	}
 
-func (m *NATSMessage[T]) publish() error {
-	return m.conn.conn.PublishMsg(m.source)
+	return m.source
 }
 
-func (m *NATSMessage[T]) request(ctx context.Context) (Message[AuthRelationshipResponse], error) {
-	if m.source.Reply == "" {
-		m.source.Reply = m.conn.conn.NewRespInbox()
+// publish publishes the message to jetstream and waits for the message to be stored.
+// Messages published with a future delivery time are scheduled instead, see ContextWithDeliverAt.
+// The result is recorded in the publish metrics with the provided attributes.
+func (m *NATSMessage[T]) publish(ctx context.Context, attrs []attribute.KeyValue) error {
+	if deliverAt, ok := scheduledDeliverAt(ctx); ok {
+		return m.schedule(ctx, deliverAt, attrs)
 	}
 
-	nMsg, err := m.conn.conn.RequestMsgWithContext(ctx, m.source)
+	start := time.Now()
+
+	ack, err := m.conn.jetstream.PublishMsg(ctx, m.source)
+
+	m.conn.metrics.recordPublishDuration(ctx, attrs, start, err)
+	m.conn.metrics.recordPublish(ctx, attrs, ack != nil && ack.Duplicate, err)
+
 	if err != nil {
-		// ensure we wrap no responder errors with ErrRequestNoResponders.
-		if errors.Is(err, nats.ErrNoResponders) {
-			return nil, fmt.Errorf("%w: %w", ErrRequestNoResponders, err)
-		}
+		return err
+	}
 
-		return nil, err
+	m.pubAck = ack
+
+	if ack.Duplicate {
+		m.conn.logger.Debugw("duplicate message not stored",
+			"nats.subject", m.source.Subject,
+			"nats.msg_id", m.source.Header.Get(nats.MsgIdHdr),
+			"nats.stream", ack.Stream,
+			"nats.stream_sequence", ack.Sequence,
+		)
 	}
 
-	respMsg := natsDecodeMessage[AuthRelationshipResponse](m.conn, nMsg)
+	return nil
+}
 
-	return respMsg, nil
+func (m *NATSMessage[T]) request(ctx context.Context, attrs []attribute.KeyValue) (Message[AuthRelationshipResponse], error) {
+	return natsRequest[AuthRelationshipResponse](ctx, m, attrs)
 }
 
 var _ Request[AuthRelationshipRequest, AuthRelationshipResponse] = (*NATSAuthRelationshipRequest)(nil)
@@ -209,14 +395,12 @@ func (r *NATSAuthRelationshipRequest) Reply(ctx context.Context, message AuthRel
 		return nil, err
 	}
 
-	// Propagate trace context into the message for the subscriber
-	var mapCarrier propagation.MapCarrier = make(map[string]string)
-
-	otel.GetTextMapPropagator().Inject(ctx, mapCarrier)
-
-	message.TraceContext = mapCarrier
+	// Propagate trace context into the message body for subscribers which do not read headers
+	if r.conn.cfg.LegacyTraceContext {
+		message.TraceContext = legacyTraceContext(ctx)
+	}
 
-	respMsg, err := newNATSMessage(r.conn, r.source.Reply, message)
+	respMsg, err := newNATSMessage(ctx, r.conn, r.source.Reply, message)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -0,0 +1,361 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSStreamConfig describes a jetstream stream the connection provisions.
+type NATSStreamConfig struct {
+	// Name is the name of the stream.
+	Name string
+	// Subjects are the subjects, relative to the PublishPrefix and SubscribePrefix, the stream captures.
+	// Defaults to all subjects under the PublishPrefix and SubscribePrefix.
+	Subjects []string
+	// Retention is the retention policy of the stream, one of limits (default), interest or workqueue.
+	Retention string
+	// Replicas is the number of stream replicas, defaults to 1.
+	Replicas int
+	// MaxAge is the max age of messages in the stream, zero retains messages indefinitely.
+	MaxAge time.Duration
+	// Storage is the storage backend of the stream, one of file (default) or memory.
+	Storage string
+}
+
+// NATSConsumerConfig describes a durable pull consumer the connection provisions.
+type NATSConsumerConfig struct {
+	// Stream is the name of the stream the consumer is created on.
+	Stream string
+	// Topic is the subscription topic, relative to the SubscribePrefix, such as changes.> or events.*.load-balancer.
+	Topic string
+	// Durable is the name of the consumer, defaults to the name subscriptions on Topic use for the QueueGroup.
+	Durable string
+	// AckWait is the duration the server waits for an ack before redelivering a message, defaults to the SubscriberAckWait.
+	AckWait time.Duration
+	// MaxAckPending is the max number of delivered messages which are not yet acked, defaults to the SubscriberMaxAckPending.
+	MaxAckPending int
+	// MaxDeliver is the max number of times a message is delivered, zero is unlimited.
+	MaxDeliver int
+}
+
+// NATSProvisionDrift describes a difference between the provisioned and the existing configuration.
+type NATSProvisionDrift struct {
+	// Kind is the kind of resource which drifted, stream or consumer.
+	Kind string
+	// Name is the name of the stream or consumer.
+	Name string
+	// Field is the configuration field which drifted.
+	Field string
+	// Expected is the configured value.
+	Expected string
+	// Actual is the existing value.
+	Actual string
+	// Updated reports whether the existing resource was updated to the configured value.
+	// Fields which may not be changed once created are only reported.
+	Updated bool
+}
+
+func (c NATSStreamConfig) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name required", ErrNATSInvalidStreamConfig)
+	}
+
+	switch c.Retention {
+	case "", "limits", "interest", "workqueue":
+	default:
+		return fmt.Errorf("%w: %s: invalid retention %q, expected limits|interest|workqueue", ErrNATSInvalidStreamConfig, c.Name, c.Retention)
+	}
+
+	switch c.Storage {
+	case "", "file", "memory":
+	default:
+		return fmt.Errorf("%w: %s: invalid storage %q, expected file|memory", ErrNATSInvalidStreamConfig, c.Name, c.Storage)
+	}
+
+	return nil
+}
+
+func (c NATSConsumerConfig) validate(queueGroup string) error {
+	if c.Stream == "" || c.Topic == "" {
+		return fmt.Errorf("%w: stream and topic required", ErrNATSInvalidConsumerConfig)
+	}
+
+	if c.Durable == "" && queueGroup == "" {
+		return fmt.Errorf("%w: %s: durable or queue group required", ErrNATSInvalidConsumerConfig, c.Topic)
+	}
+
+	return nil
+}
+
+// streamConfig builds the jetstream configuration for the provided stream.
+func (c *NATSConnection) streamConfig(stream NATSStreamConfig) jetstream.StreamConfig {
+	cfg := jetstream.StreamConfig{
+		Name:      stream.Name,
+		Retention: jetstream.LimitsPolicy,
+		Storage:   jetstream.FileStorage,
+		Replicas:  stream.Replicas,
+		MaxAge:    stream.MaxAge,
+	}
+
+	subjects := stream.Subjects
+	if len(subjects) == 0 {
+		subjects = []string{subjectFullWildcard}
+	}
+
+	// subscriptions bind to consumers filtered under the SubscribePrefix, so when it differs from
+	// the PublishPrefix the stream captures both.
+	for _, subject := range subjects {
+		for _, full := range []string{c.buildPublishSubject(subject), c.buildSubscribeSubject(subject)} {
+			if !slices.Contains(cfg.Subjects, full) {
+				cfg.Subjects = append(cfg.Subjects, full)
+			}
+		}
+	}
+
+	switch stream.Retention {
+	case "interest":
+		cfg.Retention = jetstream.InterestPolicy
+	case "workqueue":
+		cfg.Retention = jetstream.WorkQueuePolicy
+	}
+
+	if stream.Storage == "memory" {
+		cfg.Storage = jetstream.MemoryStorage
+	}
+
+	if cfg.Replicas == 0 {
+		cfg.Replicas = 1
+	}
+
+	return cfg
+}
+
+// consumerConfig builds the jetstream configuration for a consumer on the provided subject.
+// Subscriptions and provisioned consumers share the configuration so subscriptions bind to provisioned consumers without drift.
+func (c *NATSConnection) consumerConfig(subject string, consumer NATSConsumerConfig) jetstream.ConsumerConfig {
+	cfg := jetstream.ConsumerConfig{
+		Durable:       consumer.Durable,
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       consumer.AckWait,
+		MaxAckPending: consumer.MaxAckPending,
+		MaxDeliver:    consumer.MaxDeliver,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+	}
+
+	if cfg.Durable == "" {
+		cfg.Durable = c.durableName(subject)
+	}
+
+	if cfg.AckWait == 0 {
+		cfg.AckWait = c.cfg.SubscriberAckWait
+	}
+
+	if cfg.MaxAckPending == 0 {
+		cfg.MaxAckPending = c.cfg.SubscriberMaxAckPending
+	}
+
+	if c.cfg.SubscriberNoAckExplicit {
+		cfg.AckPolicy = jetstream.AckNonePolicy
+	}
+
+	switch c.cfg.SubscriberDeliveryPolicy {
+	case "last":
+		cfg.DeliverPolicy = jetstream.DeliverLastPolicy
+	case "last-per-subject":
+		cfg.DeliverPolicy = jetstream.DeliverLastPerSubjectPolicy
+	case "new":
+		cfg.DeliverPolicy = jetstream.DeliverNewPolicy
+	case "start-sequence":
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = c.cfg.SubscriberStartSequence
+	case "start-time":
+		startTime := c.cfg.SubscriberStartTime
+
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &startTime
+	}
+
+	for _, opt := range c.cfg.consumerOptions {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
+// Provision creates or updates the streams and consumers described by the configuration.
+// Provisioning is idempotent, existing resources matching the configuration are left untouched.
+// Any differences found on existing resources are returned, updating them where the field may be changed.
+func (c *NATSConnection) Provision(ctx context.Context) ([]NATSProvisionDrift, error) {
+	var drifts []NATSProvisionDrift
+
+	for _, stream := range c.cfg.Streams {
+		drift, err := c.provisionStream(ctx, c.streamConfig(stream))
+		if err != nil {
+			return drifts, fmt.Errorf("%w: stream %s: %w", ErrNATSProvisionFailed, stream.Name, err)
+		}
+
+		drifts = append(drifts, drift...)
+	}
+
+	for _, consumer := range c.cfg.Consumers {
+		cfg := c.consumerConfig(c.buildSubscribeSubject(consumer.Topic), consumer)
+
+		_, drift, err := c.provisionConsumer(ctx, consumer.Stream, cfg)
+		if err != nil {
+			return drifts, fmt.Errorf("%w: consumer %s: %w", ErrNATSProvisionFailed, cfg.Durable, err)
+		}
+
+		drifts = append(drifts, drift...)
+	}
+
+	return drifts, nil
+}
+
+func (c *NATSConnection) provisionStream(ctx context.Context, cfg jetstream.StreamConfig) ([]NATSProvisionDrift, error) {
+	stream, err := c.jetstream.Stream(ctx, cfg.Name)
+	if err != nil {
+		if !errors.Is(err, jetstream.ErrStreamNotFound) {
+			return nil, err
+		}
+
+		c.logger.Infow("creating nats stream", "nats.stream", cfg.Name, "nats.subjects", cfg.Subjects)
+
+		_, err = c.jetstream.CreateStream(ctx, cfg)
+
+		return nil, err
+	}
+
+	existing := stream.CachedInfo().Config
+
+	drifts := newProvisionDrifts("stream", cfg.Name)
+
+	// Retention and storage may not be changed once a stream is created.
+	drifts.check("Retention", cfg.Retention.String(), existing.Retention.String(), false)
+	drifts.check("Storage", cfg.Storage.String(), existing.Storage.String(), false)
+
+	if drifts.check("Subjects", fmt.Sprint(sortedCopy(cfg.Subjects)), fmt.Sprint(sortedCopy(existing.Subjects)), true) {
+		existing.Subjects = cfg.Subjects
+	}
+
+	if drifts.check("Replicas", fmt.Sprint(cfg.Replicas), fmt.Sprint(existing.Replicas), true) {
+		existing.Replicas = cfg.Replicas
+	}
+
+	if drifts.check("MaxAge", cfg.MaxAge.String(), existing.MaxAge.String(), true) {
+		existing.MaxAge = cfg.MaxAge
+	}
+
+	if drifts.updated() {
+		c.logger.Infow("updating nats stream", "nats.stream", cfg.Name)
+
+		if _, err := c.jetstream.UpdateStream(ctx, existing); err != nil {
+			return drifts.list, err
+		}
+	}
+
+	return drifts.list, nil
+}
+
+// provisionConsumer creates the durable consumer or updates the mutable fields which drifted from the configuration,
+// returning the consumer and any drifts found.
+func (c *NATSConnection) provisionConsumer(ctx context.Context, stream string, cfg jetstream.ConsumerConfig) (jetstream.Consumer, []NATSProvisionDrift, error) {
+	consumer, err := c.jetstream.Consumer(ctx, stream, cfg.Durable)
+	if err != nil {
+		if !errors.Is(err, jetstream.ErrConsumerNotFound) {
+			return nil, nil, err
+		}
+
+		c.logger.Infow("creating nats consumer", "nats.stream", stream, "nats.consumer", cfg.Durable, "nats.subject", cfg.FilterSubject)
+
+		consumer, err = c.jetstream.CreateConsumer(ctx, stream, cfg)
+
+		return consumer, nil, err
+	}
+
+	existing := consumer.CachedInfo().Config
+
+	drifts := newProvisionDrifts("consumer", cfg.Durable)
+
+	// Ack and deliver policies may not be changed once a consumer is created.
+	drifts.check("AckPolicy", cfg.AckPolicy.String(), existing.AckPolicy.String(), false)
+	drifts.check("DeliverPolicy", cfg.DeliverPolicy.String(), existing.DeliverPolicy.String(), false)
+
+	if drifts.check("FilterSubject", cfg.FilterSubject, existing.FilterSubject, true) {
+		existing.FilterSubject = cfg.FilterSubject
+	}
+
+	// zero values are defaulted by the server.
+	if cfg.AckWait != 0 && drifts.check("AckWait", cfg.AckWait.String(), existing.AckWait.String(), true) {
+		existing.AckWait = cfg.AckWait
+	}
+
+	if cfg.MaxAckPending != 0 && drifts.check("MaxAckPending", fmt.Sprint(cfg.MaxAckPending), fmt.Sprint(existing.MaxAckPending), true) {
+		existing.MaxAckPending = cfg.MaxAckPending
+	}
+
+	if cfg.MaxDeliver != 0 && drifts.check("MaxDeliver", fmt.Sprint(cfg.MaxDeliver), fmt.Sprint(existing.MaxDeliver), true) {
+		existing.MaxDeliver = cfg.MaxDeliver
+	}
+
+	if drifts.updated() {
+		c.logger.Infow("updating nats consumer", "nats.stream", stream, "nats.consumer", cfg.Durable)
+
+		consumer, err = c.jetstream.UpdateConsumer(ctx, stream, existing)
+		if err != nil {
+			return nil, drifts.list, err
+		}
+	}
+
+	return consumer, drifts.list, nil
+}
+
+func sortedCopy(values []string) []string {
+	values = slices.Clone(values)
+
+	slices.Sort(values)
+
+	return values
+}
+
+type provisionDrifts struct {
+	kind string
+	name string
+	list []NATSProvisionDrift
+}
+
+func newProvisionDrifts(kind, name string) *provisionDrifts {
+	return &provisionDrifts{
+		kind: kind,
+		name: name,
+	}
+}
+
+// check records a drift if expected and actual differ, returning true if the field should be updated.
+func (d *provisionDrifts) check(field, expected, actual string, update bool) bool {
+	if expected == actual {
+		return false
+	}
+
+	d.list = append(d.list, NATSProvisionDrift{
+		Kind:     d.kind,
+		Name:     d.name,
+		Field:    field,
+		Expected: expected,
+		Actual:   actual,
+		Updated:  update,
+	})
+
+	return update
+}
+
+func (d *provisionDrifts) updated() bool {
+	return slices.ContainsFunc(d.list, func(drift NATSProvisionDrift) bool {
+		return drift.Updated
+	})
+}
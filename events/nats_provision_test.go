@@ -0,0 +1,213 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestNATSProvision(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	prefix := "com.infratographer.provision"
+
+	natsCfg := nats.Config.NATS
+	natsCfg.PublishPrefix = prefix
+	natsCfg.SubscribePrefix = prefix
+	natsCfg.QueueGroup = "testing-provision"
+	natsCfg.Streams = []events.NATSStreamConfig{
+		{
+			Name:   "provision-tests",
+			MaxAge: time.Hour,
+		},
+	}
+	natsCfg.Consumers = []events.NATSConsumerConfig{
+		{
+			Stream:  "provision-tests",
+			Topic:   "changes.>",
+			AckWait: time.Second * 10,
+		},
+	}
+
+	conn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	stream, err := nats.JetStream.StreamInfo("provision-tests")
+	require.NoError(t, err)
+	assert.Equal(t, []string{prefix + ".>"}, stream.Config.Subjects)
+	assert.Equal(t, time.Hour, stream.Config.MaxAge)
+
+	durable := events.NATSConsumerDurableName(natsCfg.QueueGroup, prefix+".changes.>")
+
+	consumer, err := nats.JetStream.ConsumerInfo("provision-tests", durable)
+	require.NoError(t, err)
+	assert.Equal(t, prefix+".changes.>", consumer.Config.FilterSubject)
+	assert.Equal(t, time.Second*10, consumer.Config.AckWait)
+
+	drifts, err := conn.Provision(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, drifts)
+
+	// subscriptions bind to the provisioned consumer.
+	_, err = conn.PublishChange(ctx, "test", testCreateChange())
+	require.NoError(t, err)
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, receivedMsg.Ack())
+
+	natsCfg.Streams[0].MaxAge = time.Hour * 2
+	natsCfg.Streams[0].Storage = "memory"
+	natsCfg.Consumers[0].AckWait = time.Second * 20
+	natsCfg.Consumers[0].MaxAckPending = 50
+
+	driftConn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer driftConn.Shutdown(ctx) //nolint:errcheck // within test
+
+	drifts, err = driftConn.Provision(ctx)
+	require.NoError(t, err)
+
+	// the first connect already updated the mutable fields, only the storage drift remains.
+	assert.Equal(t, []events.NATSProvisionDrift{
+		{
+			Kind:     "stream",
+			Name:     "provision-tests",
+			Field:    "Storage",
+			Expected: "Memory",
+			Actual:   "File",
+		},
+	}, drifts)
+
+	stream, err = nats.JetStream.StreamInfo("provision-tests")
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour*2, stream.Config.MaxAge)
+
+	consumer, err = nats.JetStream.ConsumerInfo("provision-tests", durable)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second*20, consumer.Config.AckWait)
+	assert.Equal(t, 50, consumer.Config.MaxAckPending)
+
+	natsCfg.Streams = []events.NATSStreamConfig{{Name: "invalid", Retention: "forever"}}
+
+	_, err = events.NewNATSConnection(natsCfg)
+	require.ErrorIs(t, err, events.ErrNATSInvalidStreamConfig)
+}
+
+func TestNATSSubscribeConsumerDrift(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	natsCfg := nats.Config.NATS
+	natsCfg.QueueGroup = "testing-drift"
+	natsCfg.SubscriberAckWait = time.Second * 10
+	natsCfg.SubscriberMaxAckPending = 10
+
+	conn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	_, err = conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	durable := events.NATSConsumerDurableName(natsCfg.QueueGroup, eventtools.Prefix+".changes.>")
+
+	consumer, err := nats.JetStream.ConsumerInfo("events-tests", durable)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second*10, consumer.Config.AckWait)
+	assert.Equal(t, 10, consumer.Config.MaxAckPending)
+
+	// a new deployment with a changed configuration updates the existing durable consumer.
+	natsCfg.SubscriberAckWait = time.Second * 20
+	natsCfg.SubscriberMaxAckPending = 50
+
+	driftConn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer driftConn.Shutdown(ctx) //nolint:errcheck // within test
+
+	_, err = driftConn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	consumer, err = nats.JetStream.ConsumerInfo("events-tests", durable)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second*20, consumer.Config.AckWait)
+	assert.Equal(t, 50, consumer.Config.MaxAckPending)
+}
+
+func TestNATSProvisionSubscribePrefix(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	natsCfg := nats.Config.NATS
+	natsCfg.PublishPrefix = "com.infratographer.provision.publish"
+	natsCfg.SubscribePrefix = "com.infratographer.provision.subscribe"
+	natsCfg.QueueGroup = "testing-provision-prefix"
+	natsCfg.Streams = []events.NATSStreamConfig{{Name: "provision-prefix-tests"}}
+	natsCfg.Consumers = []events.NATSConsumerConfig{
+		{
+			Stream: "provision-prefix-tests",
+			Topic:  "changes.>",
+		},
+	}
+
+	conn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	stream, err := nats.JetStream.StreamInfo("provision-prefix-tests")
+	require.NoError(t, err)
+	assert.Equal(t, []string{natsCfg.PublishPrefix + ".>", natsCfg.SubscribePrefix + ".>"}, stream.Config.Subjects)
+
+	drifts, err := conn.Provision(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, drifts)
+
+	// messages under the SubscribePrefix are captured and delivered to the provisioned consumer.
+	publisher := nats.Config.NATS
+	publisher.PublishPrefix = natsCfg.SubscribePrefix
+
+	pubConn, err := events.NewNATSConnection(publisher)
+	require.NoError(t, err)
+
+	defer pubConn.Shutdown(ctx) //nolint:errcheck // within test
+
+	_, err = pubConn.PublishChange(ctx, "test", testCreateChange())
+	require.NoError(t, err)
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, receivedMsg.Ack())
+}
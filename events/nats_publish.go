@@ -3,10 +3,8 @@ package events
 import (
 	"context"
 
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 
 	"go.infratographer.com/x/echojwtx"
@@ -30,17 +28,19 @@ func (c *NATSConnection) PublishAuthRelationshipRequest(ctx context.Context, top
 		return nil, err
 	}
 
-	// Propagate trace context into the message for the subscriber
-	var mapCarrier propagation.MapCarrier = make(map[string]string)
-
-	otel.GetTextMapPropagator().Inject(ctx, mapCarrier)
+	// Propagate trace context into the message body for subscribers which do not read headers
+	if c.cfg.LegacyTraceContext {
+		message.TraceContext = legacyTraceContext(ctx)
+	}
 
-	message.TraceContext = mapCarrier
+	attrs := c.metrics.publishAttributes("auth", string(message.Action), topic)
 
 	topic = c.buildPublishSubject("auth", "relationships", string(message.Action), topic)
 
-	reqMsg, err := newNATSMessage(c, topic, message)
+	reqMsg, err := newNATSMessage(ctx, c, topic, message)
 	if err != nil {
+		c.metrics.recordPublish(ctx, attrs, false, err)
+
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 
@@ -49,7 +49,7 @@ func (c *NATSConnection) PublishAuthRelationshipRequest(ctx context.Context, top
 
 	c.logger.Debugf("publishing auth relation request message to topic %s", topic)
 
-	respMsg, err := reqMsg.request(ctx)
+	respMsg, err := reqMsg.request(ctx, attrs)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -78,12 +78,12 @@ func (c *NATSConnection) PublishChange(ctx context.Context, topic string, messag
 		return nil, err
 	}
 
-	// Propagate trace context into the message for the subscriber
-	var mapCarrier propagation.MapCarrier = make(map[string]string)
-
-	otel.GetTextMapPropagator().Inject(ctx, mapCarrier)
+	// Propagate trace context into the message body for subscribers which do not read headers
+	if c.cfg.LegacyTraceContext {
+		message.TraceContext = legacyTraceContext(ctx)
+	}
 
-	message.TraceContext = mapCarrier
+	attrs := c.metrics.publishAttributes("changes", message.EventType, topic)
 
 	topic = c.buildPublishSubject("changes", message.EventType, topic)
 
@@ -105,8 +105,10 @@ func (c *NATSConnection) PublishChange(ctx context.Context, topic string, messag
 		),
 	)
 
-	msg, err := newNATSMessage(c, topic, message)
+	msg, err := newNATSMessage(ctx, c, topic, message)
 	if err != nil {
+		c.metrics.recordPublish(ctx, attrs, false, err)
+
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 
@@ -115,7 +117,7 @@ func (c *NATSConnection) PublishChange(ctx context.Context, topic string, messag
 
 	c.logger.Debugf("publishing change message to topic %s", topic)
 
-	if err = msg.publish(); err != nil {
+	if err = msg.publish(ctx, attrs); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 
@@ -143,17 +145,19 @@ func (c *NATSConnection) PublishEvent(ctx context.Context, topic string, message
 		return nil, err
 	}
 
-	// Propagate trace context into the message for the subscriber
-	var mapCarrier propagation.MapCarrier = make(map[string]string)
-
-	otel.GetTextMapPropagator().Inject(ctx, mapCarrier)
+	// Propagate trace context into the message body for subscribers which do not read headers
+	if c.cfg.LegacyTraceContext {
+		message.TraceContext = legacyTraceContext(ctx)
+	}
 
-	message.TraceContext = mapCarrier
+	attrs := c.metrics.publishAttributes("events", message.EventType, topic)
 
 	topic = c.buildPublishSubject("events", message.EventType, topic)
 
-	msg, err := newNATSMessage(c, topic, message)
+	msg, err := newNATSMessage(ctx, c, topic, message)
 	if err != nil {
+		c.metrics.recordPublish(ctx, attrs, false, err)
+
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 
@@ -162,7 +166,49 @@ func (c *NATSConnection) PublishEvent(ctx context.Context, topic string, message
 
 	c.logger.Debugf("publishing event message to topic %s", topic)
 
-	if err = msg.publish(); err != nil {
+	if err = msg.publish(ctx, attrs); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return msg, err
+	}
+
+	return msg, nil
+}
+
+// natsPublish publishes a message of a custom message family.
+func natsPublish[T Validator](ctx context.Context, c *NATSConnection, family, topic string, message T) (Message[T], error) {
+	ctx, span := c.tracer.Start(ctx, "events.nats.Publish", trace.WithAttributes(
+		attribute.String("events.family", family),
+		attribute.String("events.subject_type", topic),
+	))
+
+	defer span.End()
+
+	if err := message.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	attrs := c.metrics.publishAttributes(family, messageEventType(message), topic)
+
+	topic = c.buildPublishSubject(typedSubjectParts(family, topic, message)...)
+
+	msg, err := newNATSMessage(ctx, c, topic, message)
+	if err != nil {
+		c.metrics.recordPublish(ctx, attrs, false, err)
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	c.logger.Debugf("publishing %s message to topic %s", family, topic)
+
+	if err = msg.publish(ctx, attrs); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// natsRequest publishes the message as a core nats request and waits for the response, decoding it as TResp.
+func natsRequest[TResp, TReq any](ctx context.Context, m *NATSMessage[TReq], attrs []attribute.KeyValue) (Message[TResp], error) {
+	if m.source.Reply == "" {
+		m.source.Reply = m.conn.conn.NewRespInbox()
+	}
+
+	start := time.Now()
+
+	nMsg, err := m.conn.conn.RequestMsgWithContext(ctx, m.source)
+
+	m.conn.metrics.recordPublishDuration(ctx, attrs, start, err)
+	m.conn.metrics.recordPublish(ctx, attrs, false, err)
+
+	if err != nil {
+		// ensure we wrap no responder errors with ErrRequestNoResponders.
+		if errors.Is(err, nats.ErrNoResponders) {
+			return nil, fmt.Errorf("%w: %w", ErrRequestNoResponders, err)
+		}
+
+		return nil, err
+	}
+
+	respMsg := natsDecodeMessage[TResp](m.conn, nMsg)
+
+	return respMsg, nil
+}
+
+// natsPublishRequest publishes a request of a custom message family and waits for the response.
+func natsPublishRequest[TReq, TResp any](ctx context.Context, c *NATSConnection, family, topic string, message TReq) (Message[TResp], error) {
+	ctx, span := c.tracer.Start(ctx, "events.nats.Request", trace.WithAttributes(
+		attribute.String("events.family", family),
+		attribute.String("events.subject_type", topic),
+	))
+
+	defer span.End()
+
+	attrs := c.metrics.publishAttributes(family, messageEventType(message), topic)
+
+	topic = c.buildPublishSubject(requestSubjectParts(family, topic, message)...)
+
+	reqMsg, err := newNATSMessage(ctx, c, topic, message)
+	if err != nil {
+		c.metrics.recordPublish(ctx, attrs, false, err)
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	c.logger.Debugf("publishing %s request to topic %s", family, topic)
+
+	respMsg, err := natsRequest[TResp](ctx, reqMsg, attrs)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	return respMsg, nil
+}
+
+// natsSubscribeRequests subscribes to requests of a custom message family.
+// Requests are load balanced between the connections sharing the QueueGroup.
+func natsSubscribeRequests[TReq, TResp any](ctx context.Context, c *NATSConnection, family, topic string) (<-chan Request[TReq, TResp], error) {
+	topic = c.buildSubscribeSubject(requestSubjectToken, family, topic)
+
+	natsCh, err := c.coreSubscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debugf("subscribing to %s requests on topic %s", family, topic)
+
+	msgCh := make(chan Request[TReq, TResp], c.cfg.SubscriberFetchBatchSize)
+
+	go func() {
+		defer close(msgCh)
+
+		for nMsg := range natsCh {
+			req := &NATSRequest[TReq, TResp]{
+				NATSMessage: natsDecodeMessage[TReq](c, nMsg),
+			}
+
+			select {
+			case msgCh <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgCh, nil
+}
+
+var _ Request[any, any] = (*NATSRequest[any, any])(nil)
+
+// NATSRequest implements Request for requests of a custom message family.
+type NATSRequest[TReq, TResp any] struct {
+	*NATSMessage[TReq]
+}
+
+// Reply responds to the request with the response message.
+// Responses implementing Validator are validated before the reply is sent.
+func (r *NATSRequest[TReq, TResp]) Reply(ctx context.Context, message TResp) (Message[TResp], error) {
+	ctx, span := r.conn.tracer.Start(ctx, "events.Reply")
+
+	defer span.End()
+
+	if r.source.Reply == "" {
+		span.RecordError(ErrNATSMessageNoReplySubject)
+		span.SetStatus(codes.Error, ErrNATSMessageNoReplySubject.Error())
+
+		return nil, ErrNATSMessageNoReplySubject
+	}
+
+	if err := validateReply(ctx, message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	respMsg, err := newNATSMessage(ctx, r.conn, r.source.Reply, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	if err := r.source.RespondMsg(respMsg.source); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return respMsg, err
+	}
+
+	return respMsg, nil
+}
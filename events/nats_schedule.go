@@ -0,0 +1,310 @@
+package events
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// NATSHeaderScheduleTarget is the header containing the subject a message held by the internal scheduler is delivered to.
+	NATSHeaderScheduleTarget = "Events-Schedule-Target"
+
+	// natsHeaderSchedule and natsHeaderScheduleTarget are the headers used by jetstream message scheduling.
+	natsHeaderSchedule       = "Nats-Schedule"
+	natsHeaderScheduleTarget = "Nats-Schedule-Target"
+
+	// natsScheduleMsgIDPrefix prefixes the message id of held messages so the delivered message is not dropped as a duplicate.
+	natsScheduleMsgIDPrefix = "scheduled-"
+
+	// natsSchedulerQueueGroup is the queue group of the durable consumer shared by all schedulers.
+	natsSchedulerQueueGroup = "scheduler"
+)
+
+// natsScheduleStream is the stream storing the schedule subject.
+type natsScheduleStream struct {
+	name string
+	// native is true when the stream allows jetstream message schedules.
+	native bool
+}
+
+// scheduleFilter returns the subject matching all held scheduled messages.
+func (c *NATSConnection) scheduleFilter() string {
+	return c.buildPublishSubject(c.cfg.ScheduleSubject, subjectFullWildcard)
+}
+
+// scheduleSubject returns the subject the scheduled message with the id is held under.
+// Message ids may contain characters which are not valid in a subject so the id is hashed.
+func (c *NATSConnection) scheduleSubject(id string) string {
+	hash := md5.Sum([]byte(id))
+
+	return c.buildPublishSubject(c.cfg.ScheduleSubject, hex.EncodeToString(hash[:]))
+}
+
+// scheduleStream looks up the stream storing the schedule subject and whether it allows message schedules.
+// The result is cached once the stream is found.
+func (c *NATSConnection) scheduleStream(ctx context.Context) (natsScheduleStream, error) {
+	c.scheduleMu.Lock()
+	defer c.scheduleMu.Unlock()
+
+	if c.schedule != nil {
+		return *c.schedule, nil
+	}
+
+	name, err := c.jetstream.StreamNameBySubject(ctx, c.scheduleFilter())
+	if err != nil {
+		return natsScheduleStream{}, err
+	}
+
+	// the stream config returned by the jetstream client does not include the message schedules setting, so it's read from the raw stream info.
+	resp, err := c.conn.RequestWithContext(ctx, c.jetStreamAPIPrefix()+"STREAM.INFO."+name, nil)
+	if err != nil {
+		return natsScheduleStream{}, err
+	}
+
+	var info struct {
+		Config struct {
+			AllowMsgSchedules bool `json:"allow_msg_schedules"`
+		} `json:"config"`
+	}
+
+	if err := json.Unmarshal(resp.Data, &info); err != nil {
+		return natsScheduleStream{}, err
+	}
+
+	c.schedule = &natsScheduleStream{
+		name:   name,
+		native: info.Config.AllowMsgSchedules,
+	}
+
+	return *c.schedule, nil
+}
+
+// jetStreamAPIPrefix returns the subject prefix of jetstream API requests.
+func (c *NATSConnection) jetStreamAPIPrefix() string {
+	opts := c.jetstream.Options()
+
+	switch {
+	case opts.Domain != "":
+		return "$JS." + opts.Domain + ".API."
+	case opts.APIPrefix != "":
+		return strings.TrimSuffix(opts.APIPrefix, subjectSeparator) + subjectSeparator
+	default:
+		return jetstream.DefaultAPIPrefix
+	}
+}
+
+// schedule holds the message under the schedule subject until deliverAt.
+// Streams which allow message schedules deliver the message with jetstream message scheduling, otherwise the internal scheduler delivers it.
+func (m *NATSMessage[T]) schedule(ctx context.Context, deliverAt time.Time, attrs []attribute.KeyValue) error {
+	stream, err := m.conn.scheduleStream(ctx)
+	if err != nil {
+		m.conn.metrics.recordPublish(ctx, attrs, false, err)
+
+		return err
+	}
+
+	msgID := m.MessageID()
+
+	sMsg := nats.NewMsg(m.conn.scheduleSubject(msgID))
+	sMsg.Data = m.source.Data
+
+	for key, values := range m.source.Header {
+		sMsg.Header[key] = values
+	}
+
+	sMsg.Header.Set(nats.MsgIdHdr, natsScheduleMsgIDPrefix+msgID)
+	sMsg.Header.Set(HeaderDeliverAt, deliverAt.UTC().Format(time.RFC3339Nano))
+
+	if stream.native {
+		sMsg.Header.Set(natsHeaderSchedule, "@at "+deliverAt.UTC().Format(time.RFC3339))
+		sMsg.Header.Set(natsHeaderScheduleTarget, m.source.Subject)
+	} else {
+		sMsg.Header.Set(NATSHeaderScheduleTarget, m.source.Subject)
+	}
+
+	start := time.Now()
+
+	ack, err := m.conn.jetstream.PublishMsg(ctx, sMsg)
+
+	m.conn.metrics.recordPublishDuration(ctx, attrs, start, err)
+	m.conn.metrics.recordPublish(ctx, attrs, ack != nil && ack.Duplicate, err)
+
+	if err != nil {
+		return err
+	}
+
+	m.pubAck = ack
+
+	m.conn.logger.Debugw("message scheduled",
+		"nats.subject", m.source.Subject,
+		"nats.schedule_subject", sMsg.Subject,
+		"nats.msg_id", msgID,
+		"nats.native_schedule", stream.native,
+		"events.deliver_at", deliverAt,
+	)
+
+	return nil
+}
+
+// CancelScheduled cancels the pending scheduled message with the provided message id by removing it from the schedule subject.
+func (c *NATSConnection) CancelScheduled(ctx context.Context, id string) error {
+	stream, err := c.scheduleStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	js, err := c.jetstream.Stream(ctx, stream.name)
+	if err != nil {
+		return err
+	}
+
+	subject := c.scheduleSubject(id)
+
+	if _, err := js.GetLastMsgForSubject(ctx, subject); err != nil {
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			return ErrScheduledMessageNotFound
+		}
+
+		return err
+	}
+
+	return js.Purge(ctx, jetstream.WithPurgeSubject(subject))
+}
+
+// RunScheduler delivers messages held by the internal scheduler once they are due, until the context is canceled.
+// Messages are held in the stream storing the ScheduleSubject, so messages scheduled before a restart are still delivered.
+// Schedulers share a durable consumer, any number may run and each message is delivered by one of them.
+//
+// When the stream allows jetstream message schedules the server delivers scheduled messages and RunScheduler returns immediately.
+func (c *NATSConnection) RunScheduler(ctx context.Context) error {
+	stream, err := c.scheduleStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	if stream.native {
+		c.logger.Infow("scheduled messages are delivered by jetstream message scheduling", "nats.stream", stream.name)
+
+		return nil
+	}
+
+	filter := c.scheduleFilter()
+
+	consumer, err := c.jetstream.CreateOrUpdateConsumer(ctx, stream.name, jetstream.ConsumerConfig{
+		Durable:       NATSConsumerDurableName(natsSchedulerQueueGroup, filter),
+		FilterSubject: filter,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		// messages waiting to be delivered remain pending, so pending messages must not be limited.
+		MaxAckPending: -1,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger := c.logger.With(
+		"nats.stream", stream.name,
+		"nats.subject", filter,
+		"nats.consumer", consumer.CachedInfo().Name,
+	)
+
+	iter, err := consumer.Messages(
+		jetstream.PullMaxMessages(c.cfg.SubscriberFetchBatchSize),
+		jetstream.PullExpiry(c.cfg.SubscriberFetchTimeout),
+		jetstream.PullHeartbeat(c.cfg.SubscriberHeartbeat),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Stopping the iterator releases any pending Next call.
+	stop := context.AfterFunc(ctx, iter.Stop)
+
+	defer stop()
+
+	for {
+		msg, err := iter.Next()
+		if err != nil {
+			if errors.Is(err, jetstream.ErrMsgIteratorClosed) || errors.Is(err, nats.ErrConnectionClosed) {
+				return nil
+			}
+
+			logger.Errorw("error receiving scheduled messages", "error", err)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(c.cfg.SubscriberFetchBackoff):
+			}
+
+			continue
+		}
+
+		if err := c.deliverScheduled(ctx, stream.name, msg); err != nil {
+			logger.Errorw("error delivering scheduled message", "nats.schedule_subject", msg.Subject(), "error", err)
+
+			if err := msg.NakWithDelay(c.cfg.SubscriberFetchBackoff); err != nil {
+				logger.Warnw("error naking scheduled message", "nats.schedule_subject", msg.Subject(), "error", err)
+			}
+		}
+	}
+}
+
+// deliverScheduled publishes a held message to its target subject once due and removes it from the schedule subject.
+// Messages which are not yet due are naked until they are.
+func (c *NATSConnection) deliverScheduled(ctx context.Context, stream string, msg jetstream.Msg) error {
+	header := msg.Headers()
+
+	target := header.Get(NATSHeaderScheduleTarget)
+	if target == "" {
+		c.logger.Warnw("terminating scheduled message without a target", "nats.schedule_subject", msg.Subject())
+
+		return msg.Term()
+	}
+
+	deliverAt, err := time.Parse(time.RFC3339Nano, header.Get(HeaderDeliverAt))
+	if err != nil {
+		c.logger.Warnw("delivering scheduled message with an invalid delivery time", "nats.schedule_subject", msg.Subject(), "error", err)
+	}
+
+	if wait := time.Until(deliverAt); wait > 0 {
+		return msg.NakWithDelay(wait)
+	}
+
+	tMsg := nats.NewMsg(target)
+	tMsg.Data = msg.Data()
+
+	for key, values := range header {
+		tMsg.Header[key] = values
+	}
+
+	tMsg.Header.Del(NATSHeaderScheduleTarget)
+	tMsg.Header.Del(HeaderDeliverAt)
+
+	if msgID := header.Get(nats.MsgIdHdr); msgID != "" {
+		tMsg.Header.Set(nats.MsgIdHdr, strings.TrimPrefix(msgID, natsScheduleMsgIDPrefix))
+	}
+
+	if _, err := c.jetstream.PublishMsg(ctx, tMsg); err != nil {
+		return err
+	}
+
+	js, err := c.jetstream.Stream(ctx, stream)
+	if err != nil {
+		return err
+	}
+
+	if err := js.Purge(ctx, jetstream.WithPurgeSubject(msg.Subject())); err != nil {
+		return err
+	}
+
+	return msg.Ack()
+}
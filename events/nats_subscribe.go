@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
 func (c *NATSConnection) coreSubscribe(ctx context.Context, subject string) (<-chan *nats.Msg, error) {
@@ -53,47 +54,121 @@ func (c *NATSConnection) coreSubscribe(ctx context.Context, subject string) (<-c
 	return msgCh, nil
 }
 
-func (c *NATSConnection) jsSubscribe(ctx context.Context, subject string) (<-chan *nats.Msg, error) {
-	durableName := c.durableName(subject)
+// jsConsumer binds to the existing durable consumer for the subject, creating the consumer if it does not exist.
+// An existing consumer which drifted from the subscriber configuration, such as the SubscriberAckWait or
+// SubscriberMaxAckPending, is updated. Drifted fields which may not be changed once created are logged.
+// If no queue group is configured, an ephemeral consumer is created which is removed by the server once inactive.
+func (c *NATSConnection) jsConsumer(ctx context.Context, subject string) (jetstream.Consumer, error) {
+	stream, err := c.jetstream.StreamNameBySubject(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := c.consumerConfig(subject, c.subscriptionConsumer(subject))
+
+	if cfg.Durable == "" {
+		return c.jetstream.CreateConsumer(ctx, stream, cfg)
+	}
+
+	consumer, drifts, err := c.provisionConsumer(ctx, stream, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, drift := range drifts {
+		if !drift.Updated {
+			c.logger.Warnw("nats consumer drifted from the subscriber configuration and may not be updated",
+				"nats.stream", stream,
+				"nats.consumer", drift.Name,
+				"nats.consumer.field", drift.Field,
+				"expected", drift.Expected,
+				"actual", drift.Actual,
+			)
+		}
+	}
+
+	return consumer, nil
+}
+
+// subscriptionConsumer returns the provisioned consumer the subscription on subject binds to.
+// Subscriptions use the provisioned configuration so they do not revert the provisioned values.
+func (c *NATSConnection) subscriptionConsumer(subject string) NATSConsumerConfig {
+	for _, consumer := range c.cfg.Consumers {
+		if c.buildSubscribeSubject(consumer.Topic) != subject {
+			continue
+		}
+
+		if consumer.Durable == "" || consumer.Durable == c.durableName(subject) {
+			return consumer
+		}
+	}
+
+	return NATSConsumerConfig{}
+}
+
+func (c *NATSConnection) jsSubscribe(ctx context.Context, subject string) (<-chan jetstream.Msg, error) {
+	consumer, err := c.jsConsumer(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
 
 	logger := c.logger.With(
 		"nats.provider", "jetstream",
 		"nats.subject", subject,
-		"nats.durable_name", durableName,
+		"nats.consumer", consumer.CachedInfo().Name,
 	)
 
-	sub, err := c.jetstream.PullSubscribe(subject, durableName, c.cfg.subscribeOptions...)
+	pullOptions := []jetstream.PullMessagesOpt{
+		jetstream.PullMaxMessages(c.cfg.SubscriberFetchBatchSize),
+		jetstream.PullExpiry(c.cfg.SubscriberFetchTimeout),
+		jetstream.PullHeartbeat(c.cfg.SubscriberHeartbeat),
+	}
+
+	iter, err := consumer.Messages(append(pullOptions, c.cfg.pullOptions...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	msgCh := make(chan *nats.Msg, c.cfg.SubscriberFetchBatchSize)
+	attrs := c.metrics.subscriptionAttributes(subject)
+
+	msgCh := make(chan jetstream.Msg, c.cfg.SubscriberFetchBatchSize)
 
 	go func() {
+		defer close(msgCh)
+
+		// Stopping the iterator releases any pending Next call.
+		stop := context.AfterFunc(ctx, iter.Stop)
+
+		defer stop()
+
 		for {
-			if err := c.fetchMessages(ctx, sub, msgCh); err != nil {
-				if errors.Is(err, context.DeadlineExceeded) {
-					continue
+			start := time.Now()
+
+			msg, err := iter.Next()
+			if err != nil {
+				if errors.Is(err, jetstream.ErrMsgIteratorClosed) || errors.Is(err, nats.ErrConnectionClosed) {
+					return
 				}
 
-				logger.Errorw("error fetching messages", "error", err)
+				c.metrics.recordFetch(ctx, attrs, start, err)
+
+				logger.Errorw("error receiving messages", "error", err)
 
 				select {
 				case <-ctx.Done():
+					return
 				case <-time.After(c.cfg.SubscriberFetchBackoff):
 				}
+
+				continue
 			}
 
+			c.metrics.recordFetch(ctx, attrs, start, nil)
+
 			select {
+			case msgCh <- msg:
 			case <-ctx.Done():
-				close(msgCh)
-
-				if err := sub.Unsubscribe(); err != nil {
-					logger.Warnw("error unsubscribing", "error", err)
-				}
-
 				return
-			default:
 			}
 		}
 	}()
@@ -101,27 +176,6 @@ func (c *NATSConnection) jsSubscribe(ctx context.Context, subject string) (<-cha
 	return msgCh, nil
 }
 
-func (c *NATSConnection) fetchMessages(ctx context.Context, sub *nats.Subscription, msgCh chan<- *nats.Msg) error {
-	ctx, cancel := context.WithTimeout(ctx, c.cfg.SubscriberFetchTimeout)
-
-	defer cancel()
-
-	batch, err := sub.FetchBatch(c.cfg.SubscriberFetchBatchSize, nats.Context(ctx))
-	if err != nil {
-		return err
-	}
-
-	for msg := range batch.Messages() {
-		select {
-		case msgCh <- msg:
-		case <-ctx.Done():
-			return ctx.Err()
-		}
-	}
-
-	return batch.Error()
-}
-
 func (c *NATSConnection) nextMessage(ctx context.Context, sub *nats.Subscription, msgCh chan<- *nats.Msg) error {
 	ctx, cancel := context.WithTimeout(ctx, c.cfg.SubscriberFetchTimeout)
 
@@ -157,28 +211,24 @@ func (c *NATSConnection) SubscribeAuthRelationshipRequests(ctx context.Context,
 
 // SubscribeChanges creates a new pull subscription parsing incoming messages as ChangeMessage messages and returning a new Message channel.
 func (c *NATSConnection) SubscribeChanges(ctx context.Context, topic string) (<-chan Message[ChangeMessage], error) {
-	topic = c.buildSubscribeSubject("changes", topic)
-
-	natsCh, err := c.jsSubscribe(ctx, topic)
-	if err != nil {
-		return nil, err
-	}
-
-	c.logger.Debugf("subscribing to changes message on topic %s", topic)
-
-	return natsSubscriptionMessageChan[ChangeMessage](ctx, c, c.cfg.SubscriberFetchBatchSize, natsCh), nil
+	return natsSubscribe[ChangeMessage](ctx, c, "changes", topic)
 }
 
 // SubscribeEvents creates a new pull subscription parsing incoming messages as EventMessage messages and returning a new Message channel.
 func (c *NATSConnection) SubscribeEvents(ctx context.Context, topic string) (<-chan Message[EventMessage], error) {
-	topic = c.buildSubscribeSubject("events", topic)
+	return natsSubscribe[EventMessage](ctx, c, "events", topic)
+}
+
+// natsSubscribe creates a new pull subscription parsing incoming messages of the family as T.
+func natsSubscribe[T any](ctx context.Context, c *NATSConnection, family, topic string) (<-chan Message[T], error) {
+	topic = c.buildSubscribeSubject(family, topic)
 
 	natsCh, err := c.jsSubscribe(ctx, topic)
 	if err != nil {
 		return nil, err
 	}
 
-	c.logger.Debugf("subscribing to events message on topic %s", topic)
+	c.logger.Debugf("subscribing to %s message on topic %s", family, topic)
 
-	return natsSubscriptionMessageChan[EventMessage](ctx, c, c.cfg.SubscriberFetchBatchSize, natsCh), nil
+	return natsSubscriptionMessageChan[T](ctx, c, topic, c.cfg.SubscriberFetchBatchSize, natsCh), nil
 }
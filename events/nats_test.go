@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
 	nc "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -363,3 +365,184 @@ func testChange(eventType string) events.ChangeMessage {
 func testCreateChange() events.ChangeMessage {
 	return testChange("create")
 }
+
+func TestNATSDeadLetter(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	natsCfg := nats.Config.NATS
+	natsCfg.QueueGroup = "testing-dead-letter"
+	natsCfg.DeadLetterMaxDeliveries = 2
+
+	// signatures cover the original subject and message id, so redriven messages still verify.
+	signer := newTestNKeySigner(t)
+	natsCfg.TrustedSigningKeys = []string{signer.PublicKey()}
+
+	conn, err := events.NewNATSConnection(natsCfg, events.WithNATSSigner(signer))
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	change := testCreateChange()
+
+	published, err := conn.PublishChange(ctx, "test", change)
+	require.NoError(t, err)
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receivedMsg.Deliveries())
+	require.NoError(t, receivedMsg.Nak(0))
+
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), receivedMsg.Deliveries())
+	require.NoError(t, receivedMsg.Nak(0))
+
+	_, err = getSingleMessage(messages, time.Second)
+	require.ErrorIs(t, err, errTimeout)
+
+	deadLetters, err := conn.ListDeadLetters(ctx, ">")
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+
+	deadLetter := deadLetters[0]
+
+	assert.Equal(t, eventtools.Prefix+".changes.create.test", deadLetter.Topic)
+	assert.Equal(t, eventtools.Prefix+".deadletter.changes.create.test", deadLetter.Subject)
+	assert.Equal(t, events.NATSConsumerDurableName(natsCfg.QueueGroup, eventtools.Prefix+".changes.>"), deadLetter.Consumer)
+	assert.Equal(t, events.ErrNATSMaxDeliveriesExceeded.Error(), deadLetter.Error)
+	assert.Equal(t, uint64(2), deadLetter.Deliveries)
+
+	// dead-lettered messages verify against their original topic.
+	deadLetterMessages, err := events.Subscribe[events.ChangeMessage](ctx, conn, "deadletter", "changes.>")
+	require.NoError(t, err)
+
+	deadLetterMsg, err := getSingleMessage(deadLetterMessages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, deadLetterMsg.Error())
+	assert.Equal(t, change.SubjectID, deadLetterMsg.Message().SubjectID)
+	require.NoError(t, deadLetterMsg.Ack())
+
+	require.NoError(t, conn.RedriveDeadLetter(ctx, deadLetter))
+
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, receivedMsg.Error())
+	assert.Equal(t, uint64(1), receivedMsg.Deliveries())
+	assert.Equal(t, change.SubjectID, receivedMsg.Message().SubjectID)
+	assert.Equal(t, published.ID(), receivedMsg.(*events.NATSMessage[events.ChangeMessage]).MessageID())
+	require.NoError(t, receivedMsg.Ack())
+
+	deadLetters, err = conn.ListDeadLetters(ctx, ">")
+	require.NoError(t, err)
+	assert.Empty(t, deadLetters)
+
+	// the dead-letter topic header isn't signed, so the message may not be moved to the dead-letter subject of another topic.
+	original := deadLetterMsg.Source().(jetstream.Msg)
+
+	_, err = nats.JetStream.PublishMsg(&nc.Msg{
+		Subject: eventtools.Prefix + ".deadletter.changes.create.other",
+		Header:  nc.Header(events.Headers(original.Headers()).Clone()),
+		Data:    original.Data(),
+	})
+	require.NoError(t, err)
+
+	deadLetterMsg, err = getSingleMessage(deadLetterMessages, time.Second)
+	require.NoError(t, err)
+	require.ErrorIs(t, deadLetterMsg.Error(), events.ErrMessageUnverified)
+	require.NoError(t, deadLetterMsg.Ack())
+}
+
+func TestNATSPublishAcknowledged(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	conn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	change := testCreateChange()
+
+	msg, err := conn.PublishChange(ctx, "test", change)
+	require.NoError(t, err)
+
+	natsMsg, ok := msg.(*events.NATSMessage[events.ChangeMessage])
+	require.True(t, ok)
+
+	assert.Equal(t, "events-tests", natsMsg.Stream())
+	assert.Equal(t, uint64(1), natsMsg.Sequence())
+	assert.False(t, natsMsg.Duplicate())
+	assert.True(t, strings.HasPrefix(natsMsg.MessageID(), events.MessageIDPrefix+"-"))
+
+	// retries with the same message id are de-duplicated.
+	retryCtx := events.ContextWithMessageID(ctx, "retry-id")
+
+	msg, err = conn.PublishChange(retryCtx, "test", change)
+	require.NoError(t, err)
+
+	natsMsg = msg.(*events.NATSMessage[events.ChangeMessage])
+
+	assert.Equal(t, "retry-id", natsMsg.MessageID())
+	assert.Equal(t, uint64(2), natsMsg.Sequence())
+	assert.False(t, natsMsg.Duplicate())
+
+	msg, err = conn.PublishChange(retryCtx, "test", change)
+	require.NoError(t, err)
+
+	natsMsg = msg.(*events.NATSMessage[events.ChangeMessage])
+
+	assert.Equal(t, uint64(2), natsMsg.Sequence())
+	assert.True(t, natsMsg.Duplicate())
+
+	// clearing the message id publishes with a generated id.
+	msg, err = conn.PublishChange(events.ContextWithMessageID(retryCtx, ""), "test", change)
+	require.NoError(t, err)
+
+	natsMsg = msg.(*events.NATSMessage[events.ChangeMessage])
+
+	assert.True(t, strings.HasPrefix(natsMsg.MessageID(), events.MessageIDPrefix+"-"))
+	assert.Equal(t, uint64(3), natsMsg.Sequence())
+	assert.False(t, natsMsg.Duplicate())
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	for _, sequence := range []uint64{1, 2, 3} {
+		receivedMsg, err := getSingleMessage(messages, time.Second)
+		require.NoError(t, err)
+
+		natsMsg = receivedMsg.(*events.NATSMessage[events.ChangeMessage])
+
+		assert.Equal(t, sequence, natsMsg.Sequence())
+		assert.Equal(t, "events-tests", natsMsg.Stream())
+
+		require.NoError(t, receivedMsg.Ack())
+	}
+
+	_, err = getSingleMessage(messages, time.Millisecond*100)
+	require.ErrorIs(t, err, errTimeout)
+
+	// messages which are not stored by a stream fail to publish.
+	noStreamCfg := nats.Config.NATS
+	noStreamCfg.PublishPrefix = "com.infratographer.nostream"
+
+	noStreamConn, err := events.NewNATSConnection(noStreamCfg)
+	require.NoError(t, err)
+
+	defer noStreamConn.Shutdown(ctx) //nolint:errcheck // within test
+
+	_, err = noStreamConn.PublishChange(ctx, "test", change)
+	require.Error(t, err)
+}
@@ -0,0 +1,20 @@
+// Copyright 2023 The Infratographer Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outbox provides a transactional outbox for events.
+//
+// Change messages are written to an outbox table within the same database transaction as
+// the mutation which produced them, a Relay then publishes the stored messages at least once,
+// in best-effort order, using an events.Publisher, ensuring events are only published for committed changes.
+package outbox
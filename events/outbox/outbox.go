@@ -0,0 +1,94 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"go.infratographer.com/x/crdbx"
+	"go.infratographer.com/x/echojwtx"
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/gidx"
+)
+
+// DefaultTable is the default name of the outbox table.
+const DefaultTable = "event_outbox"
+
+// ErrInvalidTable is returned when the outbox table name is not a valid identifier.
+// It is crdbx.ErrInvalidTableName, shared by the tables of each package.
+var ErrInvalidTable = crdbx.ErrInvalidTableName
+
+// Execer executes a statement, this is implemented by *sql.DB, *sql.Tx and ent clients,
+// transactions and mutations generated with the sql/execquery feature.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Schema returns the statement to create the outbox table with the provided name.
+// The statement is compatible with both CockroachDB and PostgreSQL and should be included in the service migrations.
+func Schema(table string) (string, error) {
+	if err := crdbx.ValidateTableName(table); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id BIGSERIAL PRIMARY KEY,
+	topic TEXT NOT NULL,
+	payload JSONB NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	sent_at TIMESTAMPTZ NULL,
+	attempts INT8 NOT NULL DEFAULT 0,
+	last_error TEXT NULL
+);
+CREATE INDEX IF NOT EXISTS %[2]s_pending_idx ON %[1]s (sent_at, id);`, table, indexPrefix(table)), nil
+}
+
+// WriteChange writes the change message to the outbox table using the provided execer.
+// When execer is bound to a transaction, the message is only relayed if the transaction commits.
+//
+// Fields which would otherwise be resolved from the context at publish time, such as the trace context
+// and the actor, are resolved when the message is written as the relay publishes without the original context.
+func WriteChange(ctx context.Context, execer Execer, table, topic string, message events.ChangeMessage) error {
+	if err := crdbx.ValidateTableName(table); err != nil {
+		return err
+	}
+
+	if err := message.Validate(); err != nil {
+		return err
+	}
+
+	// Propagate trace context into the message for the subscriber
+	var mapCarrier propagation.MapCarrier = make(map[string]string)
+
+	otel.GetTextMapPropagator().Inject(ctx, mapCarrier)
+
+	message.TraceContext = mapCarrier
+
+	if message.ActorID == gidx.NullPrefixedID {
+		if id, ok := ctx.Value(echojwtx.ActorCtxKey).(string); ok {
+			message.ActorID = gidx.PrefixedID(id)
+		}
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (topic, payload) VALUES ($1, $2)", table) //nolint:gosec // table is validated
+
+	if _, err := execer.ExecContext(ctx, query, topic, string(payload)); err != nil {
+		return fmt.Errorf("failed to write change to outbox: %w", err)
+	}
+
+	return nil
+}
+
+func indexPrefix(table string) string {
+	return regexp.MustCompile(`\W`).ReplaceAllString(table, "_")
+}
@@ -0,0 +1,129 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/v2/testserver"
+	_ "github.com/lib/pq" // Register the Postgres driver.
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/echojwtx"
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/events/outbox"
+	"go.infratographer.com/x/gidx"
+)
+
+type execRecorder struct {
+	query string
+	args  []any
+}
+
+func (e *execRecorder) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	e.query = query
+	e.args = args
+
+	return nil, nil
+}
+
+func TestWriteChange(t *testing.T) {
+	ctx := context.WithValue(context.Background(), echojwtx.ActorCtxKey, "idntusr-abc123")
+
+	recorder := &execRecorder{}
+
+	change := events.ChangeMessage{
+		SubjectID: gidx.MustNewID("testing"),
+		EventType: string(events.CreateChangeType),
+	}
+
+	err := outbox.WriteChange(ctx, recorder, outbox.DefaultTable, "test", change)
+	require.NoError(t, err)
+
+	assert.Equal(t, "INSERT INTO event_outbox (topic, payload) VALUES ($1, $2)", recorder.query)
+	require.Len(t, recorder.args, 2)
+	assert.Equal(t, "test", recorder.args[0])
+
+	var written events.ChangeMessage
+
+	require.NoError(t, json.Unmarshal([]byte(recorder.args[1].(string)), &written))
+
+	assert.Equal(t, change.SubjectID, written.SubjectID)
+	assert.Equal(t, gidx.PrefixedID("idntusr-abc123"), written.ActorID)
+
+	err = outbox.WriteChange(ctx, recorder, "bad; DROP TABLE", "test", change)
+	require.ErrorIs(t, err, outbox.ErrInvalidTable)
+
+	err = outbox.WriteChange(ctx, recorder, outbox.DefaultTable, "test", events.ChangeMessage{})
+	require.ErrorIs(t, err, events.ErrMissingChangeMessageSubjectID)
+}
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := testserver.NewTestServer()
+	require.NoError(t, err)
+
+	defer server.Stop()
+
+	db, err := sql.Open("postgres", server.PGURL().String())
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	schema, err := outbox.Schema(outbox.DefaultTable)
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, schema)
+	require.NoError(t, err)
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	committed := events.ChangeMessage{SubjectID: gidx.MustNewID("testing"), EventType: string(events.CreateChangeType)}
+	rolledBack := events.ChangeMessage{SubjectID: gidx.MustNewID("testing"), EventType: string(events.CreateChangeType)}
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, outbox.WriteChange(ctx, tx, outbox.DefaultTable, "test", rolledBack))
+	require.NoError(t, tx.Rollback())
+
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, outbox.WriteChange(ctx, tx, outbox.DefaultTable, "test", committed))
+	require.NoError(t, tx.Commit())
+
+	relay, err := outbox.NewRelay(db, conn)
+	require.NoError(t, err)
+
+	sent, err := relay.RelayBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	select {
+	case msg := <-messages:
+		require.NoError(t, msg.Error())
+		assert.Equal(t, committed.SubjectID, msg.Message().SubjectID)
+		assert.True(t, strings.HasPrefix(msg.Headers().Get(events.HeaderMessageID), "outbox-"), "published with the outbox message id")
+		require.NoError(t, msg.Ack())
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for relayed message")
+	}
+
+	sent, err = relay.RelayBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	purged, err := relay.Purge(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
@@ -0,0 +1,254 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/v2/crdb"
+	"go.uber.org/zap"
+
+	"go.infratographer.com/x/crdbx"
+	"go.infratographer.com/x/events"
+)
+
+var (
+	// DefaultBatchSize is the default number of messages relayed per transaction.
+	DefaultBatchSize = 50
+	// DefaultPollInterval is the default delay between polls when the outbox is empty.
+	DefaultPollInterval = time.Second
+	// DefaultRetryBackoff is the default delay before retrying after a failed publish.
+	DefaultRetryBackoff = 5 * time.Second
+)
+
+// RelayOption configures a Relay.
+type RelayOption func(r *Relay)
+
+// WithRelayLogger sets the logger for the relay.
+func WithRelayLogger(logger *zap.SugaredLogger) RelayOption {
+	return func(r *Relay) {
+		r.logger = logger
+	}
+}
+
+// WithRelayTable sets the outbox table the relay reads from.
+func WithRelayTable(table string) RelayOption {
+	return func(r *Relay) {
+		r.table = table
+	}
+}
+
+// WithRelayBatchSize sets the number of messages relayed per transaction.
+func WithRelayBatchSize(size int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = size
+	}
+}
+
+// WithRelayPollInterval sets the delay between polls when the outbox is empty.
+func WithRelayPollInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.pollInterval = interval
+	}
+}
+
+// WithRelayRetryBackoff sets the delay before retrying after a failed publish.
+func WithRelayRetryBackoff(backoff time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.retryBackoff = backoff
+	}
+}
+
+// Relay publishes messages written to the outbox table.
+//
+// Pending messages are locked while they are published so only a single relay publishes at a time.
+// Ordering is best-effort: messages are published in id order, however on CockroachDB ids generated with
+// unique_rowid() are not assigned in commit order, so a message committed after a later id may already have
+// been relayed. Consumers must not rely on the relay order of messages written by concurrent transactions.
+//
+// Messages are published at least once. A failure between publishing and marking a message as sent, including
+// a retry of the relay transaction by crdb.ExecuteTx, results in the batch being published again. Each message
+// is published with the message id "outbox-<id>", so providers which de-duplicate by message id, such as NATS
+// JetStream within the stream duplicate window, drop republished messages.
+type Relay struct {
+	db           *sql.DB
+	publisher    events.Publisher
+	logger       *zap.SugaredLogger
+	table        string
+	batchSize    int
+	pollInterval time.Duration
+	retryBackoff time.Duration
+}
+
+// NewRelay creates a new Relay reading from db, usually created with crdbx.NewDB, and publishing with publisher.
+func NewRelay(db *sql.DB, publisher events.Publisher, options ...RelayOption) (*Relay, error) {
+	r := &Relay{
+		db:           db,
+		publisher:    publisher,
+		logger:       zap.NewNop().Sugar(),
+		table:        DefaultTable,
+		batchSize:    DefaultBatchSize,
+		pollInterval: DefaultPollInterval,
+		retryBackoff: DefaultRetryBackoff,
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	if err := crdbx.ValidateTableName(r.table); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Run relays messages until the context is canceled.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		delay := r.pollInterval
+
+		sent, err := r.RelayBatch(ctx)
+
+		switch {
+		case err != nil:
+			r.logger.Errorw("failed to relay outbox messages", "error", err)
+
+			delay = r.retryBackoff
+		case sent == r.batchSize:
+			// more messages are likely pending
+			delay = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+type outboxRow struct {
+	id      int64
+	topic   string
+	payload []byte
+}
+
+// RelayBatch publishes up to the configured batch size of pending messages in id order, returning the number of messages sent.
+// Publishing stops at the first failure so later messages are not published ahead of the failed message.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	var (
+		sent       int
+		publishErr error
+	)
+
+	err := crdb.ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		sent = 0
+		publishErr = nil
+
+		rows, err := r.pending(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := r.publish(ctx, row); err != nil {
+				query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = $2 WHERE id = $1", r.table) //nolint:gosec // table is validated
+
+				if _, uErr := tx.ExecContext(ctx, query, row.id, err.Error()); uErr != nil {
+					return uErr
+				}
+
+				publishErr = fmt.Errorf("failed to publish outbox message %d: %w", row.id, err)
+
+				return nil
+			}
+
+			query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, sent_at = now(), last_error = NULL WHERE id = $1", r.table) //nolint:gosec // table is validated
+
+			if _, err := tx.ExecContext(ctx, query, row.id); err != nil {
+				return err
+			}
+
+			sent++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return sent, err
+	}
+
+	if publishErr != nil {
+		return sent, publishErr
+	}
+
+	if sent != 0 {
+		r.logger.Debugw("relayed outbox messages", "outbox.sent", sent)
+	}
+
+	return sent, nil
+}
+
+func (r *Relay) pending(ctx context.Context, tx *sql.Tx) ([]outboxRow, error) {
+	query := fmt.Sprintf("SELECT id, topic, payload FROM %s WHERE sent_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE", r.table) //nolint:gosec // table is validated
+
+	rows, err := tx.QueryContext(ctx, query, r.batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var results []outboxRow
+
+	for rows.Next() {
+		var row outboxRow
+
+		if err := rows.Scan(&row.id, &row.topic, &row.payload); err != nil {
+			return nil, err
+		}
+
+		results = append(results, row)
+	}
+
+	return results, rows.Err()
+}
+
+func (r *Relay) publish(ctx context.Context, row outboxRow) error {
+	var message events.ChangeMessage
+
+	if err := json.Unmarshal(row.payload, &message); err != nil {
+		return err
+	}
+
+	ctx = events.ContextWithMessageID(message.GetTraceContext(ctx), outboxMessageID(row.id))
+
+	if _, err := r.publisher.PublishChange(ctx, row.topic, message); err != nil {
+		r.logger.Warnw("failed to publish outbox message", "outbox.id", row.id, "events.topic", row.topic, "error", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// outboxMessageID returns the message id the outbox message is published with, stable across republishes.
+func outboxMessageID(id int64) string {
+	return "outbox-" + strconv.FormatInt(id, 10)
+}
+
+// Purge deletes messages which were sent before the provided time, returning the number of messages deleted.
+func (r *Relay) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE sent_at IS NOT NULL AND sent_at < $1", r.table) //nolint:gosec // table is validated
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
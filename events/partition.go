@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"hash/fnv"
+
+	"go.infratographer.com/x/gidx"
+)
+
+// PartitionKey returns the key a received message is partitioned by.
+// Messages with a subject, such as ChangeMessage and EventMessage, are partitioned by their SubjectID,
+// otherwise by the subject the message was published to.
+func PartitionKey[T any](msg Message[T]) string {
+	if m, ok := any(msg.Message()).(interface{ GetSubject() gidx.PrefixedID }); ok && m.GetSubject() != "" {
+		return m.GetSubject().String()
+	}
+
+	return msg.Topic()
+}
+
+// partitionIndex returns the partition for the key.
+func partitionIndex(key string, partitions int) int {
+	if partitions <= 1 {
+		return 0
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key)) //nolint:errcheck // hash writes never fail
+
+	return int(hash.Sum32() % uint32(partitions)) //nolint:gosec // partitions is positive
+}
+
+// Partition distributes the messages received from msgs across the returned partition channels by key,
+// messages with the same key are always sent to the same partition in the order they were received.
+// Processing each partition with a single goroutine preserves ordering per key while keys are processed concurrently.
+//
+// A nil keyFunc partitions messages with PartitionKey.
+// Partition channels are unbuffered, a partition which is not keeping up blocks the distribution of further messages
+// so no more than the subscription buffer, sized by SubscriberFetchBatchSize, is fetched ahead of the partitions.
+// Ordering only holds for messages delivered in order, a message which is naked is redelivered after the messages following it.
+// The partition channels are closed when msgs is closed or the context is canceled.
+func Partition[T any](ctx context.Context, msgs <-chan Message[T], partitions int, keyFunc func(msg Message[T]) string) []<-chan Message[T] {
+	partitions = max(partitions, 1)
+
+	if keyFunc == nil {
+		keyFunc = PartitionKey[T]
+	}
+
+	chans := make([]chan Message[T], partitions)
+	out := make([]<-chan Message[T], partitions)
+
+	for i := range chans {
+		chans[i] = make(chan Message[T])
+		out[i] = chans[i]
+	}
+
+	go func() {
+		defer func() {
+			for _, ch := range chans {
+				close(ch)
+			}
+		}()
+
+		for {
+			select {
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+
+				select {
+				case chans[partitionIndex(keyFunc(msg), partitions)] <- msg:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,208 @@
+package events_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/gidx"
+)
+
+// publishOrderedChanges publishes count changes for each subject, recording the sequence of the change in AdditionalData.
+func publishOrderedChanges(t *testing.T, conn events.Connection, subjects []gidx.PrefixedID, count int) {
+	t.Helper()
+
+	for i := range count {
+		for _, subject := range subjects {
+			change := testCreateChange()
+			change.SubjectID = subject
+			change.AdditionalData = map[string]any{"seq": i}
+
+			_, err := conn.PublishChange(context.Background(), "test", change)
+			require.NoError(t, err)
+		}
+	}
+}
+
+// orderRecorder records the sequences handled for each subject.
+type orderRecorder struct {
+	mu       sync.Mutex
+	received map[gidx.PrefixedID][]int
+	total    int
+	done     chan struct{}
+}
+
+func newOrderRecorder(total int) *orderRecorder {
+	return &orderRecorder{
+		received: map[gidx.PrefixedID][]int{},
+		total:    total,
+		done:     make(chan struct{}),
+	}
+}
+
+func (r *orderRecorder) record(change events.ChangeMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.received[change.SubjectID] = append(r.received[change.SubjectID], int(change.AdditionalData["seq"].(float64)))
+
+	r.total--
+	if r.total == 0 {
+		close(r.done)
+	}
+}
+
+func (r *orderRecorder) assertOrdered(t *testing.T, subjects []gidx.PrefixedID, count int) {
+	t.Helper()
+
+	select {
+	case <-r.done:
+	case <-time.After(time.Second * 5):
+		require.FailNow(t, "timed out waiting for messages")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expected := make([]int, count)
+	for i := range expected {
+		expected[i] = i
+	}
+
+	for _, subject := range subjects {
+		assert.Equal(t, expected, r.received[subject], "messages for %s handled out of order", subject)
+	}
+}
+
+func testSubjects(n int) []gidx.PrefixedID {
+	subjects := make([]gidx.PrefixedID, n)
+
+	for i := range subjects {
+		subjects[i] = gidx.MustNewID("testing")
+	}
+
+	return subjects
+}
+
+func TestPartition(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	const count = 20
+
+	subjects := testSubjects(8)
+	recorder := newOrderRecorder(count * len(subjects))
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	partitions := events.Partition(ctx, messages, 4, nil)
+	require.Len(t, partitions, 4)
+
+	var (
+		mu          sync.Mutex
+		partitionOf = map[gidx.PrefixedID]int{}
+	)
+
+	for i, partition := range partitions {
+		go func() {
+			for msg := range partition {
+				mu.Lock()
+				if p, ok := partitionOf[msg.Message().SubjectID]; ok {
+					assert.Equal(t, p, i, "subject handled by multiple partitions")
+				}
+
+				partitionOf[msg.Message().SubjectID] = i
+				mu.Unlock()
+
+				// slow handlers would reorder messages if keys were handled concurrently.
+				time.Sleep(time.Millisecond)
+
+				recorder.record(msg.Message())
+
+				assert.NoError(t, msg.Ack())
+			}
+		}()
+	}
+
+	publishOrderedChanges(t, conn, subjects, count)
+
+	recorder.assertOrdered(t, subjects, count)
+
+	cancel()
+
+	for _, partition := range partitions {
+		for range partition { //nolint:revive // drain until closed
+		}
+	}
+}
+
+func TestRouterPartitioning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{
+		Enabled:    true,
+		QueueGroup: "testing-router-partitioning",
+	})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	const count = 10
+
+	subjects := testSubjects(6)
+	recorder := newOrderRecorder(count * len(subjects))
+
+	var keys sync.Map
+
+	router := events.NewRouter(conn,
+		events.WithRouterWorkers(4),
+		events.WithRouterPartitioning(func(msg any) string {
+			key := events.PartitionKey(msg.(events.Message[events.ChangeMessage]))
+
+			keys.Store(key, true)
+
+			return key
+		}),
+	)
+
+	router.HandleChange("*.test", events.AnyEventType, func(_ context.Context, msg events.Message[events.ChangeMessage]) error {
+		time.Sleep(time.Millisecond)
+
+		recorder.record(msg.Message())
+
+		return nil
+	})
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- router.Run(ctx)
+	}()
+
+	publishOrderedChanges(t, conn, subjects, count)
+
+	recorder.assertOrdered(t, subjects, count)
+
+	for _, subject := range subjects {
+		_, ok := keys.Load(subject.String())
+		assert.True(t, ok, "expected messages partitioned by subject id")
+	}
+
+	cancel()
+
+	require.NoError(t, <-errCh)
+}
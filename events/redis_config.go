@@ -0,0 +1,205 @@
+package events
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+var (
+	// RedisDefaultStream is the default key of the stream messages are published to.
+	RedisDefaultStream = "events"
+	// RedisDefaultSubscriberFetchBatchSize is the default max number of messages read by a subscription at once.
+	RedisDefaultSubscriberFetchBatchSize = 20
+	// RedisDefaultSubscriberFetchTimeout is the default duration a subscription blocks waiting for new messages.
+	RedisDefaultSubscriberFetchTimeout = time.Second
+	// RedisDefaultAckWait is the default duration a delivered message may remain unacknowledged before it is redelivered.
+	RedisDefaultAckWait = 30 * time.Second
+)
+
+// RedisConfig defines the Redis Streams provider configuration.
+type RedisConfig struct {
+	// URL is the redis connection url, such as redis://redis:6379/0.
+	URL string
+	// Stream is the key of the stream messages are published to, defaults to events.
+	// Auth relationship requests are published to the stream suffixed with :requests and dead-lettered messages are added
+	// to the stream suffixed with :deadletter.
+	//
+	// Every family and topic shares the stream and subjects are filtered by subscribers, so each consumer group reads,
+	// and acknowledges as skipped, every message published to the stream regardless of its subject. Read traffic grows
+	// with the number of subscriptions times the total publish rate, use a stream per application or domain, with
+	// separate connections, when subscriptions only receive a small share of the messages.
+	Stream          string
+	SubscribePrefix string
+	PublishPrefix   string
+	// QueueGroup is the prefix of the consumer groups subscriptions read with.
+	// Subscriptions sharing a queue group receive each message once, without a queue group each subscription receives every message.
+	QueueGroup string
+	Source     string
+
+	// Encoding is the encoding published messages use, one of json (default), cloudevents-binary or cloudevents-structured.
+	// Received messages are decoded regardless of the encoding they were published with.
+	Encoding string
+	// Codec is the name of the codec published message payloads are marshaled with, defaults to json.
+	// Received messages are decoded with the codec declared in their headers, see Codec.
+	Codec string
+
+	// TrustedSigningKeys are the public nkeys of the signers received messages must be signed by.
+	// When set, messages which are not signed by a trusted key are returned with an ErrMessageUnverified error.
+	TrustedSigningKeys []string
+	// SignatureMaxAge is the max age of the signature of received messages, when set messages signed longer ago are
+	// returned with an ErrMessageUnverified error. Dead-lettered and replayed messages keep their original signature.
+	SignatureMaxAge time.Duration
+	// SignatureClockSkew is the clock skew allowed between signers and subscribers, when set messages signed further
+	// in the future, or older than the SignatureMaxAge plus the skew, are returned with an ErrMessageUnverified error.
+	SignatureClockSkew time.Duration
+	// EncryptedTopics are the topics, relative to the PublishPrefix, which published message payloads are encrypted for.
+	// Encryption keys are configured with WithRedisEncryptionKeys.
+	EncryptedTopics []string
+
+	// SubscriberFetchBatchSize is the max number of messages read by a subscription at once and buffered by the subscription.
+	SubscriberFetchBatchSize int
+	// SubscriberFetchTimeout is the duration a subscription blocks waiting for new messages before checking for redeliveries.
+	SubscriberFetchTimeout time.Duration
+	// AckWait is the duration a delivered message may remain unacknowledged before it is claimed for redelivery.
+	AckWait time.Duration
+	// MaxLen approximately caps the number of messages retained by the stream, zero retains every message.
+	MaxLen int64
+	// DeadLetterMaxDeliveries is the number of deliveries after which a message is dead-lettered, zero disables dead-lettering.
+	// Dead-lettered messages are added to the stream suffixed with :deadletter along with the RedisFieldDeadLetter fields.
+	DeadLetterMaxDeliveries int
+
+	logger         *zap.SugaredLogger
+	client         redis.UniversalClient
+	schemas        *SchemaRegistry
+	signer         MessageSigner
+	encryptionKeys []KeyEncrypter
+}
+
+// Configured checks whether the provider has been configured.
+func (c RedisConfig) Configured() bool {
+	return c.URL != "" || c.client != nil
+}
+
+// Validate ensures the configuration is valid.
+func (c RedisConfig) Validate() error {
+	var err error
+
+	if c.URL != "" {
+		if _, urlErr := redis.ParseURL(c.URL); urlErr != nil {
+			err = multierr.Append(err, urlErr)
+		}
+	}
+
+	if c.MaxLen < 0 {
+		err = multierr.Append(err, ErrRedisInvalidMaxLen)
+	}
+
+	return multierr.Append(err, validateMessageConfig(c.Encoding, c.Codec, c.TrustedSigningKeys))
+}
+
+// WithDefaults sets default values for the field unset.
+func (c RedisConfig) WithDefaults() RedisConfig {
+	if c.logger == nil {
+		c.logger = zap.NewNop().Sugar()
+	}
+
+	if c.schemas == nil {
+		c.schemas = DefaultSchemaRegistry
+	}
+
+	if c.Stream == "" {
+		c.Stream = RedisDefaultStream
+	}
+
+	if c.SubscriberFetchBatchSize == 0 {
+		c.SubscriberFetchBatchSize = RedisDefaultSubscriberFetchBatchSize
+	}
+
+	if c.SubscriberFetchTimeout == 0 {
+		c.SubscriberFetchTimeout = RedisDefaultSubscriberFetchTimeout
+	}
+
+	if c.AckWait == 0 {
+		c.AckWait = RedisDefaultAckWait
+	}
+
+	return c
+}
+
+// RedisOption defines a Redis Streams provider configuration option.
+type RedisOption func(c *RedisConfig) error
+
+// WithRedisLogger sets the logger for the redis connection.
+func WithRedisLogger(logger *zap.SugaredLogger) RedisOption {
+	return func(c *RedisConfig) error {
+		c.logger = logger
+
+		return nil
+	}
+}
+
+// WithRedisClient sets the redis client used by the connection instead of connecting to the URL, such as a cluster client.
+// The client is closed when the connection is shutdown.
+func WithRedisClient(client redis.UniversalClient) RedisOption {
+	return func(c *RedisConfig) error {
+		c.client = client
+
+		return nil
+	}
+}
+
+// WithRedisSchemaRegistry sets the schema registry used to version and validate messages, defaults to DefaultSchemaRegistry.
+func WithRedisSchemaRegistry(registry *SchemaRegistry) RedisOption {
+	return func(c *RedisConfig) error {
+		c.schemas = registry
+
+		return nil
+	}
+}
+
+// WithRedisSigner signs published messages with the signer.
+func WithRedisSigner(signer MessageSigner) RedisOption {
+	return func(c *RedisConfig) error {
+		c.signer = signer
+
+		return nil
+	}
+}
+
+// WithRedisEncryptionKeys sets the keys used to encrypt messages published to the EncryptedTopics and decrypt received messages.
+// Messages are encrypted with the first key, all keys are used to decrypt messages so keys may be rotated.
+func WithRedisEncryptionKeys(keys ...KeyEncrypter) RedisOption {
+	return func(c *RedisConfig) error {
+		c.encryptionKeys = append(c.encryptionKeys, keys...)
+
+		return nil
+	}
+}
+
+// MustViperFlagsForRedis returns the cobra flags and viper config for the Redis Streams provider.
+func MustViperFlagsForRedis(v *viper.Viper, _ *pflag.FlagSet, appName string) {
+	v.MustBindEnv("events.redis.url")
+	v.MustBindEnv("events.redis.stream")
+	v.MustBindEnv("events.redis.subscribePrefix")
+	v.MustBindEnv("events.redis.publishPrefix")
+	v.MustBindEnv("events.redis.queueGroup")
+	v.MustBindEnv("events.redis.source")
+	v.MustBindEnv("events.redis.encoding")
+	v.MustBindEnv("events.redis.codec")
+	v.MustBindEnv("events.redis.trustedSigningKeys")
+	v.MustBindEnv("events.redis.signatureMaxAge")
+	v.MustBindEnv("events.redis.signatureClockSkew")
+	v.MustBindEnv("events.redis.encryptedTopics")
+	v.MustBindEnv("events.redis.subscriberFetchBatchSize")
+	v.MustBindEnv("events.redis.subscriberFetchTimeout")
+	v.MustBindEnv("events.redis.ackWait")
+	v.MustBindEnv("events.redis.maxLen")
+	v.MustBindEnv("events.redis.deadLetterMaxDeliveries")
+
+	v.SetDefault("events.redis.source", appName)
+}
@@ -0,0 +1,253 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nuid"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	redisTracerName = tracerName + ":redis"
+
+	redisRequestsSuffix = ":requests"
+	redisReplySuffix    = ":reply:"
+	// redisRequestFiltersSuffix is the suffix of the hash mapping request consumer groups to the subject they subscribe to.
+	redisRequestFiltersSuffix = ":requests:filters"
+
+	// redisRequestsMaxLen caps the requests stream, requests are only read when published.
+	redisRequestsMaxLen = 1000
+
+	// redisGroupStartAll creates a consumer group delivering every message in the stream.
+	redisGroupStartAll = "0"
+	// redisGroupStartNew creates a consumer group delivering messages published after the group is created.
+	redisGroupStartNew = "$"
+)
+
+var _ Connection = (*RedisConnection)(nil)
+
+// RedisConnection implements Connection using Redis Streams.
+// Messages are published to a single stream with the message subject stored alongside the payload.
+// Subscriptions read the stream with a consumer group per queue group and subject, receiving the messages matching the subject.
+type RedisConnection struct {
+	logger   *zap.SugaredLogger
+	tracer   trace.Tracer
+	client   redis.UniversalClient
+	cfg      RedisConfig
+	security *messageSecurity
+	consumer string
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	wg        sync.WaitGroup
+}
+
+// Shutdown closes all subscriptions created by the connection, waits for them to complete and closes the redis client.
+func (c *RedisConnection) Shutdown(ctx context.Context) error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		c.wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if err := c.client.Close(); err != nil && !errors.Is(err, redis.ErrClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Source returns the underlying redis.UniversalClient.
+func (c *RedisConnection) Source() any {
+	return c.client
+}
+
+// runTyped runs the typed message operation with the connection.
+func (c *RedisConnection) runTyped(ctx context.Context, op typedOperation) (any, error) {
+	return op.redis(ctx, c)
+}
+
+// HealthCheck pings the redis server, returning ErrRedisConnectionClosed if the connection has been shutdown.
+func (c *RedisConnection) HealthCheck(ctx context.Context) error {
+	if c.isClosed() {
+		return ErrRedisConnectionClosed
+	}
+
+	return c.client.Ping(ctx).Err()
+}
+
+func (c *RedisConnection) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *RedisConnection) durableName(subject string) string {
+	return NATSConsumerDurableName(c.cfg.QueueGroup, subject)
+}
+
+func (c *RedisConnection) buildSubscribeSubject(parts ...string) string {
+	return buildSubject(c.cfg.SubscribePrefix, parts...)
+}
+
+func (c *RedisConnection) buildPublishSubject(parts ...string) string {
+	return buildSubject(c.cfg.PublishPrefix, parts...)
+}
+
+func (c *RedisConnection) requestsStream() string {
+	return c.cfg.Stream + redisRequestsSuffix
+}
+
+func (c *RedisConnection) requestFilters() string {
+	return c.cfg.Stream + redisRequestFiltersSuffix
+}
+
+func (c *RedisConnection) newReplyStream() string {
+	return c.cfg.Stream + redisReplySuffix + nuid.Next()
+}
+
+// createGroup creates the consumer group on the stream, creating the stream if it does not exist.
+// Existing groups are left untouched so durable groups resume where they left off.
+func (c *RedisConnection) createGroup(ctx context.Context, stream, group, start string) error {
+	err := c.client.XGroupCreateMkStream(ctx, stream, group, start).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	return nil
+}
+
+func newRedisMessage[T any](ctx context.Context, conn *RedisConnection, subject string, message T) (*RedisMessage[T], error) {
+	codec, err := codecByName(conn.cfg.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := codec.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := schemaFor[T](conn.cfg.schemas)
+
+	if schema != nil {
+		if err := schema.validatePublish(codec, data); err != nil {
+			return nil, err
+		}
+	}
+
+	msgID, err := messageID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, encodingHeaders, err := encodePayload(conn.cfg.Encoding, codec, msgID, conn.cfg.Source, message, data)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := publishHeaders(ctx, conn.cfg.Source, message)
+	headers.Set(HeaderMessageID, msgID)
+
+	if !isJSONCodec(codec.Name()) {
+		headers.Set(HeaderCodec, codec.Name())
+	}
+
+	if schema != nil {
+		headers.Set(HeaderSchemaVersion, schema.version())
+	}
+
+	for key, value := range encodingHeaders {
+		headers.Set(key, value)
+	}
+
+	payload, err = conn.security.seal(subject, headers, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisMessage[T]{
+		conn: conn,
+		source: &RedisMsg{
+			Subject: subject,
+			Header:  headers,
+			Data:    payload,
+		},
+		message: message,
+	}, nil
+}
+
+// NewRedisConnection creates a new Redis Streams connection.
+func NewRedisConnection(config RedisConfig, options ...RedisOption) (*RedisConnection, error) {
+	rc := config.WithDefaults()
+
+	if err := rc.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, opt := range options {
+		if err := opt(&rc); err != nil {
+			return nil, err
+		}
+	}
+
+	if rc.QueueGroup == "" {
+		rc.logger.Warn("Redis QueueGroup is not set. Subscriptions will not be durable.")
+	}
+
+	security, err := newMessageSecurity(rc.signer, rc.TrustedSigningKeys, rc.SignatureMaxAge, rc.SignatureClockSkew, rc.encryptionKeys, rc.PublishPrefix, rc.EncryptedTopics)
+	if err != nil {
+		return nil, err
+	}
+
+	client := rc.client
+
+	if client == nil {
+		opts, err := redis.ParseURL(rc.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		if rc.Source != "" && opts.ClientName == "" {
+			opts.ClientName = rc.Source
+		}
+
+		client = redis.NewClient(opts)
+	}
+
+	consumer := nuid.Next()
+	if rc.Source != "" {
+		consumer = rc.Source + "-" + consumer
+	}
+
+	return &RedisConnection{
+		logger:   rc.logger,
+		tracer:   otel.GetTracerProvider().Tracer(redisTracerName),
+		client:   client,
+		cfg:      rc,
+		security: security,
+		consumer: consumer,
+		closed:   make(chan struct{}),
+	}, nil
+}
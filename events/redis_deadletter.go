@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisDeadLetterSuffix = ":deadletter"
+
+	// RedisFieldDeadLetterError is the dead-letter stream entry field containing the error which caused the message to be dead-lettered.
+	RedisFieldDeadLetterError = "deadletter_error"
+	// RedisFieldDeadLetterStream is the dead-letter stream entry field containing the stream the message was read from.
+	RedisFieldDeadLetterStream = "deadletter_stream"
+	// RedisFieldDeadLetterID is the dead-letter stream entry field containing the original stream entry id of the message.
+	RedisFieldDeadLetterID = "deadletter_id"
+	// RedisFieldDeadLetterGroup is the dead-letter stream entry field containing the consumer group which dead-lettered the message.
+	RedisFieldDeadLetterGroup = "deadletter_group"
+	// RedisFieldDeadLetterDeliveries is the dead-letter stream entry field containing the number of times the message was delivered.
+	RedisFieldDeadLetterDeliveries = "deadletter_deliveries"
+)
+
+// deadLetterStream returns the stream dead-lettered messages are added to.
+func (c *RedisConnection) deadLetterStream() string {
+	return c.cfg.Stream + redisDeadLetterSuffix
+}
+
+// deadLetterExceeded reports whether a message delivered the number of times has exceeded the configured max deliveries.
+func (c *RedisConnection) deadLetterExceeded(deliveries uint64) bool {
+	maxDeliveries := c.cfg.DeadLetterMaxDeliveries
+
+	return maxDeliveries > 0 && deliveries > uint64(maxDeliveries)
+}
+
+// deadLetter adds the delivered message to the dead-letter stream recording the provided error and acknowledges it.
+// The entry is added before the message is acknowledged, a failed acknowledgement may dead-letter the message again.
+func (c *RedisConnection) deadLetter(ctx context.Context, d *redisDelivery, cause error) error {
+	if cause == nil {
+		cause = ErrRedisMaxDeliveriesExceeded
+	}
+
+	values, err := d.msg.values()
+	if err != nil {
+		return err
+	}
+
+	values = append(values,
+		RedisFieldDeadLetterError, cause.Error(),
+		RedisFieldDeadLetterStream, d.stream,
+		RedisFieldDeadLetterID, d.id,
+		RedisFieldDeadLetterGroup, d.group,
+		RedisFieldDeadLetterDeliveries, d.deliveries,
+	)
+
+	if err := c.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: c.deadLetterStream(),
+		Values: values,
+	}).Err(); err != nil {
+		return err
+	}
+
+	if err := c.client.XAck(ctx, d.stream, d.group, d.id).Err(); err != nil {
+		return err
+	}
+
+	c.logger.Warnw("message dead-lettered",
+		"redis.stream", d.stream,
+		"redis.id", d.id,
+		"redis.group", d.group,
+		"redis.subject", d.msg.Subject,
+		"redis.dead_letter_stream", c.deadLetterStream(),
+		"redis.deliveries", d.deliveries,
+		"error", cause,
+	)
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package events
+
+import "errors"
+
+var (
+	// ErrRedisConnectionClosed is returned when the redis connection has been shutdown.
+	ErrRedisConnectionClosed = errors.New("redis connection closed")
+
+	// ErrRedisInvalidMaxLen is returned when a negative stream max length is provided.
+	ErrRedisInvalidMaxLen = errors.New("invalid redis stream max length, must not be negative")
+
+	// ErrRedisInvalidEntry is returned when a stream entry is not a message published by a redis connection.
+	ErrRedisInvalidEntry = errors.New("invalid redis stream entry")
+
+	// ErrRedisMessageAlreadyAcked is returned when a message is acked, nacked or terminated more than once.
+	ErrRedisMessageAlreadyAcked = errors.New("redis message already acknowledged")
+
+	// ErrRedisMessageNotAckable is returned when acknowledging a message which was not delivered from a stream, such as a request.
+	ErrRedisMessageNotAckable = errors.New("redis message was not delivered from a stream and cannot be acknowledged")
+
+	// ErrRedisMaxDeliveriesExceeded is recorded on dead-lettered messages which exceeded the configured max deliveries.
+	ErrRedisMaxDeliveriesExceeded = errors.New("message exceeded max deliveries")
+
+	// ErrRedisMessageNoReplySubject is returned when replying to a request which has no reply stream defined.
+	ErrRedisMessageNoReplySubject = errors.New("unable to reply to request, no reply stream specified")
+)
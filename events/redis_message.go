@@ -0,0 +1,423 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	redisFieldSubject = "subject"
+	redisFieldReply   = "reply"
+	redisFieldHeaders = "headers"
+	redisFieldData    = "data"
+)
+
+// RedisMsg is a message stored in a redis stream.
+type RedisMsg struct {
+	// ID is the stream entry id, empty until the message is published.
+	ID        string
+	Subject   string
+	Reply     string
+	Header    Headers
+	Data      []byte
+	Timestamp time.Time
+}
+
+// values returns the stream entry fields for the message.
+func (m *RedisMsg) values() ([]any, error) {
+	headers, err := json.Marshal(m.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []any{
+		redisFieldSubject, m.Subject,
+		redisFieldHeaders, headers,
+		redisFieldData, m.Data,
+	}
+
+	if m.Reply != "" {
+		values = append(values, redisFieldReply, m.Reply)
+	}
+
+	return values, nil
+}
+
+// redisMsgFromEntry parses a stream entry published by a redis connection.
+func redisMsgFromEntry(entry redis.XMessage) (*RedisMsg, error) {
+	subject, ok := entry.Values[redisFieldSubject].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: missing subject", ErrRedisInvalidEntry, entry.ID)
+	}
+
+	data, _ := entry.Values[redisFieldData].(string)
+	reply, _ := entry.Values[redisFieldReply].(string)
+
+	msg := &RedisMsg{
+		ID:        entry.ID,
+		Subject:   subject,
+		Reply:     reply,
+		Header:    Headers{},
+		Data:      []byte(data),
+		Timestamp: redisEntryTime(entry.ID),
+	}
+
+	if headers, ok := entry.Values[redisFieldHeaders].(string); ok && headers != "" {
+		if err := json.Unmarshal([]byte(headers), &msg.Header); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrRedisInvalidEntry, entry.ID, err)
+		}
+	}
+
+	return msg, nil
+}
+
+// redisEntryTime returns the time a stream entry was added from the millisecond timestamp of its id.
+func redisEntryTime(id string) time.Time {
+	ms, _, _ := strings.Cut(id, "-")
+
+	millis, err := strconv.ParseInt(ms, base10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(millis)
+}
+
+func redisDecodeMessage[T any](conn *RedisConnection, rMsg *RedisMsg, delivery *redisDelivery) *RedisMessage[T] {
+	msg := &RedisMessage[T]{
+		conn:     conn,
+		source:   rMsg,
+		delivery: delivery,
+	}
+
+	payload, err := conn.security.open(rMsg.Subject, rMsg.Header.Get, rMsg.Header.Keys(), rMsg.Data)
+	if err == nil {
+		err = decodePayload(rMsg.Header.Get, rMsg.Header.Keys(), payload, &msg.message, schemaFor[T](conn.cfg.schemas))
+	}
+
+	if err != nil {
+		msg.err = err
+	}
+
+	applyHeaderTraceContext(rMsg.Header, &msg.message)
+
+	return msg
+}
+
+var (
+	_ Message[any]        = (*RedisMessage[any])(nil)
+	_ NakWithErrorMessage = (*RedisMessage[any])(nil)
+)
+
+// RedisMessage implements Message
+type RedisMessage[T any] struct {
+	conn     *RedisConnection
+	source   *RedisMsg
+	delivery *redisDelivery
+	process  *processSpan
+	message  T
+	err      error
+}
+
+// Connection returns the underlying Connection.
+func (m *RedisMessage[T]) Connection() Connection {
+	return m.conn
+}
+
+// ID returns the stream entry id of the message.
+func (m *RedisMessage[T]) ID() string {
+	return m.source.ID
+}
+
+// MessageID returns the id the message was published with.
+func (m *RedisMessage[T]) MessageID() string {
+	return m.source.Header.Get(HeaderMessageID)
+}
+
+// Topic returns the subject the message was published to.
+func (m *RedisMessage[T]) Topic() string {
+	return m.source.Subject
+}
+
+// Message returns the decoded message object.
+func (m *RedisMessage[T]) Message() T {
+	return m.message
+}
+
+// Headers returns the message headers.
+func (m *RedisMessage[T]) Headers() Headers {
+	if m.source.Header == nil {
+		m.source.Header = Headers{}
+	}
+
+	return m.source.Header
+}
+
+// Ack acks the message.
+func (m *RedisMessage[T]) Ack() error {
+	if m.delivery == nil {
+		return ErrRedisMessageNotAckable
+	}
+
+	return m.process.end(ackActionAck, m.delivery.ack())
+}
+
+// Nak nacks the message, redelivering it after the provided delay.
+// The delay is capped at the AckWait, after which the message is redelivered regardless of the delay.
+// If dead-lettering is enabled and the message has reached the max deliveries, the message is dead-lettered instead,
+// recording the decode error of the message. Use NakWithError to record the error processing the message failed with.
+func (m *RedisMessage[T]) Nak(delay time.Duration) error {
+	return m.NakWithError(delay, m.err)
+}
+
+// NakWithError nacks the message, redelivering it after the provided delay.
+// If dead-lettering is enabled and the message has reached the max deliveries, the message is dead-lettered instead,
+// recording the provided error.
+func (m *RedisMessage[T]) NakWithError(delay time.Duration, cause error) error {
+	if m.delivery == nil {
+		return ErrRedisMessageNotAckable
+	}
+
+	if m.conn.deadLetterExceeded(m.delivery.deliveries + 1) {
+		return m.DeadLetter(cause)
+	}
+
+	return m.process.end(ackActionNak, m.delivery.nak(delay))
+}
+
+// DeadLetter adds the message to the dead-letter stream recording the provided error and acknowledges the message.
+func (m *RedisMessage[T]) DeadLetter(cause error) error {
+	if m.delivery == nil {
+		return ErrRedisMessageNotAckable
+	}
+
+	return m.process.end(ackActionTerm, m.delivery.deadLetter(cause))
+}
+
+// Term terminates the message from being processed again.
+func (m *RedisMessage[T]) Term() error {
+	if m.delivery == nil {
+		return ErrRedisMessageNotAckable
+	}
+
+	return m.process.end(ackActionTerm, m.delivery.ack())
+}
+
+// InProgress resets the idle time of the pending message, extending the time to process the message by the AckWait.
+func (m *RedisMessage[T]) InProgress() error {
+	if m.delivery == nil {
+		return ErrRedisMessageNotAckable
+	}
+
+	return m.delivery.inProgress()
+}
+
+// Context returns the context of the process span started when the message was delivered to a subscription.
+// The span is ended when the message is acked, naked or terminated.
+// Messages which were not delivered to a subscription return a background context.
+func (m *RedisMessage[T]) Context() context.Context {
+	return m.process.context()
+}
+
+// Timestamp returns the time the message was added to the stream.
+func (m *RedisMessage[T]) Timestamp() time.Time {
+	return m.source.Timestamp
+}
+
+// Deliveries returns the number of times the message was delivered.
+func (m *RedisMessage[T]) Deliveries() uint64 {
+	if m.delivery == nil {
+		return 0
+	}
+
+	return m.delivery.deliveries
+}
+
+// Error returns any error with the message.
+func (m *RedisMessage[T]) Error() error {
+	if m.err != nil {
+		return m.err
+	}
+
+	return nil
+}
+
+// Source returns the underlying *RedisMsg.
+func (m *RedisMessage[T]) Source() any {
+	return m.source
+}
+
+// publish adds the message to the stream, scheduled messages are not supported and return ErrSchedulingUnsupported.
+func (m *RedisMessage[T]) publish(ctx context.Context, stream string, maxLen int64) error {
+	if m.conn.isClosed() {
+		return ErrRedisConnectionClosed
+	}
+
+	if _, ok := scheduledDeliverAt(ctx); ok {
+		return ErrSchedulingUnsupported
+	}
+
+	values, err := m.source.values()
+	if err != nil {
+		return err
+	}
+
+	id, err := m.conn.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: maxLen != 0,
+		Values: values,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	m.source.ID = id
+	m.source.Timestamp = redisEntryTime(id)
+
+	return nil
+}
+
+// request publishes the message to the requests stream and waits for a reply on a reply stream created for the request.
+func (m *RedisMessage[T]) request(ctx context.Context) (Message[AuthRelationshipResponse], error) {
+	return redisRequest[AuthRelationshipResponse](ctx, m)
+}
+
+// isRedisNoSuchKey reports whether the error was returned for a stream which does not exist.
+func isRedisNoSuchKey(err error) bool {
+	return strings.Contains(err.Error(), "no such key")
+}
+
+var _ Request[AuthRelationshipRequest, AuthRelationshipResponse] = (*RedisAuthRelationshipRequest)(nil)
+
+// RedisAuthRelationshipRequest implements Request for AuthRelationshipRequest / AuthRelationshipResponse
+type RedisAuthRelationshipRequest struct {
+	*RedisMessage[AuthRelationshipRequest]
+}
+
+// Reply responds to an AuthRelationshipRequest with an AuthRelationshipResponse.
+// The reply is added to the reply stream of the request, which expires if the requester stopped waiting.
+func (r *RedisAuthRelationshipRequest) Reply(ctx context.Context, message AuthRelationshipResponse) (Message[AuthRelationshipResponse], error) {
+	ctx, span := r.conn.tracer.Start(ctx, "events.Reply")
+
+	defer span.End()
+
+	if r.source.Reply == "" {
+		span.RecordError(ErrRedisMessageNoReplySubject)
+		span.SetStatus(codes.Error, ErrRedisMessageNoReplySubject.Error())
+
+		return nil, ErrRedisMessageNoReplySubject
+	}
+
+	if err := message.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	respMsg, err := newRedisMessage(ctx, r.conn, r.source.Reply, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	if err := respMsg.publish(ctx, r.source.Reply, 0); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	if err := r.conn.client.Expire(ctx, r.source.Reply, defaultTimeout).Err(); err != nil {
+		r.conn.logger.Warnw("failed to expire reply stream", "redis.stream", r.source.Reply, "error", err)
+	}
+
+	return respMsg, nil
+}
+
+// redisDelivery is a single delivery of a stream message to a consumer group subscriber.
+type redisDelivery struct {
+	conn       *RedisConnection
+	stream     string
+	group      string
+	id         string
+	msg        *RedisMsg
+	deliveries uint64
+
+	mu   sync.Mutex
+	done bool
+}
+
+// finish marks the delivery as complete, returning false if it was already completed.
+func (d *redisDelivery) finish() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.done {
+		return false
+	}
+
+	d.done = true
+
+	return true
+}
+
+func (d *redisDelivery) ack() error {
+	if !d.finish() {
+		return ErrRedisMessageAlreadyAcked
+	}
+
+	return d.conn.client.XAck(context.Background(), d.stream, d.group, d.id).Err()
+}
+
+// deadLetter adds the message to the dead-letter stream and acknowledges it.
+func (d *redisDelivery) deadLetter(cause error) error {
+	if !d.finish() {
+		return ErrRedisMessageAlreadyAcked
+	}
+
+	return d.conn.deadLetter(context.Background(), d, cause)
+}
+
+// nak leaves the message pending, setting its idle time so it's claimed for redelivery once the delay has passed.
+func (d *redisDelivery) nak(delay time.Duration) error {
+	if !d.finish() {
+		return ErrRedisMessageAlreadyAcked
+	}
+
+	return d.claim(max(d.conn.cfg.AckWait-delay, 0))
+}
+
+// inProgress resets the idle time of the pending message.
+func (d *redisDelivery) inProgress() error {
+	d.mu.Lock()
+	done := d.done
+	d.mu.Unlock()
+
+	if done {
+		return ErrRedisMessageAlreadyAcked
+	}
+
+	return d.claim(0)
+}
+
+// claim claims the pending message for the connection consumer with the provided idle time, keeping the delivery count.
+func (d *redisDelivery) claim(idle time.Duration) error {
+	return d.conn.client.Do(context.Background(),
+		"XCLAIM", d.stream, d.group, d.conn.consumer, 0, d.id,
+		"IDLE", idle.Milliseconds(),
+		"RETRYCOUNT", d.deliveries,
+		"JUSTID",
+	).Err()
+}
@@ -0,0 +1,214 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redisRequest publishes the message to the requests stream and waits for a reply on a reply stream created for the request, decoding it as TResp.
+func redisRequest[TResp, TReq any](ctx context.Context, m *RedisMessage[TReq]) (Message[TResp], error) {
+	stream := m.conn.requestsStream()
+
+	responders, err := m.conn.hasResponders(ctx, m.source.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if !responders {
+		return nil, ErrRequestNoResponders
+	}
+
+	m.source.Reply = m.conn.newReplyStream()
+
+	defer m.conn.client.Del(context.WithoutCancel(ctx), m.source.Reply)
+
+	if err := m.publish(ctx, stream, redisRequestsMaxLen); err != nil {
+		return nil, err
+	}
+
+	timeout := defaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	if timeout <= 0 {
+		return nil, context.DeadlineExceeded
+	}
+
+	streams, err := m.conn.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{m.source.Reply, redisGroupStartAll},
+		Count:   1,
+		Block:   timeout,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, context.DeadlineExceeded
+		}
+
+		return nil, err
+	}
+
+	rMsg, err := redisMsgFromEntry(streams[0].Messages[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return redisDecodeMessage[TResp](m.conn, rMsg, nil), nil
+}
+
+// hasResponders reports whether a consumer group of the requests stream subscribes to the subject.
+// Durable groups remain once their subscribers stop, so requests to a durable queue group are published
+// while the group exists and time out if no subscriber is running.
+// Groups without a recorded filter, created before filters were recorded, are assumed to subscribe to every subject.
+func (c *RedisConnection) hasResponders(ctx context.Context, subject string) (bool, error) {
+	groups, err := c.client.XInfoGroups(ctx, c.requestsStream()).Result()
+	if err != nil && !isRedisNoSuchKey(err) {
+		return false, err
+	}
+
+	if len(groups) == 0 {
+		return false, nil
+	}
+
+	names := make([]string, len(groups))
+
+	for i, group := range groups {
+		names[i] = group.Name
+	}
+
+	filters, err := c.client.HMGet(ctx, c.requestFilters(), names...).Result()
+	if err != nil {
+		return false, err
+	}
+
+	for _, filter := range filters {
+		filter, ok := filter.(string)
+		if !ok || subjectMatches(filter, subject) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// redisPublishRequest publishes a request of a custom message family and waits for the response.
+func redisPublishRequest[TReq, TResp any](ctx context.Context, c *RedisConnection, family, topic string, message TReq) (Message[TResp], error) {
+	ctx, span := c.tracer.Start(ctx, "events.redis.Request", trace.WithAttributes(
+		attribute.String("events.family", family),
+		attribute.String("events.subject_type", topic),
+	))
+
+	defer span.End()
+
+	topic = c.buildPublishSubject(requestSubjectParts(family, topic, message)...)
+
+	reqMsg, err := newRedisMessage(ctx, c, topic, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	c.logger.Debugf("publishing %s request to topic %s", family, topic)
+
+	respMsg, err := redisRequest[TResp](ctx, reqMsg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	return respMsg, nil
+}
+
+// redisSubscribeRequests subscribes to requests of a custom message family.
+// Requests are load balanced between the connections sharing the QueueGroup.
+func redisSubscribeRequests[TReq, TResp any](ctx context.Context, c *RedisConnection, family, topic string) (<-chan Request[TReq, TResp], error) {
+	topic = c.buildSubscribeSubject(requestSubjectToken, family, topic)
+
+	redisCh, err := c.requestSubscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debugf("subscribing to %s requests on topic %s", family, topic)
+
+	msgCh := make(chan Request[TReq, TResp], c.cfg.SubscriberFetchBatchSize)
+
+	go func() {
+		defer close(msgCh)
+
+		for rMsg := range redisCh {
+			req := &RedisRequest[TReq, TResp]{
+				RedisMessage: redisDecodeMessage[TReq](c, rMsg, nil),
+			}
+
+			select {
+			case msgCh <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgCh, nil
+}
+
+var _ Request[any, any] = (*RedisRequest[any, any])(nil)
+
+// RedisRequest implements Request for requests of a custom message family.
+type RedisRequest[TReq, TResp any] struct {
+	*RedisMessage[TReq]
+}
+
+// Reply responds to the request with the response message.
+// Responses implementing Validator are validated before the reply is sent.
+// The reply is added to the reply stream of the request, which expires if the requester stopped waiting.
+func (r *RedisRequest[TReq, TResp]) Reply(ctx context.Context, message TResp) (Message[TResp], error) {
+	ctx, span := r.conn.tracer.Start(ctx, "events.Reply")
+
+	defer span.End()
+
+	if r.source.Reply == "" {
+		span.RecordError(ErrRedisMessageNoReplySubject)
+		span.SetStatus(codes.Error, ErrRedisMessageNoReplySubject.Error())
+
+		return nil, ErrRedisMessageNoReplySubject
+	}
+
+	if err := validateReply(ctx, message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	respMsg, err := newRedisMessage(ctx, r.conn, r.source.Reply, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	if err := respMsg.publish(ctx, r.source.Reply, 0); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	if err := r.conn.client.Expire(ctx, r.source.Reply, defaultTimeout).Err(); err != nil {
+		r.conn.logger.Warnw("failed to expire reply stream", "redis.stream", r.source.Reply, "error", err)
+	}
+
+	return respMsg, nil
+}
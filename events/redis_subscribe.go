@@ -0,0 +1,405 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisGroup is a consumer group a subscription reads a stream with.
+type redisGroup struct {
+	stream    string
+	name      string
+	filter    string
+	ephemeral bool
+}
+
+// subscriptionGroup creates the consumer group for a subscription to subject on the stream.
+// Without a queue group an ephemeral group is created, which is destroyed when the subscription ends.
+func (c *RedisConnection) subscriptionGroup(ctx context.Context, stream, subject, start string) (*redisGroup, error) {
+	if c.isClosed() {
+		return nil, ErrRedisConnectionClosed
+	}
+
+	group := &redisGroup{
+		stream: stream,
+		name:   c.durableName(subject),
+		filter: subject,
+	}
+
+	if group.name == "" {
+		group.name = "ephemeral-" + nuid.Next()
+		group.ephemeral = true
+	}
+
+	if err := c.createGroup(ctx, stream, group.name, start); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// release destroys ephemeral groups once the subscription has ended.
+func (c *RedisConnection) release(group *redisGroup) {
+	if !group.ephemeral {
+		return
+	}
+
+	if err := c.client.XGroupDestroy(context.Background(), group.stream, group.name).Err(); err != nil {
+		c.logger.Warnw("error destroying redis consumer group", "redis.stream", group.stream, "redis.group", group.name, "error", err)
+	}
+
+	if group.stream != c.requestsStream() {
+		return
+	}
+
+	if err := c.client.HDel(context.Background(), c.requestFilters(), group.name).Err(); err != nil {
+		c.logger.Warnw("error removing redis request filter", "redis.stream", group.stream, "redis.group", group.name, "error", err)
+	}
+}
+
+// readGroup returns the next messages for the group, claiming messages which were not acknowledged within the AckWait
+// before reading new messages. Messages not matching the group filter are acknowledged and skipped.
+// Claimed messages which exceeded the max deliveries are dead-lettered, such as those whose subscriber stopped before
+// acknowledging them.
+func (c *RedisConnection) readGroup(ctx context.Context, group *redisGroup) ([]*redisDelivery, error) {
+	entries, deliveries, err := c.claimGroup(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group.name,
+			Consumer: c.consumer,
+			Streams:  []string{group.stream, ">"},
+			Count:    int64(c.cfg.SubscriberFetchBatchSize),
+			Block:    c.cfg.SubscriberFetchTimeout,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		for _, stream := range streams {
+			entries = append(entries, stream.Messages...)
+		}
+	}
+
+	var (
+		rDeliveries []*redisDelivery
+		skipped     []string
+	)
+
+	for _, entry := range entries {
+		rMsg, err := redisMsgFromEntry(entry)
+		if err != nil {
+			c.logger.Warnw("skipping invalid redis stream entry", "redis.stream", group.stream, "redis.id", entry.ID, "error", err)
+
+			skipped = append(skipped, entry.ID)
+
+			continue
+		}
+
+		if !subjectMatches(group.filter, rMsg.Subject) {
+			skipped = append(skipped, entry.ID)
+
+			continue
+		}
+
+		count := deliveries[entry.ID]
+		if count == 0 {
+			count = 1
+		}
+
+		delivery := &redisDelivery{
+			conn:       c,
+			stream:     group.stream,
+			group:      group.name,
+			id:         entry.ID,
+			msg:        rMsg,
+			deliveries: count,
+		}
+
+		if c.deadLetterExceeded(count) {
+			// messages which fail to be dead-lettered are left pending and claimed again after the AckWait.
+			if err := delivery.deadLetter(nil); err != nil {
+				c.logger.Errorw("failed to dead-letter message", "redis.stream", group.stream, "redis.id", entry.ID, "error", err)
+			}
+
+			continue
+		}
+
+		rDeliveries = append(rDeliveries, delivery)
+	}
+
+	if len(skipped) != 0 {
+		if err := c.client.XAck(ctx, group.stream, group.name, skipped...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return rDeliveries, nil
+}
+
+// claimGroup claims the pending messages of the group idle for longer than the AckWait, returning the claimed entries
+// along with their delivery counts. The counts include the claim, which readGroup checks against the max deliveries.
+func (c *RedisConnection) claimGroup(ctx context.Context, group *redisGroup) ([]redis.XMessage, map[string]uint64, error) {
+	entries, _, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   group.stream,
+		Group:    group.name,
+		Consumer: c.consumer,
+		MinIdle:  c.cfg.AckWait,
+		Start:    "0-0",
+		Count:    int64(c.cfg.SubscriberFetchBatchSize),
+	}).Result()
+	if err != nil || len(entries) == 0 {
+		return nil, nil, err
+	}
+
+	pending, err := c.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   group.stream,
+		Group:    group.name,
+		Start:    entries[0].ID,
+		End:      entries[len(entries)-1].ID,
+		Count:    int64(len(entries)),
+		Consumer: c.consumer,
+	}).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deliveries := make(map[string]uint64, len(pending))
+
+	for _, p := range pending {
+		deliveries[p.ID] = uint64(p.RetryCount) //nolint:gosec // retry count is never negative
+	}
+
+	return entries, deliveries, nil
+}
+
+// subscriptionContext returns a context which is canceled when ctx is done or the connection is shutdown.
+func (c *RedisConnection) subscriptionContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		select {
+		case <-c.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// consume reads the group until the context is done, calling send for each message.
+// send returns false when the subscription has ended.
+func (c *RedisConnection) consume(ctx context.Context, group *redisGroup, send func(delivery *redisDelivery) bool) {
+	logger := c.logger.With(
+		"redis.stream", group.stream,
+		"redis.group", group.name,
+		"redis.subject", group.filter,
+	)
+
+	for {
+		deliveries, err := c.readGroup(ctx, group)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, redis.ErrClosed) {
+				return
+			}
+
+			logger.Errorw("error reading messages", "error", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.cfg.SubscriberFetchTimeout):
+			}
+
+			continue
+		}
+
+		for _, delivery := range deliveries {
+			if !send(delivery) {
+				return
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (c *RedisConnection) streamSubscribe(ctx context.Context, subject string) (<-chan *redisDelivery, error) {
+	group, err := c.subscriptionGroup(ctx, c.cfg.Stream, subject, redisGroupStartAll)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.subscriptionContext(ctx)
+
+	deliveryCh := make(chan *redisDelivery, c.cfg.SubscriberFetchBatchSize)
+
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+		defer cancel()
+		defer close(deliveryCh)
+		defer c.release(group)
+
+		c.consume(ctx, group, func(delivery *redisDelivery) bool {
+			select {
+			case deliveryCh <- delivery:
+				return true
+			case <-ctx.Done():
+				// messages left pending are claimed by another subscriber after the AckWait.
+				return false
+			}
+		})
+	}()
+
+	return deliveryCh, nil
+}
+
+// requestSubscribe subscribes to requests published after the subscription is created.
+// Requests are acknowledged when received, a request which is not replied to is not redelivered.
+func (c *RedisConnection) requestSubscribe(ctx context.Context, subject string) (<-chan *RedisMsg, error) {
+	group, err := c.subscriptionGroup(ctx, c.requestsStream(), subject, redisGroupStartNew)
+	if err != nil {
+		return nil, err
+	}
+
+	// requesters only publish requests matching the filter of a group, see hasResponders.
+	if err := c.client.HSet(ctx, c.requestFilters(), group.name, subject).Err(); err != nil {
+		c.release(group)
+
+		return nil, err
+	}
+
+	ctx, cancel := c.subscriptionContext(ctx)
+
+	msgCh := make(chan *RedisMsg, c.cfg.SubscriberFetchBatchSize)
+
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+		defer cancel()
+		defer close(msgCh)
+		defer c.release(group)
+
+		c.consume(ctx, group, func(delivery *redisDelivery) bool {
+			if err := delivery.ack(); err != nil {
+				c.logger.Warnw("error acknowledging request", "redis.stream", group.stream, "redis.id", delivery.id, "error", err)
+			}
+
+			select {
+			case msgCh <- delivery.msg:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return msgCh, nil
+}
+
+func redisSubscriptionMessageChan[T any](ctx context.Context, conn *RedisConnection, subject string, bufferSize int, deliveryCh <-chan *redisDelivery) chan Message[T] {
+	msgCh := make(chan Message[T], bufferSize)
+
+	go func() {
+		defer close(msgCh)
+
+		for delivery := range deliveryCh {
+			msg := redisDecodeMessage[T](conn, delivery.msg, delivery)
+			msg.process = startProcessSpan(ctx, conn.tracer, processSpanConfig{
+				system:       "redis",
+				subject:      delivery.msg.Subject,
+				subscription: subject,
+				group:        conn.cfg.QueueGroup,
+				messageID:    msg.ID(),
+				deliveries:   msg.Deliveries(),
+				headers:      delivery.msg.Header,
+				err:          msg.err,
+			}, msg.message)
+
+			select {
+			case msgCh <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgCh
+}
+
+func redisSubscriptionAuthRelationshipRequestChan(ctx context.Context, conn *RedisConnection, bufferSize int, redisCh <-chan *RedisMsg) chan Request[AuthRelationshipRequest, AuthRelationshipResponse] {
+	msgCh := make(chan Request[AuthRelationshipRequest, AuthRelationshipResponse], bufferSize)
+
+	go func() {
+		defer close(msgCh)
+
+		for rMsg := range redisCh {
+			req := &RedisAuthRelationshipRequest{
+				RedisMessage: redisDecodeMessage[AuthRelationshipRequest](conn, rMsg, nil),
+			}
+
+			select {
+			case msgCh <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgCh
+}
+
+// SubscribeAuthRelationshipRequests creates a new subscription parsing incoming messages as AuthRelationshipRequest messages and returning a new Message channel.
+func (c *RedisConnection) SubscribeAuthRelationshipRequests(ctx context.Context, topic string) (<-chan Request[AuthRelationshipRequest, AuthRelationshipResponse], error) {
+	topic = c.buildSubscribeSubject("auth", "relationships", topic)
+
+	redisCh, err := c.requestSubscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debugf("subscribing to auth relation request message on topic %s", topic)
+
+	return redisSubscriptionAuthRelationshipRequestChan(ctx, c, c.cfg.SubscriberFetchBatchSize, redisCh), nil
+}
+
+// SubscribeChanges creates a new subscription parsing incoming messages as ChangeMessage messages and returning a new Message channel.
+func (c *RedisConnection) SubscribeChanges(ctx context.Context, topic string) (<-chan Message[ChangeMessage], error) {
+	return redisSubscribe[ChangeMessage](ctx, c, "changes", topic)
+}
+
+// SubscribeEvents creates a new subscription parsing incoming messages as EventMessage messages and returning a new Message channel.
+func (c *RedisConnection) SubscribeEvents(ctx context.Context, topic string) (<-chan Message[EventMessage], error) {
+	return redisSubscribe[EventMessage](ctx, c, "events", topic)
+}
+
+// redisSubscribe creates a new subscription parsing incoming messages of the family as T.
+func redisSubscribe[T any](ctx context.Context, c *RedisConnection, family, topic string) (<-chan Message[T], error) {
+	topic = c.buildSubscribeSubject(family, topic)
+
+	deliveryCh, err := c.streamSubscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debugf("subscribing to %s message on topic %s", family, topic)
+
+	return redisSubscriptionMessageChan[T](ctx, c, topic, c.cfg.SubscriberFetchBatchSize, deliveryCh), nil
+}
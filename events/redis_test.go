@@ -0,0 +1,333 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func newTestRedisConnection(t *testing.T, redis *eventtools.TestRedis, queueGroup string) *events.RedisConnection {
+	t.Helper()
+
+	cfg := redis.Config.Redis
+	cfg.QueueGroup = queueGroup
+	cfg.SubscriberFetchTimeout = time.Millisecond * 50
+	cfg.AckWait = time.Millisecond * 300
+
+	conn, err := events.NewRedisConnection(cfg)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Shutdown(context.Background()) //nolint:errcheck // within test
+	})
+
+	return conn
+}
+
+func TestRedisPublishAndSubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name       string
+		queueGroup string
+	}{
+		{
+			name:       "with ephemeral consumer",
+			queueGroup: "",
+		},
+		{
+			name:       "with durable consumer",
+			queueGroup: "testing-durable",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			redis, err := eventtools.NewRedisServer()
+			require.NoError(t, err)
+
+			defer redis.Close()
+
+			conn := newTestRedisConnection(t, redis, tc.queueGroup)
+
+			change := testCreateChange()
+
+			msg, err := conn.PublishChange(ctx, "test", change)
+			require.NoError(t, err)
+			require.Equal(t, change, msg.Message())
+			assert.Equal(t, eventtools.Prefix+".changes.create.test", msg.Topic())
+
+			_, err = conn.PublishEvent(ctx, "test", events.EventMessage{
+				SubjectID: gidx.MustNewID("testing"),
+				EventType: "ignored",
+			})
+			require.NoError(t, err)
+
+			messages, err := conn.SubscribeChanges(ctx, ">")
+			require.NoError(t, err)
+
+			receivedMsg, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, receivedMsg.Error())
+			assert.EqualValues(t, change, receivedMsg.Message())
+			assert.Equal(t, msg.ID(), receivedMsg.ID())
+			assert.Equal(t, uint64(1), receivedMsg.Deliveries())
+			assert.NoError(t, receivedMsg.Ack())
+			assert.ErrorIs(t, receivedMsg.Ack(), events.ErrRedisMessageAlreadyAcked)
+
+			// the event message does not match the subscription.
+			_, err = getSingleMessage(messages, time.Millisecond*100)
+			require.ErrorIs(t, err, errTimeout)
+		})
+	}
+}
+
+func TestRedisRedelivery(t *testing.T) {
+	ctx := context.Background()
+
+	redis, err := eventtools.NewRedisServer()
+	require.NoError(t, err)
+
+	defer redis.Close()
+
+	conn := newTestRedisConnection(t, redis, "testing-redelivery")
+
+	messages, err := conn.SubscribeChanges(ctx, "create.test")
+	require.NoError(t, err)
+
+	change := testCreateChange()
+
+	_, err = conn.PublishChange(ctx, "test", change)
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receivedMsg.Deliveries())
+	require.NoError(t, receivedMsg.Nak(0))
+
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), receivedMsg.Deliveries())
+	assert.EqualValues(t, change, receivedMsg.Message())
+
+	// signaling progress delays the redelivery.
+	time.Sleep(time.Millisecond * 200)
+	require.NoError(t, receivedMsg.InProgress())
+
+	_, err = getSingleMessage(messages, time.Millisecond*200)
+	require.ErrorIs(t, err, errTimeout)
+
+	// let the ack wait expire
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), receivedMsg.Deliveries())
+	require.NoError(t, receivedMsg.Term())
+
+	_, err = getSingleMessage(messages, time.Millisecond*500)
+	require.ErrorIs(t, err, errTimeout)
+}
+
+func TestRedisDeadLetter(t *testing.T) {
+	ctx := context.Background()
+
+	redis, err := eventtools.NewRedisServer()
+	require.NoError(t, err)
+
+	defer redis.Close()
+
+	cfg := redis.Config.Redis
+	cfg.QueueGroup = "testing-dead-letter"
+	cfg.SubscriberFetchTimeout = time.Millisecond * 50
+	cfg.AckWait = time.Millisecond * 300
+	cfg.DeadLetterMaxDeliveries = 2
+
+	conn, err := events.NewRedisConnection(cfg)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	naked, err := conn.PublishChange(ctx, "test", testCreateChange())
+	require.NoError(t, err)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receivedMsg.Deliveries())
+	require.NoError(t, receivedMsg.Nak(0))
+
+	receivedMsg, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), receivedMsg.Deliveries())
+	require.NoError(t, receivedMsg.Nak(0))
+
+	// messages which are never acknowledged are dead-lettered once claimed beyond the max deliveries.
+	abandoned, err := conn.PublishChange(ctx, "test", testCreateChange())
+	require.NoError(t, err)
+
+	for i := 1; i <= 2; i++ {
+		receivedMsg, err = getSingleMessage(messages, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, abandoned.ID(), receivedMsg.ID())
+		assert.Equal(t, uint64(i), receivedMsg.Deliveries())
+	}
+
+	_, err = getSingleMessage(messages, time.Second)
+	require.ErrorIs(t, err, errTimeout)
+
+	client, ok := conn.Source().(goredis.UniversalClient)
+	require.True(t, ok)
+
+	deadLetters, err := client.XRange(ctx, events.RedisDefaultStream+":deadletter", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 2)
+
+	assert.Equal(t, naked.ID(), deadLetters[0].Values[events.RedisFieldDeadLetterID])
+	assert.Equal(t, events.ErrRedisMaxDeliveriesExceeded.Error(), deadLetters[0].Values[events.RedisFieldDeadLetterError])
+	assert.Equal(t, "2", deadLetters[0].Values[events.RedisFieldDeadLetterDeliveries])
+	assert.Equal(t, eventtools.Prefix+".changes.create.test", deadLetters[0].Values["subject"])
+
+	assert.Equal(t, abandoned.ID(), deadLetters[1].Values[events.RedisFieldDeadLetterID])
+	assert.Equal(t, "3", deadLetters[1].Values[events.RedisFieldDeadLetterDeliveries])
+
+	pending, err := client.XPending(ctx, events.RedisDefaultStream, events.NATSConsumerDurableName(cfg.QueueGroup, eventtools.Prefix+".changes.>")).Result()
+	require.NoError(t, err)
+	assert.Zero(t, pending.Count)
+}
+
+func TestRedisQueueGroup(t *testing.T) {
+	ctx := context.Background()
+
+	redis, err := eventtools.NewRedisServer()
+	require.NoError(t, err)
+
+	defer redis.Close()
+
+	conn1 := newTestRedisConnection(t, redis, "testing-queue")
+	conn2 := newTestRedisConnection(t, redis, "testing-queue")
+
+	messages1, err := conn1.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	messages2, err := conn2.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	const total = 10
+
+	for i := 0; i < total; i++ {
+		_, err := conn1.PublishChange(ctx, "test", testCreateChange())
+		require.NoError(t, err)
+	}
+
+	received := map[string]bool{}
+
+	for len(received) < total {
+		select {
+		case msg := <-messages1:
+			received[msg.ID()] = true
+
+			require.NoError(t, msg.Ack())
+		case msg := <-messages2:
+			received[msg.ID()] = true
+
+			require.NoError(t, msg.Ack())
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for messages, received %d", len(received))
+		}
+	}
+
+	select {
+	case msg := <-messages1:
+		t.Fatalf("unexpected duplicate message %s", msg.ID())
+	case msg := <-messages2:
+		t.Fatalf("unexpected duplicate message %s", msg.ID())
+	case <-time.After(time.Millisecond * 500):
+	}
+}
+
+func TestRedisRequestReply(t *testing.T) {
+	ctx := context.Background()
+
+	redis, err := eventtools.NewRedisServer()
+	require.NoError(t, err)
+
+	defer redis.Close()
+
+	conn, err := events.NewConnection(redis.Config)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	require.IsType(t, &events.RedisConnection{}, conn)
+	require.NoError(t, conn.HealthCheck(ctx))
+
+	authRequest := events.AuthRelationshipRequest{
+		Action:   events.WriteAuthRelationshipAction,
+		ObjectID: gidx.PrefixedID("prntobj-abc123"),
+		Relations: []events.AuthRelationshipRelation{
+			{
+				Relation:  "owner",
+				SubjectID: gidx.PrefixedID("chldobj-abc123"),
+			},
+		},
+		TraceContext: map[string]string{},
+	}
+
+	authResponse := events.AuthRelationshipResponse{
+		TraceID:      "some-id",
+		TraceContext: map[string]string{},
+	}
+
+	resp, err := conn.PublishAuthRelationshipRequest(ctx, "test", authRequest)
+	require.ErrorIs(t, err, events.ErrRequestNoResponders)
+	require.Nil(t, resp)
+
+	subCtx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	requests, err := conn.SubscribeAuthRelationshipRequests(subCtx, "*.test")
+	require.NoError(t, err)
+
+	go func() {
+		reqMsg, ok := <-requests
+		if !ok {
+			return
+		}
+
+		assert.EqualValues(t, authRequest, reqMsg.Message())
+		assert.ErrorIs(t, reqMsg.Ack(), events.ErrRedisMessageNotAckable)
+
+		_, err := reqMsg.Reply(ctx, authResponse)
+		assert.NoError(t, err)
+	}()
+
+	reqCtx, reqCancel := context.WithTimeout(ctx, time.Second*2)
+
+	defer reqCancel()
+
+	resp, err = conn.PublishAuthRelationshipRequest(reqCtx, "test", authRequest)
+	require.NoError(t, err)
+	require.NoError(t, resp.Error())
+	assert.EqualValues(t, authResponse, resp.Message())
+
+	require.NoError(t, conn.Shutdown(ctx))
+	require.ErrorIs(t, conn.HealthCheck(ctx), events.ErrRedisConnectionClosed)
+}
+
+func TestRedisConfigValidate(t *testing.T) {
+	_, err := events.NewRedisConnection(events.RedisConfig{URL: "redis://localhost:6379", MaxLen: -1})
+	require.ErrorIs(t, err, events.ErrRedisInvalidMaxLen)
+
+	_, err = events.NewRedisConnection(events.RedisConfig{URL: "redis://localhost:6379", Codec: "unknown"})
+	require.ErrorIs(t, err, events.ErrUnsupportedCodec)
+}
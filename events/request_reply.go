@@ -0,0 +1,378 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	requestReplyTracerName = tracerName + ":requestreply"
+
+	// requestSubjectToken is the subject token requests are sent under, followed by the family and topic.
+	// Requests use their own subjects so they are not captured by streams storing messages published to the family.
+	requestSubjectToken = "requests"
+
+	// RequestReplyDefaultTimeout is the default time a request waits for a response, including retries.
+	RequestReplyDefaultTimeout = 10 * time.Second
+	// RequestReplyDefaultRetryBackoff is the default delay before a request with no responders is retried.
+	RequestReplyDefaultRetryBackoff = 100 * time.Millisecond
+	// RequestReplyDefaultWorkers is the default number of requests handled concurrently by Serve.
+	RequestReplyDefaultWorkers = 10
+
+	// ReplyErrorCodeInternal is the code replied when a responder fails with an error which is not a ReplyError.
+	ReplyErrorCodeInternal = "internal"
+	// ReplyErrorCodeInvalidRequest is the code replied when a request can not be decoded or fails validation.
+	ReplyErrorCodeInvalidRequest = "invalid_request"
+)
+
+// ReplyError is a structured error replied by a responder and returned to the requester.
+type ReplyError struct {
+	// Code is a machine readable error code, such as ReplyErrorCodeInvalidRequest.
+	Code string
+	// Message describes the error.
+	Message string
+}
+
+// NewReplyError returns a ReplyError with the provided code and message.
+func NewReplyError(code, message string) error {
+	return &ReplyError{Code: code, Message: message}
+}
+
+// Error returns the error code and message.
+func (e *ReplyError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Unwrap returns ErrRequestFailed so all reply errors may be matched with errors.Is.
+func (e *ReplyError) Unwrap() error {
+	return ErrRequestFailed
+}
+
+// Responder handles a request received by RequestReply.Serve, returning the response replied to the requester.
+// Returning a ReplyError replies with its code and message, any other error replies with ReplyErrorCodeInternal.
+type Responder[TReq, TResp any] func(ctx context.Context, req Message[TReq]) (TResp, error)
+
+// RequestReplyOption configures a RequestReply.
+type RequestReplyOption func(cfg *requestReplyConfig)
+
+type requestReplyConfig struct {
+	logger       *zap.SugaredLogger
+	timeout      time.Duration
+	retries      int
+	retryBackoff time.Duration
+	workers      int
+}
+
+// WithRequestReplyLogger sets the logger for the request reply.
+func WithRequestReplyLogger(logger *zap.SugaredLogger) RequestReplyOption {
+	return func(cfg *requestReplyConfig) {
+		cfg.logger = logger
+	}
+}
+
+// WithRequestTimeout sets the max time a request waits for a response, including retries.
+// A shorter deadline on the request context takes precedence.
+func WithRequestTimeout(timeout time.Duration) RequestReplyOption {
+	return func(cfg *requestReplyConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+// WithRequestRetries retries requests which fail with ErrRequestNoResponders up to retries times, waiting backoff between attempts.
+// Retries allow requests to succeed while responders are restarting. Requests which reached a responder are never retried.
+func WithRequestRetries(retries int, backoff time.Duration) RequestReplyOption {
+	return func(cfg *requestReplyConfig) {
+		cfg.retries = retries
+		cfg.retryBackoff = backoff
+	}
+}
+
+// WithResponderWorkers sets the number of requests handled concurrently by Serve.
+func WithResponderWorkers(workers int) RequestReplyOption {
+	return func(cfg *requestReplyConfig) {
+		cfg.workers = workers
+	}
+}
+
+// RequestReply sends typed requests of a message family and serves typed responses to them.
+// Requests are sent to the family and topic under the requests subject token, such as prefix.requests.family.topic,
+// so they are not stored by streams capturing messages published with Publish, and are load balanced
+// between the responders sharing the connection QueueGroup. The trace context is propagated from the requester to the responder.
+type RequestReply[TReq, TResp any] struct {
+	conn   Connection
+	family string
+	tracer trace.Tracer
+	cfg    requestReplyConfig
+}
+
+// NewRequestReply creates a RequestReply for requests of the message family sent with the connection.
+func NewRequestReply[TReq, TResp any](conn Connection, family string, options ...RequestReplyOption) (*RequestReply[TReq, TResp], error) {
+	if err := validateFamily(family); err != nil {
+		return nil, err
+	}
+
+	if !supportsTyped(conn) {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedConnection, conn)
+	}
+
+	cfg := requestReplyConfig{
+		logger:       zap.NewNop().Sugar(),
+		timeout:      RequestReplyDefaultTimeout,
+		retryBackoff: RequestReplyDefaultRetryBackoff,
+		workers:      RequestReplyDefaultWorkers,
+	}
+
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	return &RequestReply[TReq, TResp]{
+		conn:   conn,
+		family: family,
+		tracer: otel.GetTracerProvider().Tracer(requestReplyTracerName),
+		cfg:    cfg,
+	}, nil
+}
+
+// Request sends the request to the topic and waits for the response.
+// Requests implementing Validator are validated before they are sent.
+// A ReplyError is returned when the responder failed the request, ErrRequestNoResponders when no responder is subscribed.
+func (rr *RequestReply[TReq, TResp]) Request(ctx context.Context, topic string, request TReq) (TResp, error) {
+	var response TResp
+
+	ctx, span := rr.tracer.Start(ctx, "events.RequestReply.Request", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("events.family", rr.family),
+		attribute.String("events.subject_type", topic),
+	))
+
+	defer span.End()
+
+	err := rr.request(ctx, topic, request, &response)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return response, err
+}
+
+func (rr *RequestReply[TReq, TResp]) request(ctx context.Context, topic string, request TReq, response *TResp) error {
+	if validator, ok := any(request).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if rr.cfg.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, rr.cfg.timeout)
+
+		defer cancel()
+	}
+
+	var (
+		msg Message[TResp]
+		err error
+	)
+
+	for attempt := 0; ; attempt++ {
+		msg, err = rr.send(ctx, topic, request)
+		if !errors.Is(err, ErrRequestNoResponders) || attempt >= rr.cfg.retries {
+			break
+		}
+
+		rr.cfg.logger.Debugw("retrying request with no responders", "events.family", rr.family, "events.subject_type", topic, "attempt", attempt+1)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(rr.cfg.retryBackoff):
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if code := msg.Headers().Get(HeaderReplyErrorCode); code != "" {
+		return &ReplyError{Code: code, Message: msg.Headers().Get(HeaderReplyError)}
+	}
+
+	if err := msg.Error(); err != nil {
+		return err
+	}
+
+	*response = msg.Message()
+
+	return nil
+}
+
+// send publishes the request with the connection provider.
+func (rr *RequestReply[TReq, TResp]) send(ctx context.Context, topic string, request TReq) (Message[TResp], error) {
+	return runTyped[Message[TResp]](ctx, rr.conn, requestOperation[TReq, TResp]{
+		messageFamily: rr.family,
+		topic:         topic,
+		request:       request,
+	})
+}
+
+// subscribe subscribes to requests with the connection provider.
+func (rr *RequestReply[TReq, TResp]) subscribe(ctx context.Context, topic string) (<-chan Request[TReq, TResp], error) {
+	return runTyped[<-chan Request[TReq, TResp]](ctx, rr.conn, subscribeRequestsOperation[TReq, TResp]{
+		messageFamily: rr.family,
+		topic:         topic,
+	})
+}
+
+type requestOperation[TReq, TResp any] struct {
+	messageFamily string
+	topic         string
+	request       TReq
+}
+
+func (o requestOperation[TReq, TResp]) nats(ctx context.Context, c *NATSConnection) (any, error) {
+	return natsPublishRequest[TReq, TResp](ctx, c, o.messageFamily, o.topic, o.request)
+}
+
+func (o requestOperation[TReq, TResp]) memory(ctx context.Context, c *MemoryConnection) (any, error) {
+	return memoryPublishRequest[TReq, TResp](ctx, c, o.messageFamily, o.topic, o.request)
+}
+
+func (o requestOperation[TReq, TResp]) redis(ctx context.Context, c *RedisConnection) (any, error) {
+	return redisPublishRequest[TReq, TResp](ctx, c, o.messageFamily, o.topic, o.request)
+}
+
+func (o requestOperation[TReq, TResp]) familyConnection(ctx context.Context, c FamilyConnection) (any, error) {
+	return c.PublishFamilyRequest(ctx, o.messageFamily, o.topic, o.request)
+}
+
+type subscribeRequestsOperation[TReq, TResp any] struct {
+	messageFamily string
+	topic         string
+}
+
+func (o subscribeRequestsOperation[TReq, TResp]) nats(ctx context.Context, c *NATSConnection) (any, error) {
+	return natsSubscribeRequests[TReq, TResp](ctx, c, o.messageFamily, o.topic)
+}
+
+func (o subscribeRequestsOperation[TReq, TResp]) memory(ctx context.Context, c *MemoryConnection) (any, error) {
+	return memorySubscribeRequests[TReq, TResp](ctx, c, o.messageFamily, o.topic)
+}
+
+func (o subscribeRequestsOperation[TReq, TResp]) redis(ctx context.Context, c *RedisConnection) (any, error) {
+	return redisSubscribeRequests[TReq, TResp](ctx, c, o.messageFamily, o.topic)
+}
+
+func (o subscribeRequestsOperation[TReq, TResp]) familyConnection(ctx context.Context, c FamilyConnection) (any, error) {
+	return c.SubscribeFamilyRequests(ctx, o.messageFamily, o.topic)
+}
+
+// Serve subscribes to requests on the topic and replies with the responses returned by the responder until the context is canceled.
+// Requests which can not be decoded or fail validation are replied with ReplyErrorCodeInvalidRequest without calling the responder.
+func (rr *RequestReply[TReq, TResp]) Serve(ctx context.Context, topic string, responder Responder[TReq, TResp]) error {
+	requests, err := rr.subscribe(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+
+	for range max(rr.cfg.workers, 1) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for req := range requests {
+				rr.respond(ctx, req, responder)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// respond handles a single request and replies with the response or error.
+func (rr *RequestReply[TReq, TResp]) respond(ctx context.Context, req Request[TReq, TResp], responder Responder[TReq, TResp]) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, req.Headers())
+
+	ctx, span := rr.tracer.Start(ctx, "events.RequestReply.Serve", trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		attribute.String("events.family", rr.family),
+		attribute.String("messaging.destination.name", req.Topic()),
+	))
+
+	defer span.End()
+
+	logger := rr.cfg.logger.With("events.family", rr.family, "events.subject", req.Topic())
+
+	response, err := rr.handle(ctx, req, responder)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		var replyErr *ReplyError
+
+		if !errors.As(err, &replyErr) {
+			logger.Errorw("responder failed", "error", err)
+
+			// internal errors are not replied to avoid exposing responder details to requesters.
+			replyErr = &ReplyError{Code: ReplyErrorCodeInternal, Message: "internal error"}
+		}
+
+		ctx = ContextWithHeaders(ctx, Headers{
+			HeaderReplyErrorCode: {replyErr.Code},
+			HeaderReplyError:     {replyErr.Message},
+		})
+	}
+
+	if _, err := req.Reply(ctx, response); err != nil {
+		logger.Warnw("failed to reply to request", "error", err)
+	}
+}
+
+// handle validates the request and calls the responder, recovering from any panic.
+func (rr *RequestReply[TReq, TResp]) handle(ctx context.Context, req Message[TReq], responder Responder[TReq, TResp]) (response TResp, err error) {
+	if err := req.Error(); err != nil {
+		return response, &ReplyError{Code: ReplyErrorCodeInvalidRequest, Message: err.Error()}
+	}
+
+	if validator, ok := any(req.Message()).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return response, &ReplyError{Code: ReplyErrorCodeInvalidRequest, Message: err.Error()}
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v\n%s", ErrResponderPanic, r, debug.Stack())
+		}
+	}()
+
+	return responder(ctx, req)
+}
+
+// validateReply validates responses implementing Validator. Error replies carry a zero response which is not validated.
+func validateReply(ctx context.Context, message any) error {
+	if HeadersFromContext(ctx).Get(HeaderReplyErrorCode) != "" {
+		return nil
+	}
+
+	if validator, ok := message.(Validator); ok {
+		return validator.Validate()
+	}
+
+	return nil
+}
@@ -0,0 +1,380 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+var (
+	errMissingCommandName = errors.New("command name required")
+	errCommandFailed      = errors.New("database unavailable")
+)
+
+type testCommand struct {
+	Name string `json:"name"`
+}
+
+func (c testCommand) Validate() error {
+	if c.Name == "" {
+		return errMissingCommandName
+	}
+
+	return nil
+}
+
+type testCommandResult struct {
+	Greeting string `json:"greeting"`
+}
+
+func testResponder(_ context.Context, req events.Message[testCommand]) (testCommandResult, error) {
+	switch req.Message().Name {
+	case "denied":
+		return testCommandResult{}, events.NewReplyError("permission_denied", "not allowed")
+	case "failed":
+		return testCommandResult{}, errCommandFailed
+	case "panic":
+		panic("responder panic")
+	}
+
+	return testCommandResult{Greeting: "hello " + req.Message().Name}, nil
+}
+
+func TestRequestReply(t *testing.T) {
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	redis, err := eventtools.NewRedisServer()
+	require.NoError(t, err)
+
+	defer redis.Close()
+
+	testCases := []struct {
+		name string
+		conn func(t *testing.T) events.Connection
+	}{
+		{
+			name: "nats",
+			conn: func(t *testing.T) events.Connection {
+				conn, err := events.NewNATSConnection(nats.Config.NATS)
+				require.NoError(t, err)
+
+				return conn
+			},
+		},
+		{
+			name: "memory",
+			conn: func(t *testing.T) events.Connection {
+				conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+				require.NoError(t, err)
+
+				return conn
+			},
+		},
+		{
+			name: "redis",
+			conn: func(t *testing.T) events.Connection {
+				return newTestRedisConnection(t, redis, "")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			defer cancel()
+
+			conn := tc.conn(t)
+
+			defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+			rr, err := events.NewRequestReply[testCommand, testCommandResult](conn, "commands", events.WithRequestTimeout(time.Second*2))
+			require.NoError(t, err)
+
+			_, err = rr.Request(ctx, "greet", testCommand{Name: "world"})
+			require.ErrorIs(t, err, events.ErrRequestNoResponders)
+
+			served := make(chan error, 1)
+
+			go func() {
+				served <- rr.Serve(ctx, "greet", testResponder)
+			}()
+
+			// wait for the responder to subscribe.
+			require.Eventually(t, func() bool {
+				_, err := rr.Request(ctx, "greet", testCommand{Name: "world"})
+
+				return !errors.Is(err, events.ErrRequestNoResponders)
+			}, time.Second*2, time.Millisecond*50)
+
+			resp, err := rr.Request(ctx, "greet", testCommand{Name: "world"})
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", resp.Greeting)
+
+			// responders only respond to the topic they serve.
+			_, err = rr.Request(ctx, "farewell", testCommand{Name: "world"})
+			require.ErrorIs(t, err, events.ErrRequestNoResponders)
+
+			_, err = rr.Request(ctx, "greet", testCommand{})
+			require.ErrorIs(t, err, errMissingCommandName)
+
+			var replyErr *events.ReplyError
+
+			_, err = rr.Request(ctx, "greet", testCommand{Name: "denied"})
+			require.ErrorIs(t, err, events.ErrRequestFailed)
+			require.ErrorAs(t, err, &replyErr)
+			assert.Equal(t, "permission_denied", replyErr.Code)
+			assert.Equal(t, "not allowed", replyErr.Message)
+
+			_, err = rr.Request(ctx, "greet", testCommand{Name: "failed"})
+			require.ErrorAs(t, err, &replyErr)
+			assert.Equal(t, events.ReplyErrorCodeInternal, replyErr.Code)
+			assert.NotContains(t, replyErr.Message, errCommandFailed.Error())
+
+			_, err = rr.Request(ctx, "greet", testCommand{Name: "panic"})
+			require.ErrorAs(t, err, &replyErr)
+			assert.Equal(t, events.ReplyErrorCodeInternal, replyErr.Code)
+
+			cancel()
+
+			require.NoError(t, <-served)
+		})
+	}
+}
+
+func TestRequestReplyInvalidRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	responder, err := events.NewRequestReply[testCommand, testCommandResult](conn, "commands")
+	require.NoError(t, err)
+
+	// requests sent without validation still fail validation in the responder.
+	requester, err := events.NewRequestReply[map[string]any, testCommandResult](conn, "commands")
+	require.NoError(t, err)
+
+	go responder.Serve(ctx, "greet", testResponder) //nolint:errcheck // within test
+
+	var replyErr *events.ReplyError
+
+	require.Eventually(t, func() bool {
+		_, err := requester.Request(ctx, "greet", map[string]any{"name": ""})
+
+		return errors.As(err, &replyErr)
+	}, time.Second, time.Millisecond*10)
+
+	assert.Equal(t, events.ReplyErrorCodeInvalidRequest, replyErr.Code)
+	assert.Equal(t, errMissingCommandName.Error(), replyErr.Message)
+}
+
+func TestRequestReplyRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	rr, err := events.NewRequestReply[testCommand, testCommandResult](conn, "commands",
+		events.WithRequestRetries(20, time.Millisecond*20),
+	)
+	require.NoError(t, err)
+
+	// the responder subscribes after the first attempt.
+	time.AfterFunc(time.Millisecond*100, func() {
+		go rr.Serve(ctx, "greet", testResponder) //nolint:errcheck // within test
+	})
+
+	resp, err := rr.Request(ctx, "greet", testCommand{Name: "retry"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello retry", resp.Greeting)
+
+	timeoutRR, err := events.NewRequestReply[testCommand, testCommandResult](conn, "commands",
+		events.WithRequestTimeout(time.Millisecond*100),
+		events.WithRequestRetries(100, time.Millisecond*20),
+	)
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	_, err = timeoutRR.Request(ctx, "unknown", testCommand{Name: "retry"})
+	require.ErrorIs(t, err, events.ErrRequestNoResponders)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRequestReplyQueueGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	requesterCtx, traceParent := testTraceContext(t)
+
+	var (
+		mu       sync.Mutex
+		handled  = map[string]int{}
+		traceIDs = map[trace.TraceID]bool{}
+	)
+
+	for _, name := range []string{"responder-1", "responder-2"} {
+		conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, Name: "testing-request-reply", QueueGroup: "testing-responders"})
+		require.NoError(t, err)
+
+		defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+		rr, err := events.NewRequestReply[testCommand, testCommandResult](conn, "commands")
+		require.NoError(t, err)
+
+		go rr.Serve(ctx, "greet", func(ctx context.Context, req events.Message[testCommand]) (testCommandResult, error) { //nolint:errcheck // within test
+			mu.Lock()
+			defer mu.Unlock()
+
+			handled[name]++
+			traceIDs[trace.SpanContextFromContext(ctx).TraceID()] = true
+
+			return testCommandResult{Greeting: name}, nil
+		})
+	}
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, Name: "testing-request-reply"})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	rr, err := events.NewRequestReply[testCommand, testCommandResult](conn, "commands", events.WithRequestRetries(10, time.Millisecond*10))
+	require.NoError(t, err)
+
+	const total = 20
+
+	for range total {
+		_, err := rr.Request(requesterCtx, "greet", testCommand{Name: "queue"})
+		require.NoError(t, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, total, handled["responder-1"]+handled["responder-2"], "each request is handled by a single responder")
+
+	require.Len(t, traceIDs, 1)
+
+	for traceID := range traceIDs {
+		assert.Equal(t, traceParent[3:35], traceID.String(), "responder continues the requester trace")
+	}
+}
+
+func TestNewRequestReplyValidation(t *testing.T) {
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+	require.NoError(t, err)
+
+	_, err = events.NewRequestReply[testCommand, testCommandResult](conn, "invalid.family")
+	require.ErrorIs(t, err, events.ErrInvalidMessageFamily)
+
+	_, err = events.NewRequestReply[testCommand, testCommandResult](conn, "requests")
+	require.ErrorIs(t, err, events.ErrInvalidMessageFamily)
+
+	_, err = events.NewRequestReply[testCommand, testCommandResult](nil, "commands")
+	require.ErrorIs(t, err, events.ErrUnsupportedConnection)
+}
+
+func TestRequestReplyMockConnection(t *testing.T) {
+	ctx := context.Background()
+
+	conn := &eventtools.MockConnection{}
+
+	response := &eventtools.MockMessage[testCommandResult]{}
+	response.On("Headers").Return(events.Headers{})
+	response.On("Error").Return(nil)
+	response.On("Message").Return(testCommandResult{Greeting: "hello mock"})
+
+	conn.On("PublishFamilyRequest", "commands", "greet", testCommand{Name: "mock"}).Return(events.Message[testCommandResult](response), nil)
+
+	rr, err := events.NewRequestReply[testCommand, testCommandResult](conn, "commands")
+	require.NoError(t, err)
+
+	resp, err := rr.Request(ctx, "greet", testCommand{Name: "mock"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello mock", resp.Greeting)
+
+	conn.AssertExpectations(t)
+}
+
+func TestNATSRequestReplyFamilyStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	// a stream capturing messages published to the family, as required to Subscribe to the family.
+	_, err = nats.JetStream.AddStream(&natsgo.StreamConfig{
+		Name:     "commands",
+		Subjects: []string{eventtools.Prefix + ".commands.>"},
+	})
+	require.NoError(t, err)
+
+	natsCfg := nats.Config.NATS
+	natsCfg.QueueGroup = "testing-commands"
+
+	conn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	rr, err := events.NewRequestReply[testCommand, testCommandResult](conn, "commands")
+	require.NoError(t, err)
+
+	go rr.Serve(ctx, "greet", testResponder) //nolint:errcheck // within test
+
+	var resp testCommandResult
+
+	require.Eventually(t, func() bool {
+		resp, err = rr.Request(ctx, "greet", testCommand{Name: "stream"})
+
+		return !errors.Is(err, events.ErrRequestNoResponders)
+	}, time.Second*2, time.Millisecond*50)
+
+	require.NoError(t, err)
+	assert.Equal(t, "hello stream", resp.Greeting)
+
+	// requests are not stored by the family stream, so the responder replies instead of the stream.
+	info, err := nats.JetStream.StreamInfo("commands")
+	require.NoError(t, err)
+	assert.Zero(t, info.State.Msgs)
+
+	// messages published to the family are still stored and delivered to subscribers.
+	messages, err := events.Subscribe[testInvoice](ctx, conn, "commands", ">")
+	require.NoError(t, err)
+
+	_, err = events.Publish(ctx, conn, "commands", "billing", testInvoice{ID: "inv-1"})
+	require.NoError(t, err)
+
+	received, err := getSingleMessage(messages, time.Second*2)
+	require.NoError(t, err)
+	require.NoError(t, received.Error())
+	assert.Equal(t, "inv-1", received.Message().ID)
+	require.NoError(t, received.Ack())
+}
@@ -0,0 +1,460 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// AnyEventType may be used when registering a handler to handle all event types for a topic
+	// which do not have a more specific handler registered.
+	AnyEventType = "*"
+
+	// RouterDefaultWorkers is the default number of messages handled concurrently by a router.
+	RouterDefaultWorkers = 10
+	// RouterDefaultBackoffMin is the default nak delay for a message which failed its first delivery.
+	RouterDefaultBackoffMin = time.Second
+	// RouterDefaultBackoffMax is the default maximum nak delay for a message which failed to be handled.
+	RouterDefaultBackoffMax = time.Minute
+)
+
+// ErrRouterHandlerPanic is returned when a handler or middleware panics while processing a message.
+var ErrRouterHandlerPanic = errors.New("router handler panic")
+
+// TerminalError wraps an error returned from a handler to signal the message should be terminated instead of redelivered.
+type TerminalError struct {
+	Err error
+}
+
+// NewTerminalError wraps err as a TerminalError.
+func NewTerminalError(err error) error {
+	return &TerminalError{Err: err}
+}
+
+// Error returns the wrapped error string.
+func (e *TerminalError) Error() string {
+	if e.Err == nil {
+		return "terminal error"
+	}
+
+	return "terminal: " + e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *TerminalError) Unwrap() error {
+	return e.Err
+}
+
+// IsTerminalError reports whether err contains a TerminalError.
+func IsTerminalError(err error) bool {
+	var terminal *TerminalError
+
+	return errors.As(err, &terminal)
+}
+
+// Handler processes a single message received by a Router.
+// Returning nil acks the message, returning an error naks the message with a backoff delay
+// unless the error is a TerminalError in which case the message is terminated.
+// Messages implementing NakWithErrorMessage are naked with the error, which is recorded if the message is dead-lettered.
+type Handler[T any] func(ctx context.Context, msg Message[T]) error
+
+// Delivery describes the message being handled and is provided to middleware.
+type Delivery struct {
+	// Topic is the topic the handler was registered with.
+	Topic string
+	// EventType is the event type of the received message.
+	EventType string
+	// Subject is the full subject the message was received on.
+	Subject string
+	// ID is the message id.
+	ID string
+	// Deliveries is the number of times the message has been delivered.
+	Deliveries uint64
+	// Message is the received Message, either Message[ChangeMessage] or Message[EventMessage].
+	Message any
+}
+
+// HandlerFunc is the untyped handler signature middleware wraps.
+type HandlerFunc func(ctx context.Context, delivery Delivery) error
+
+// Middleware wraps the handling of every message processed by a Router.
+// Panics are recovered outside of the middleware chain, so middleware unwinds without observing a handler panic.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// RouterOption configures a Router.
+type RouterOption func(r *Router)
+
+// WithRouterLogger sets the logger for the router.
+func WithRouterLogger(logger *zap.SugaredLogger) RouterOption {
+	return func(r *Router) {
+		r.logger = logger
+	}
+}
+
+// WithRouterWorkers sets the number of messages which may be handled concurrently.
+func WithRouterWorkers(workers int) RouterOption {
+	return func(r *Router) {
+		r.workers = workers
+	}
+}
+
+// WithRouterPartitioning partitions messages across the workers by key, messages with the same key are handled
+// in the order they were received while messages with different keys are handled concurrently.
+// keyFunc is called with the received Message, either Message[ChangeMessage] or Message[EventMessage],
+// a nil keyFunc partitions messages with PartitionKey.
+func WithRouterPartitioning(keyFunc func(msg any) string) RouterOption {
+	return func(r *Router) {
+		r.partitioned = true
+		r.partitionKey = keyFunc
+	}
+}
+
+// WithRouterBackoff sets the function used to determine the nak delay for a failed message.
+func WithRouterBackoff(backoff func(deliveries uint64) time.Duration) RouterOption {
+	return func(r *Router) {
+		r.backoff = backoff
+	}
+}
+
+// WithRouterMiddleware appends middleware to the router.
+func WithRouterMiddleware(middleware ...Middleware) RouterOption {
+	return func(r *Router) {
+		r.middleware = append(r.middleware, middleware...)
+	}
+}
+
+// ExponentialBackoff returns a backoff function which doubles the delay for each delivery starting at minDelay up to maxDelay.
+func ExponentialBackoff(minDelay, maxDelay time.Duration) func(deliveries uint64) time.Duration {
+	return func(deliveries uint64) time.Duration {
+		delay := minDelay
+
+		for i := uint64(1); i < deliveries && delay < maxDelay; i++ {
+			delay *= 2
+		}
+
+		return min(delay, maxDelay)
+	}
+}
+
+// Router subscribes to topics and dispatches the received messages to the registered handlers by event type.
+// Messages are handled by a bounded pool of workers and are acked, nacked or terminated based on the handler result.
+type Router struct {
+	subscriber Subscriber
+	logger     *zap.SugaredLogger
+	workers    int
+	backoff    func(deliveries uint64) time.Duration
+	middleware []Middleware
+
+	partitioned  bool
+	partitionKey func(msg any) string
+
+	mu           sync.Mutex
+	changeRoutes map[string]map[string]Handler[ChangeMessage]
+	eventRoutes  map[string]map[string]Handler[EventMessage]
+}
+
+// NewRouter creates a new Router receiving messages from the provided subscriber.
+func NewRouter(subscriber Subscriber, options ...RouterOption) *Router {
+	r := &Router{
+		subscriber:   subscriber,
+		logger:       zap.NewNop().Sugar(),
+		workers:      RouterDefaultWorkers,
+		backoff:      ExponentialBackoff(RouterDefaultBackoffMin, RouterDefaultBackoffMax),
+		changeRoutes: make(map[string]map[string]Handler[ChangeMessage]),
+		eventRoutes:  make(map[string]map[string]Handler[EventMessage]),
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// Use appends middleware to the router.
+func (r *Router) Use(middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// HandleChange registers a handler for change messages received on topic with the provided event type.
+// Use AnyEventType to handle all event types without a specific handler.
+func (r *Router) HandleChange(topic, eventType string, handler Handler[ChangeMessage]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.changeRoutes[topic] == nil {
+		r.changeRoutes[topic] = make(map[string]Handler[ChangeMessage])
+	}
+
+	r.changeRoutes[topic][eventType] = handler
+}
+
+// HandleEvent registers a handler for event messages received on topic with the provided event type.
+// Use AnyEventType to handle all event types without a specific handler.
+func (r *Router) HandleEvent(topic, eventType string, handler Handler[EventMessage]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.eventRoutes[topic] == nil {
+		r.eventRoutes[topic] = make(map[string]Handler[EventMessage])
+	}
+
+	r.eventRoutes[topic][eventType] = handler
+}
+
+// Run subscribes to all registered topics and handles messages until the context is canceled
+// or all subscriptions are closed. Run waits for in progress handlers to complete before returning.
+func (r *Router) Run(ctx context.Context) error {
+	r.mu.Lock()
+
+	run := &routerRun{
+		Router:     r,
+		middleware: slices.Clone(r.middleware),
+	}
+
+	changeRoutes := make(map[string]map[string]Handler[ChangeMessage], len(r.changeRoutes))
+	for topic, routes := range r.changeRoutes {
+		changeRoutes[topic] = maps.Clone(routes)
+	}
+
+	eventRoutes := make(map[string]map[string]Handler[EventMessage], len(r.eventRoutes))
+	for topic, routes := range r.eventRoutes {
+		eventRoutes[topic] = maps.Clone(routes)
+	}
+
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// workers share a single queue unless partitioned, where each worker has its own queue.
+	queues := 1
+	if r.partitioned {
+		queues = max(r.workers, 1)
+	}
+
+	jobs := make([]chan func(), queues)
+	for i := range jobs {
+		jobs[i] = make(chan func())
+	}
+
+	var feeders sync.WaitGroup
+
+	for topic, routes := range changeRoutes {
+		msgs, err := r.subscriber.SubscribeChanges(ctx, topic)
+		if err != nil {
+			cancel()
+			feeders.Wait()
+
+			return fmt.Errorf("subscribing to changes topic %s: %w", topic, err)
+		}
+
+		feeders.Add(1)
+
+		go routeMessages(ctx, run, topic, routes, msgs, jobs, feeders.Done)
+	}
+
+	for topic, routes := range eventRoutes {
+		msgs, err := r.subscriber.SubscribeEvents(ctx, topic)
+		if err != nil {
+			cancel()
+			feeders.Wait()
+
+			return fmt.Errorf("subscribing to events topic %s: %w", topic, err)
+		}
+
+		feeders.Add(1)
+
+		go routeMessages(ctx, run, topic, routes, msgs, jobs, feeders.Done)
+	}
+
+	var workers sync.WaitGroup
+
+	for i := range max(r.workers, 1) {
+		queue := jobs[i%queues]
+
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for job := range queue {
+				job()
+			}
+		}()
+	}
+
+	feeders.Wait()
+
+	for _, queue := range jobs {
+		close(queue)
+	}
+
+	workers.Wait()
+
+	return nil
+}
+
+// routerRun holds the router state captured when Run is called.
+type routerRun struct {
+	*Router
+
+	middleware []Middleware
+}
+
+// eventTyped is implemented by messages which have an event type.
+type eventTyped interface {
+	GetEventType() string
+	GetTraceContext(ctx context.Context) context.Context
+}
+
+func routeMessages[T eventTyped](ctx context.Context, r *routerRun, topic string, routes map[string]Handler[T], msgs <-chan Message[T], jobs []chan func(), done func()) {
+	defer done()
+
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+
+			queue := jobs[0]
+			if len(jobs) > 1 {
+				queue = jobs[partitionIndex(routerPartitionKey(r, msg), len(jobs))]
+			}
+
+			select {
+			case queue <- func() { handleMessage(ctx, r, topic, routes, msg) }:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// routerPartitionKey returns the key the message is partitioned by.
+func routerPartitionKey[T any](r *routerRun, msg Message[T]) string {
+	if r.partitionKey != nil {
+		return r.partitionKey(msg)
+	}
+
+	return PartitionKey(msg)
+}
+
+func handleMessage[T eventTyped](ctx context.Context, r *routerRun, topic string, routes map[string]Handler[T], msg Message[T]) {
+	logger := r.logger.With("events.topic", topic, "events.subject", msg.Topic(), "events.message_id", msg.ID())
+
+	if err := msg.Error(); err != nil {
+		logger.Errorw("failed to decode message, terminating", "error", err)
+
+		if err := msg.Term(); err != nil {
+			logger.Warnw("failed to terminate message", "error", err)
+		}
+
+		return
+	}
+
+	eventType := msg.Message().GetEventType()
+
+	handler, ok := routes[eventType]
+	if !ok {
+		handler, ok = routes[AnyEventType]
+	}
+
+	if !ok {
+		logger.Debugw("no handler registered for event type, acking", "events.event_type", eventType)
+
+		if err := msg.Ack(); err != nil {
+			logger.Warnw("failed to ack message", "error", err)
+		}
+
+		return
+	}
+
+	var next HandlerFunc = func(ctx context.Context, _ Delivery) error {
+		return handler(ctx, msg)
+	}
+
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		next = r.middleware[i](next)
+	}
+
+	delivery := Delivery{
+		Topic:      topic,
+		EventType:  eventType,
+		Subject:    msg.Topic(),
+		ID:         msg.ID(),
+		Deliveries: msg.Deliveries(),
+		Message:    msg,
+	}
+
+	err := runHandler(handlerContext(ctx, msg), logger, next, delivery)
+
+	switch {
+	case err == nil:
+		if err := msg.Ack(); err != nil {
+			logger.Warnw("failed to ack message", "error", err)
+		}
+	case IsTerminalError(err):
+		logger.Errorw("handler returned terminal error, terminating", "error", err)
+
+		if err := msg.Term(); err != nil {
+			logger.Warnw("failed to terminate message", "error", err)
+		}
+	default:
+		delay := r.backoff(msg.Deliveries())
+
+		logger.Warnw("handler failed, nacking", "error", err, "events.nak_delay", delay)
+
+		if err := nakWithError(msg, delay, err); err != nil {
+			logger.Warnw("failed to nak message", "error", err)
+		}
+	}
+}
+
+// nakWithError naks the message, recording the handler error when the message supports it.
+func nakWithError[T any](msg Message[T], delay time.Duration, cause error) error {
+	if naker, ok := msg.(NakWithErrorMessage); ok {
+		return naker.NakWithError(delay, cause)
+	}
+
+	return msg.Nak(delay)
+}
+
+// runHandler calls the middleware chain, recovering a panic raised by either the middleware or the handler.
+// The recovered panic is returned as an ErrRouterHandlerPanic error, so the message is nacked and the worker continues.
+func runHandler(ctx context.Context, logger *zap.SugaredLogger, next HandlerFunc, delivery Delivery) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("%w: %v", ErrRouterHandlerPanic, rec)
+
+			logger.Errorw("handler panic", "panic", rec, "stack", string(debug.Stack()))
+		}
+	}()
+
+	return next(ctx, delivery)
+}
+
+// handlerContext returns the context handlers are called with.
+// The context continues the message process span when one was started, otherwise the trace context propagated with the message.
+func handlerContext[T eventTyped](ctx context.Context, msg Message[T]) context.Context {
+	if span := trace.SpanFromContext(msg.Context()); span.SpanContext().IsValid() {
+		return trace.ContextWithSpan(ctx, span)
+	}
+
+	return msg.Message().GetTraceContext(ctx)
+}
@@ -0,0 +1,150 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	routerTracerName = tracerName + ":router"
+	routerMeterName  = meterName + "/router"
+)
+
+// LoggingMiddleware logs the result and duration of each handled message.
+func LoggingMiddleware(logger *zap.SugaredLogger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, delivery Delivery) error {
+			start := time.Now()
+
+			err := next(ctx, delivery)
+
+			logger := logger.With(
+				"events.topic", delivery.Topic,
+				"events.subject", delivery.Subject,
+				"events.event_type", delivery.EventType,
+				"events.message_id", delivery.ID,
+				"events.deliveries", delivery.Deliveries,
+				"duration", time.Since(start),
+			)
+
+			if err != nil {
+				logger.Errorw("message handler failed", "error", err)
+			} else {
+				logger.Debug("message handled")
+			}
+
+			return err
+		}
+	}
+}
+
+// TracingMiddleware starts a span for each handled message, parented to the trace context propagated with the message.
+func TracingMiddleware() Middleware {
+	tracer := otel.GetTracerProvider().Tracer(routerTracerName)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, delivery Delivery) error {
+			ctx, span := tracer.Start(ctx, "events.router.Handle", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+				attribute.String("events.topic", delivery.Topic),
+				attribute.String("events.subject", delivery.Subject),
+				attribute.String("events.event_type", delivery.EventType),
+				attribute.String("events.message_id", delivery.ID),
+				attribute.Int64("events.deliveries", int64(delivery.Deliveries)), //nolint:gosec // deliveries will not overflow
+			))
+
+			defer span.End()
+
+			err := next(ctx, delivery)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return err
+		}
+	}
+}
+
+// MetricsMiddleware records the messages handled, by the ack, nak or term action the handler result causes, and the
+// handler duration using the global MeterProvider. Attributes are limited to the handler topic, event type and action
+// so the cardinality is bounded by the handlers registered. Handler panics are recorded as naks.
+func MetricsMiddleware() Middleware {
+	handled, duration, err := routerInstruments(otel.GetMeterProvider().Meter(routerMeterName))
+	if err != nil {
+		otel.Handle(err)
+
+		handled, duration, _ = routerInstruments(noop.NewMeterProvider().Meter(routerMeterName))
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, delivery Delivery) (err error) {
+			start := time.Now()
+
+			record := func(action string) {
+				attrs := metric.WithAttributes(
+					attribute.String("events.topic", delivery.Topic),
+					attribute.String("events.event_type", delivery.EventType),
+					attribute.String("events.ack.action", action),
+				)
+
+				handled.Add(ctx, 1, attrs)
+				duration.Record(ctx, time.Since(start).Seconds(), attrs)
+			}
+
+			defer func() {
+				// panics are recovered by the router outside of the middleware chain and the message is nacked.
+				if rec := recover(); rec != nil {
+					record(ackActionNak)
+
+					panic(rec)
+				}
+			}()
+
+			err = next(ctx, delivery)
+
+			record(routerAckAction(err))
+
+			return err
+		}
+	}
+}
+
+func routerInstruments(meter metric.Meter) (metric.Int64Counter, metric.Float64Histogram, error) {
+	handled, err := meter.Int64Counter("events.router.handled",
+		metric.WithDescription("Messages handled by router handlers, by topic, event type and the resulting ack action."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	duration, err := meter.Float64Histogram("events.router.handle.duration",
+		metric.WithDescription("Time handling a message, including the middleware following the metrics middleware."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return handled, duration, nil
+}
+
+// routerAckAction returns the action the router takes for a handler result.
+func routerAckAction(err error) string {
+	switch {
+	case err == nil:
+		return ackActionAck
+	case IsTerminalError(err):
+		return ackActionTerm
+	default:
+		return ackActionNak
+	}
+}
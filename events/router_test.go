@@ -0,0 +1,355 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+var errRouterTest = errors.New("router test error")
+
+func TestRouter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{
+		Enabled:    true,
+		QueueGroup: "testing-router",
+	})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	var (
+		mu           sync.Mutex
+		deliveries   = map[string][]uint64{}
+		middlewareCt atomic.Int32
+		done         = make(chan struct{}, 10)
+	)
+
+	record := func(eventType string, msg events.Message[events.ChangeMessage]) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		deliveries[eventType] = append(deliveries[eventType], msg.Deliveries())
+	}
+
+	router := events.NewRouter(conn,
+		events.WithRouterWorkers(2),
+		events.WithRouterBackoff(func(uint64) time.Duration { return 0 }),
+		events.WithRouterMiddleware(func(next events.HandlerFunc) events.HandlerFunc {
+			return func(ctx context.Context, delivery events.Delivery) error {
+				middlewareCt.Add(1)
+
+				return next(ctx, delivery)
+			}
+		}),
+	)
+
+	router.Use(events.TracingMiddleware())
+
+	router.HandleChange("*.test", string(events.CreateChangeType), func(_ context.Context, msg events.Message[events.ChangeMessage]) error {
+		record("create", msg)
+
+		done <- struct{}{}
+
+		return nil
+	})
+
+	router.HandleChange("*.test", string(events.UpdateChangeType), func(_ context.Context, msg events.Message[events.ChangeMessage]) error {
+		record("update", msg)
+
+		if msg.Deliveries() == 1 {
+			return errRouterTest
+		}
+
+		done <- struct{}{}
+
+		return nil
+	})
+
+	router.HandleChange("*.test", string(events.DeleteChangeType), func(_ context.Context, msg events.Message[events.ChangeMessage]) error {
+		record("delete", msg)
+
+		done <- struct{}{}
+
+		return events.NewTerminalError(errRouterTest)
+	})
+
+	router.HandleChange("*.test", events.AnyEventType, func(_ context.Context, msg events.Message[events.ChangeMessage]) error {
+		record("any", msg)
+
+		if msg.Deliveries() == 1 {
+			panic("testing panic")
+		}
+
+		done <- struct{}{}
+
+		return nil
+	})
+
+	runErr := make(chan error, 1)
+
+	go func() {
+		runErr <- router.Run(ctx)
+	}()
+
+	for _, eventType := range []string{"create", "update", "delete", "custom"} {
+		_, err := conn.PublishChange(ctx, "test", testChange(eventType))
+		require.NoError(t, err)
+	}
+
+	for range 4 {
+		select {
+		case <-done:
+		case <-time.After(time.Second * 2):
+			t.Fatal("timed out waiting for handlers")
+		}
+	}
+
+	// ensure terminated messages are not redelivered
+	time.Sleep(time.Millisecond * 100)
+
+	cancel()
+
+	require.NoError(t, <-runErr)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []uint64{1}, deliveries["create"])
+	assert.Equal(t, []uint64{1, 2}, deliveries["update"])
+	assert.Equal(t, []uint64{1}, deliveries["delete"])
+	assert.Equal(t, []uint64{1, 2}, deliveries["any"])
+	assert.Equal(t, int32(6), middlewareCt.Load())
+}
+
+func TestRouterMiddlewarePanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{
+		Enabled:    true,
+		QueueGroup: "testing-router",
+	})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	handled := make(chan uint64, 1)
+
+	router := events.NewRouter(conn,
+		events.WithRouterBackoff(func(uint64) time.Duration { return 0 }),
+		events.WithRouterMiddleware(func(next events.HandlerFunc) events.HandlerFunc {
+			return func(ctx context.Context, delivery events.Delivery) error {
+				if delivery.Deliveries == 1 {
+					panic("testing middleware panic")
+				}
+
+				return next(ctx, delivery)
+			}
+		}),
+	)
+
+	router.HandleChange("*.test", events.AnyEventType, func(_ context.Context, msg events.Message[events.ChangeMessage]) error {
+		handled <- msg.Deliveries()
+
+		return nil
+	})
+
+	runErr := make(chan error, 1)
+
+	go func() {
+		runErr <- router.Run(ctx)
+	}()
+
+	_, err = conn.PublishChange(ctx, "test", testChange("create"))
+	require.NoError(t, err)
+
+	// the panicking delivery is nacked and redelivered instead of stopping the router.
+	select {
+	case deliveries := <-handled:
+		assert.Equal(t, uint64(2), deliveries)
+	case <-time.After(time.Second * 2):
+		t.Fatal("timed out waiting for handler")
+	}
+
+	cancel()
+
+	require.NoError(t, <-runErr)
+}
+
+func TestRouterDeadLetterError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	natsCfg := nats.Config.NATS
+	natsCfg.QueueGroup = "testing-router-dead-letter"
+	natsCfg.DeadLetterMaxDeliveries = 1
+
+	conn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	router := events.NewRouter(conn, events.WithRouterBackoff(func(uint64) time.Duration { return 0 }))
+
+	router.HandleChange("*.test", events.AnyEventType, func(_ context.Context, _ events.Message[events.ChangeMessage]) error {
+		return errRouterTest
+	})
+
+	runErr := make(chan error, 1)
+
+	go func() {
+		runErr <- router.Run(ctx)
+	}()
+
+	_, err = conn.PublishChange(ctx, "test", testChange("create"))
+	require.NoError(t, err)
+
+	var deadLetters []events.DeadLetter
+
+	// the handler error is recorded on the dead-lettered message.
+	require.Eventually(t, func() bool {
+		deadLetters, err = conn.ListDeadLetters(ctx, ">")
+
+		return err == nil && len(deadLetters) == 1
+	}, time.Second*2, time.Millisecond*50)
+
+	assert.Equal(t, errRouterTest.Error(), deadLetters[0].Error)
+
+	cancel()
+
+	require.NoError(t, <-runErr)
+}
+
+func TestRouterMetricsMiddleware(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	reader := sdkmetric.NewManualReader()
+
+	prevProvider := otel.GetMeterProvider()
+
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	defer otel.SetMeterProvider(prevProvider)
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{
+		Enabled:    true,
+		QueueGroup: "testing-router-metrics",
+	})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(context.Background()) //nolint:errcheck // within test
+
+	done := make(chan struct{}, 10)
+
+	router := events.NewRouter(conn,
+		events.WithRouterBackoff(func(uint64) time.Duration { return 0 }),
+		events.WithRouterMiddleware(events.MetricsMiddleware()),
+	)
+
+	router.HandleChange("*.test", events.AnyEventType, func(_ context.Context, msg events.Message[events.ChangeMessage]) error {
+		defer func() { done <- struct{}{} }()
+
+		switch msg.Message().EventType {
+		case "update":
+			if msg.Deliveries() == 1 {
+				return errRouterTest
+			}
+		case "delete":
+			return events.NewTerminalError(errRouterTest)
+		case "custom":
+			if msg.Deliveries() == 1 {
+				panic("testing panic")
+			}
+		}
+
+		return nil
+	})
+
+	runErr := make(chan error, 1)
+
+	go func() {
+		runErr <- router.Run(ctx)
+	}()
+
+	for _, eventType := range []string{"create", "update", "delete", "custom"} {
+		_, err := conn.PublishChange(ctx, "test", testChange(eventType))
+		require.NoError(t, err)
+	}
+
+	for range 6 {
+		select {
+		case <-done:
+		case <-time.After(time.Second * 2):
+			t.Fatal("timed out waiting for handlers")
+		}
+	}
+
+	cancel()
+
+	require.NoError(t, <-runErr)
+
+	var rm metricdata.ResourceMetrics
+
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	topic := attribute.String("events.topic", "*.test")
+
+	assert.Equal(t, int64(3), metricSum(t, rm, "events.router.handled", topic, attribute.String("events.ack.action", "ack")))
+	assert.Equal(t, int64(2), metricSum(t, rm, "events.router.handled", topic, attribute.String("events.ack.action", "nak")))
+	assert.Equal(t, int64(1), metricSum(t, rm, "events.router.handled", topic, attribute.String("events.ack.action", "term")))
+	assert.Equal(t, int64(1), metricSum(t, rm, "events.router.handled", topic,
+		attribute.String("events.event_type", "custom"),
+		attribute.String("events.ack.action", "nak"),
+	))
+	assert.Equal(t, uint64(6), metricCount(t, rm, "events.router.handle.duration", topic))
+}
+
+func TestTerminalError(t *testing.T) {
+	err := events.NewTerminalError(errRouterTest)
+
+	assert.True(t, events.IsTerminalError(err))
+	assert.ErrorIs(t, err, errRouterTest)
+	assert.Equal(t, "terminal: "+errRouterTest.Error(), err.Error())
+
+	err = events.NewTerminalError(nil)
+
+	assert.True(t, events.IsTerminalError(err))
+	assert.Equal(t, "terminal error", err.Error())
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := events.ExponentialBackoff(time.Second, time.Second*10)
+
+	assert.Equal(t, time.Second, backoff(0))
+	assert.Equal(t, time.Second, backoff(1))
+	assert.Equal(t, time.Second*2, backoff(2))
+	assert.Equal(t, time.Second*8, backoff(4))
+	assert.Equal(t, time.Second*10, backoff(5))
+	assert.Equal(t, time.Second*10, backoff(100))
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type deliverAtCtxKey struct{}
+
+// ContextWithDeliverAt returns a context which schedules published messages to be delivered at the provided time.
+// Scheduled messages are identified by their message id, which is returned by the published message ID method
+// and may be provided with ContextWithMessageID. Pending scheduled messages may be canceled with CancelScheduled.
+// Messages scheduled for a time which has passed are published immediately.
+//
+// The delivery time applies to every message published with the returned context and any context derived from it,
+// such as the context of work started after scheduling the message. Derive the context for the scheduled publish
+// only, or clear the delivery time for derived contexts with the zero time, which publishes messages immediately.
+func ContextWithDeliverAt(ctx context.Context, deliverAt time.Time) context.Context {
+	return context.WithValue(ctx, deliverAtCtxKey{}, deliverAt)
+}
+
+// ContextWithDelay returns a context which schedules published messages to be delivered after the provided delay.
+// The delivery time is fixed when the context is created, see ContextWithDeliverAt.
+func ContextWithDelay(ctx context.Context, delay time.Duration) context.Context {
+	return ContextWithDeliverAt(ctx, time.Now().Add(delay))
+}
+
+// DeliverAtFromContext returns the delivery time set with ContextWithDeliverAt or ContextWithDelay, or the zero time if none was set.
+func DeliverAtFromContext(ctx context.Context) time.Time {
+	deliverAt, _ := ctx.Value(deliverAtCtxKey{}).(time.Time)
+
+	return deliverAt
+}
+
+// scheduledDeliverAt returns the delivery time from the context when the message must be scheduled instead of published immediately.
+func scheduledDeliverAt(ctx context.Context) (time.Time, bool) {
+	deliverAt := DeliverAtFromContext(ctx)
+
+	return deliverAt, !deliverAt.IsZero() && time.Now().Before(deliverAt)
+}
+
+// ScheduleCanceler is implemented by connections which support canceling scheduled messages.
+type ScheduleCanceler interface {
+	// CancelScheduled cancels the pending scheduled message with the provided message id.
+	CancelScheduled(ctx context.Context, id string) error
+}
+
+// CancelScheduled cancels the pending scheduled message published with the provided message id.
+// ErrScheduledMessageNotFound is returned if the message has already been delivered, was canceled or was never scheduled.
+func CancelScheduled(ctx context.Context, conn Connection, id string) error {
+	canceler, ok := conn.(ScheduleCanceler)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrSchedulingUnsupported, conn)
+	}
+
+	return canceler.CancelScheduled(ctx, id)
+}
@@ -0,0 +1,159 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestContextWithDelay(t *testing.T) {
+	ctx := context.Background()
+
+	assert.True(t, events.DeliverAtFromContext(ctx).IsZero())
+
+	before := time.Now()
+
+	deliverAt := events.DeliverAtFromContext(events.ContextWithDelay(ctx, time.Minute))
+
+	assert.WithinRange(t, deliverAt, before.Add(time.Minute), time.Now().Add(time.Minute))
+
+	at := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, at, events.DeliverAtFromContext(events.ContextWithDeliverAt(ctx, at)))
+
+	// derived contexts clear the delivery time with the zero time.
+	scheduledCtx := events.ContextWithDeliverAt(ctx, at)
+
+	assert.True(t, events.DeliverAtFromContext(events.ContextWithDeliverAt(scheduledCtx, time.Time{})).IsZero())
+}
+
+func TestMemoryScheduledPublish(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	change := testCreateChange()
+
+	msg, err := conn.PublishChange(events.ContextWithDelay(ctx, time.Millisecond*200), "test", change)
+	require.NoError(t, err)
+	assert.NotEmpty(t, msg.ID())
+
+	canceled, err := conn.PublishChange(events.ContextWithDelay(ctx, time.Millisecond*100), "test", testCreateChange())
+	require.NoError(t, err)
+	require.NoError(t, events.CancelScheduled(ctx, conn, canceled.ID()))
+	require.ErrorIs(t, events.CancelScheduled(ctx, conn, canceled.ID()), events.ErrScheduledMessageNotFound)
+
+	_, err = getSingleMessage(messages, time.Millisecond*100)
+	require.ErrorIs(t, err, errTimeout)
+
+	receivedMsg, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, receivedMsg.Error())
+	assert.Equal(t, change.SubjectID, receivedMsg.Message().SubjectID)
+	require.NoError(t, receivedMsg.Ack())
+
+	require.ErrorIs(t, events.CancelScheduled(ctx, conn, msg.ID()), events.ErrScheduledMessageNotFound)
+
+	// the canceled message is never delivered.
+	_, err = getSingleMessage(messages, time.Millisecond*200)
+	require.ErrorIs(t, err, errTimeout)
+}
+
+func TestNATSScheduledPublish(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	natsCfg := nats.Config.NATS
+	natsCfg.QueueGroup = "testing-scheduled"
+	natsCfg.SubscriberFetchTimeout = time.Second
+	natsCfg.SubscriberFetchBackoff = time.Millisecond * 100
+
+	conn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, ">")
+	require.NoError(t, err)
+
+	change := testCreateChange()
+
+	msg, err := conn.PublishChange(events.ContextWithDelay(ctx, time.Millisecond*500), "test", change)
+	require.NoError(t, err)
+	assert.Equal(t, eventtools.Prefix+".changes.create.test", msg.Topic())
+
+	canceled, err := conn.PublishChange(events.ContextWithDelay(ctx, time.Millisecond*500), "test", testCreateChange())
+	require.NoError(t, err)
+	require.NoError(t, events.CancelScheduled(ctx, conn, canceled.ID()))
+	require.ErrorIs(t, events.CancelScheduled(ctx, conn, canceled.ID()), events.ErrScheduledMessageNotFound)
+
+	// scheduled messages are held until a scheduler delivers them.
+	_, err = getSingleMessage(messages, time.Millisecond*200)
+	require.ErrorIs(t, err, errTimeout)
+
+	schedCtx, cancel := context.WithCancel(ctx)
+
+	schedErr := make(chan error, 1)
+
+	go func() {
+		schedErr <- conn.RunScheduler(schedCtx)
+	}()
+
+	receivedMsg, err := getSingleMessage(messages, time.Second*2)
+	require.NoError(t, err)
+	require.NoError(t, receivedMsg.Error())
+	assert.Equal(t, change.SubjectID, receivedMsg.Message().SubjectID)
+	assert.Empty(t, receivedMsg.Headers().Get(events.HeaderDeliverAt))
+	assert.Empty(t, receivedMsg.Headers().Get(events.NATSHeaderScheduleTarget))
+	require.NoError(t, receivedMsg.Ack())
+
+	natsMsg, ok := receivedMsg.(*events.NATSMessage[events.ChangeMessage])
+	require.True(t, ok)
+	assert.Equal(t, msg.ID(), natsMsg.MessageID())
+
+	// delivered messages are removed from the schedule subject once published.
+	require.Eventually(t, func() bool {
+		return errors.Is(events.CancelScheduled(ctx, conn, msg.ID()), events.ErrScheduledMessageNotFound)
+	}, time.Second, time.Millisecond*10)
+
+	// the canceled message is never delivered.
+	_, err = getSingleMessage(messages, time.Second)
+	require.ErrorIs(t, err, errTimeout)
+
+	cancel()
+
+	require.NoError(t, <-schedErr)
+}
+
+func TestRedisScheduledPublishUnsupported(t *testing.T) {
+	ctx := context.Background()
+
+	redis, err := eventtools.NewRedisServer()
+	require.NoError(t, err)
+
+	defer redis.Close()
+
+	conn := newTestRedisConnection(t, redis, "")
+
+	_, err = conn.PublishChange(events.ContextWithDelay(ctx, time.Minute), "test", testCreateChange())
+	require.ErrorIs(t, err, events.ErrSchedulingUnsupported)
+
+	require.ErrorIs(t, events.CancelScheduled(ctx, conn, "unknown"), events.ErrSchedulingUnsupported)
+}
@@ -0,0 +1,247 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// DefaultSchemaRegistry is the schema registry used by connections which are not configured with a registry.
+var DefaultSchemaRegistry = NewSchemaRegistry()
+
+// Upcaster converts a payload of one schema version to the next version.
+// The payload is the decoded json object of the message.
+type Upcaster func(payload map[string]any) (map[string]any, error)
+
+// MessageSchema describes the schema of a message type.
+type MessageSchema struct {
+	// Version is the current schema version of the message type, versions start at 1.
+	// Published messages are marked with the version in the HeaderSchemaVersion header.
+	// Messages received without a version are treated as version 1.
+	Version int
+	// JSONSchema is an optional JSON Schema document payloads are validated against.
+	JSONSchema []byte
+	// SkipPublishValidation disables JSON Schema validation of published messages.
+	SkipPublishValidation bool
+	// SkipConsumeValidation disables JSON Schema validation of received messages.
+	SkipConsumeValidation bool
+	// Upcasters convert payloads of older versions before they're decoded, keyed by the version they convert from.
+	// Received payloads are upcast one version at a time until they reach the current version.
+	Upcasters map[int]Upcaster
+}
+
+// SchemaError is the error returned when a message payload does not match its schema.
+// Received messages which fail schema handling return a SchemaError from the message Error method.
+type SchemaError struct {
+	// Type is the name of the message type.
+	Type string
+	// Version is the schema version of the payload.
+	Version int
+	// Err is the cause of the error, wrapping ErrSchemaValidationFailed, ErrSchemaVersionUnsupported or ErrSchemaUpcastFailed.
+	Err error
+}
+
+// Error implements error.
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s schema version %d: %s", e.Type, e.Version, e.Err)
+}
+
+// Unwrap returns the cause of the error.
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaRegistry holds the schemas of message types.
+type SchemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[reflect.Type]*messageSchema
+}
+
+// NewSchemaRegistry creates a new empty schema registry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas: make(map[reflect.Type]*messageSchema),
+	}
+}
+
+// RegisterSchema registers the schema for messages of type T, replacing any existing schema for the type.
+func RegisterSchema[T any](registry *SchemaRegistry, schema MessageSchema) error {
+	msgType := reflect.TypeFor[T]()
+
+	compiled, err := compileSchema(msgType.String(), schema)
+	if err != nil {
+		return err
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.schemas[msgType] = compiled
+
+	return nil
+}
+
+// schemaFor returns the schema registered for messages of type T or nil if none is registered.
+func schemaFor[T any](registry *SchemaRegistry) *messageSchema {
+	if registry == nil {
+		return nil
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	return registry.schemas[reflect.TypeFor[T]()]
+}
+
+type messageSchema struct {
+	name   string
+	schema MessageSchema
+	json   *jsonschema.Schema
+}
+
+func compileSchema(name string, schema MessageSchema) (*messageSchema, error) {
+	if schema.Version < 1 {
+		return nil, fmt.Errorf("%w: %s: version must be at least 1", ErrInvalidSchema, name)
+	}
+
+	for version := range schema.Upcasters {
+		if version < 1 || version >= schema.Version {
+			return nil, fmt.Errorf("%w: %s: upcaster from version %d is not before version %d", ErrInvalidSchema, name, version, schema.Version)
+		}
+	}
+
+	compiled := &messageSchema{
+		name:   name,
+		schema: schema,
+	}
+
+	if len(schema.JSONSchema) == 0 {
+		return compiled, nil
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema.JSONSchema))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSchema, name, err)
+	}
+
+	url := "events-schema.json"
+	compiler := jsonschema.NewCompiler()
+
+	if err := compiler.AddResource(url, doc); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSchema, name, err)
+	}
+
+	compiled.json, err = compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSchema, name, err)
+	}
+
+	return compiled, nil
+}
+
+// version returns the current schema version as a header value.
+func (s *messageSchema) version() string {
+	return strconv.Itoa(s.schema.Version)
+}
+
+// validatePublish validates a message being published, data is the payload marshaled with codec.
+func (s *messageSchema) validatePublish(codec Codec, data []byte) error {
+	if s.schema.SkipPublishValidation {
+		return nil
+	}
+
+	data, err := jsonPayload(codec, data)
+	if err != nil {
+		return err
+	}
+
+	return s.validate(s.schema.Version, data)
+}
+
+// upcast converts the payload from the provided header version to the current version.
+func (s *messageSchema) upcast(headerVersion string, payload []byte) ([]byte, error) {
+	version := 1
+
+	if headerVersion != "" {
+		var err error
+
+		version, err = strconv.Atoi(headerVersion)
+		if err != nil {
+			return nil, &SchemaError{Type: s.name, Err: fmt.Errorf("%w: invalid version %q", ErrSchemaVersionUnsupported, headerVersion)}
+		}
+	}
+
+	if version < 1 || version > s.schema.Version {
+		return nil, s.error(version, fmt.Errorf("%w: current version is %d", ErrSchemaVersionUnsupported, s.schema.Version))
+	}
+
+	if version == s.schema.Version {
+		return payload, nil
+	}
+
+	var object map[string]any
+
+	if err := json.Unmarshal(payload, &object); err != nil {
+		return nil, s.error(version, fmt.Errorf("%w: %w", ErrSchemaUpcastFailed, err))
+	}
+
+	for ; version < s.schema.Version; version++ {
+		upcaster, ok := s.schema.Upcasters[version]
+		if !ok {
+			return nil, s.error(version, fmt.Errorf("%w: no upcaster from version %d", ErrSchemaVersionUnsupported, version))
+		}
+
+		var err error
+
+		object, err = upcaster(object)
+		if err != nil {
+			return nil, s.error(version, fmt.Errorf("%w: %w", ErrSchemaUpcastFailed, err))
+		}
+	}
+
+	payload, err := json.Marshal(object)
+	if err != nil {
+		return nil, s.error(version, fmt.Errorf("%w: %w", ErrSchemaUpcastFailed, err))
+	}
+
+	return payload, nil
+}
+
+// validateConsume validates a received payload which has been upcast to the current version.
+func (s *messageSchema) validateConsume(payload []byte) error {
+	if s.schema.SkipConsumeValidation {
+		return nil
+	}
+
+	return s.validate(s.schema.Version, payload)
+}
+
+func (s *messageSchema) validate(version int, data []byte) error {
+	if s.json == nil {
+		return nil
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return s.error(version, fmt.Errorf("%w: %w", ErrSchemaValidationFailed, err))
+	}
+
+	if err := s.json.Validate(instance); err != nil {
+		return s.error(version, fmt.Errorf("%w: %w", ErrSchemaValidationFailed, err))
+	}
+
+	return nil
+}
+
+func (s *messageSchema) error(version int, err error) *SchemaError {
+	return &SchemaError{
+		Type:    s.name,
+		Version: version,
+		Err:     err,
+	}
+}
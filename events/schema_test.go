@@ -0,0 +1,191 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+const testOrderJSONSchema = `{
+	"type": "object",
+	"required": ["id", "total"],
+	"properties": {
+		"id": {"type": "string"},
+		"total": {"type": "integer", "minimum": 0}
+	}
+}`
+
+// testOrderV1 is the original version of testOrder, total was named amount.
+type testOrderV1 struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+func (o testOrderV1) Validate() error { return nil }
+
+type testOrder struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+func (o testOrder) Validate() error { return nil }
+
+func TestSchemaVersioning(t *testing.T) {
+	ctx := context.Background()
+
+	v1Registry := events.NewSchemaRegistry()
+	require.NoError(t, events.RegisterSchema[testOrderV1](v1Registry, events.MessageSchema{Version: 1}))
+
+	// a future publisher which this consumer does not know how to read.
+	v3Registry := events.NewSchemaRegistry()
+	require.NoError(t, events.RegisterSchema[testOrder](v3Registry, events.MessageSchema{Version: 3}))
+
+	registry := events.NewSchemaRegistry()
+	require.NoError(t, events.RegisterSchema[testOrder](registry, events.MessageSchema{
+		Version:    2,
+		JSONSchema: []byte(testOrderJSONSchema),
+		Upcasters: map[int]events.Upcaster{
+			1: func(payload map[string]any) (map[string]any, error) {
+				payload["total"] = payload["amount"]
+				delete(payload, "amount")
+
+				return payload, nil
+			},
+		},
+	}))
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	testCases := []struct {
+		name    string
+		connect func(t *testing.T, registry *events.SchemaRegistry) events.Connection
+	}{
+		{
+			name: "nats",
+			connect: func(t *testing.T, registry *events.SchemaRegistry) events.Connection {
+				natsCfg := nats.Config.NATS
+				natsCfg.Streams = []events.NATSStreamConfig{
+					{
+						Name:     "schema-tests",
+						Subjects: []string{"orders.>"},
+					},
+				}
+
+				conn, err := events.NewNATSConnection(natsCfg, events.WithNATSSchemaRegistry(registry))
+				require.NoError(t, err)
+
+				t.Cleanup(func() { conn.Shutdown(ctx) }) //nolint:errcheck // within test
+
+				return conn
+			},
+		},
+		{
+			name: "memory",
+			connect: func(t *testing.T, registry *events.SchemaRegistry) events.Connection {
+				conn, err := events.NewMemoryConnection(events.MemoryConfig{
+					Enabled:         true,
+					Name:            "schema-tests",
+					SubscribePrefix: eventtools.Prefix,
+					PublishPrefix:   eventtools.Prefix,
+				}, events.WithMemorySchemaRegistry(registry))
+				require.NoError(t, err)
+
+				t.Cleanup(func() { conn.Shutdown(ctx) }) //nolint:errcheck // within test
+
+				return conn
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := tc.connect(t, registry)
+			v1Conn := tc.connect(t, v1Registry)
+			v3Conn := tc.connect(t, v3Registry)
+
+			messages, err := events.Subscribe[testOrder](ctx, conn, "orders", tc.name)
+			require.NoError(t, err)
+
+			// current version
+			_, err = events.Publish(ctx, conn, "orders", tc.name, testOrder{ID: "order-1", Total: 10})
+			require.NoError(t, err)
+
+			receivedMsg, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, receivedMsg.Error())
+			assert.Equal(t, testOrder{ID: "order-1", Total: 10}, receivedMsg.Message())
+			assert.Equal(t, "2", receivedMsg.Headers().Get(events.HeaderSchemaVersion))
+			require.NoError(t, receivedMsg.Ack())
+
+			// older versions are upcast
+			_, err = events.Publish(ctx, v1Conn, "orders", tc.name, testOrderV1{ID: "order-2", Amount: 20})
+			require.NoError(t, err)
+
+			receivedMsg, err = getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, receivedMsg.Error())
+			assert.Equal(t, testOrder{ID: "order-2", Total: 20}, receivedMsg.Message())
+			assert.Equal(t, "1", receivedMsg.Headers().Get(events.HeaderSchemaVersion))
+			require.NoError(t, receivedMsg.Ack())
+
+			// invalid payloads are not published
+			_, err = events.Publish(ctx, conn, "orders", tc.name, testOrder{ID: "order-3", Total: -1})
+
+			var schemaErr *events.SchemaError
+
+			require.ErrorAs(t, err, &schemaErr)
+			require.ErrorIs(t, err, events.ErrSchemaValidationFailed)
+			assert.Equal(t, 2, schemaErr.Version)
+
+			// invalid payloads are still decoded with the error surfaced
+			_, err = events.Publish(ctx, v1Conn, "orders", tc.name, testOrderV1{ID: "order-4", Amount: -1})
+			require.NoError(t, err)
+
+			receivedMsg, err = getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.ErrorAs(t, receivedMsg.Error(), &schemaErr)
+			require.ErrorIs(t, receivedMsg.Error(), events.ErrSchemaValidationFailed)
+			assert.Equal(t, testOrder{ID: "order-4", Total: -1}, receivedMsg.Message())
+			require.NoError(t, receivedMsg.Ack())
+
+			// newer versions are not supported
+			_, err = events.Publish(ctx, v3Conn, "orders", tc.name, testOrder{ID: "order-5", Total: 50})
+			require.NoError(t, err)
+
+			receivedMsg, err = getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.ErrorAs(t, receivedMsg.Error(), &schemaErr)
+			require.ErrorIs(t, receivedMsg.Error(), events.ErrSchemaVersionUnsupported)
+			assert.Equal(t, 3, schemaErr.Version)
+			require.NoError(t, receivedMsg.Ack())
+		})
+	}
+}
+
+func TestRegisterSchemaInvalid(t *testing.T) {
+	registry := events.NewSchemaRegistry()
+
+	err := events.RegisterSchema[testOrder](registry, events.MessageSchema{})
+	require.ErrorIs(t, err, events.ErrInvalidSchema)
+
+	err = events.RegisterSchema[testOrder](registry, events.MessageSchema{
+		Version:   2,
+		Upcasters: map[int]events.Upcaster{2: nil},
+	})
+	require.ErrorIs(t, err, events.ErrInvalidSchema)
+
+	err = events.RegisterSchema[testOrder](registry, events.MessageSchema{
+		Version:    1,
+		JSONSchema: []byte(`{"type": 1}`),
+	})
+	require.ErrorIs(t, err, events.ErrInvalidSchema)
+}
@@ -0,0 +1,433 @@
+package events
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+)
+
+const (
+	// HeaderSignature is the header containing the signature of a signed message.
+	HeaderSignature = "Events-Signature"
+	// HeaderSigningKey is the header containing the public nkey of the key which signed the message.
+	HeaderSigningKey = "Events-Signing-Key"
+	// HeaderEncryptionKeyID is the header containing the id of the key encrypter which wrapped the data key of an encrypted message.
+	HeaderEncryptionKeyID = "Events-Encryption-Key-ID"
+	// HeaderEncryptedKey is the header containing the wrapped data key the payload of an encrypted message is encrypted with.
+	HeaderEncryptedKey = "Events-Encrypted-Key"
+	// HeaderSignedAt is the header containing the time a signed message was signed, formatted as RFC 3339.
+	HeaderSignedAt = "Events-Signed-At"
+
+	dataKeySize = 32
+)
+
+// signedHeaders are the headers, in addition to CloudEvents attribute headers, covered by message signatures.
+var signedHeaders = []string{
+	HeaderActorID,
+	HeaderCodec,
+	HeaderContentType,
+	HeaderEncryptedKey,
+	HeaderEncryptionKeyID,
+	HeaderSchemaVersion,
+	HeaderSignedAt,
+	HeaderSource,
+}
+
+// MessageSigner signs published messages.
+// Signatures must be ed25519 signatures verifiable with the signer's public nkey.
+type MessageSigner interface {
+	// PublicKey returns the public nkey subscribers add to their trusted signing keys to verify messages.
+	PublicKey() string
+	// Sign signs the data.
+	Sign(data []byte) ([]byte, error)
+}
+
+type nkeySigner struct {
+	kp     nkeys.KeyPair
+	public string
+}
+
+func (s nkeySigner) PublicKey() string {
+	return s.public
+}
+
+func (s nkeySigner) Sign(data []byte) ([]byte, error) {
+	return s.kp.Sign(data)
+}
+
+// NewNKeySigner returns a MessageSigner signing messages with the nkey, such as a user nkey created with nkeys.FromSeed.
+func NewNKeySigner(kp nkeys.KeyPair) (MessageSigner, error) {
+	public, err := kp.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSigningKey, err)
+	}
+
+	return nkeySigner{kp: kp, public: public}, nil
+}
+
+// NewEd25519Signer returns a MessageSigner signing messages with the ed25519 key.
+// The public key subscribers trust is returned by Ed25519PublicKey.
+func NewEd25519Signer(key ed25519.PrivateKey) (MessageSigner, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%w: invalid ed25519 private key size %d", ErrInvalidSigningKey, len(key))
+	}
+
+	kp, err := nkeys.FromRawSeed(nkeys.PrefixByteUser, key.Seed())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSigningKey, err)
+	}
+
+	return NewNKeySigner(kp)
+}
+
+// Ed25519PublicKey returns the public nkey for the ed25519 public key, for use as a trusted signing key.
+func Ed25519PublicKey(key ed25519.PublicKey) (string, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("%w: invalid ed25519 public key size %d", ErrInvalidSigningKey, len(key))
+	}
+
+	public, err := nkeys.Encode(nkeys.PrefixByteUser, key)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidSigningKey, err)
+	}
+
+	return string(public), nil
+}
+
+// KeyEncrypter wraps and unwraps the data keys encrypted messages are encrypted with.
+// Each encrypted message is encrypted with a new data key, the wrapped data key is sent in the message headers.
+// Implementations may wrap keys locally or with a key management service.
+type KeyEncrypter interface {
+	// KeyID identifies the key, subscribers unwrap data keys with the KeyEncrypter with the id in the message headers.
+	KeyID() string
+	// WrapKey encrypts the data key.
+	WrapKey(dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key encrypted by WrapKey.
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+type aesKeyEncrypter struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewAESKeyEncrypter returns a KeyEncrypter wrapping data keys locally with AES-GCM.
+// The key must be 16, 24 or 32 bytes.
+func NewAESKeyEncrypter(id string, key []byte) (KeyEncrypter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEncryptionKey, err)
+	}
+
+	return aesKeyEncrypter{id: id, aead: aead}, nil
+}
+
+func (e aesKeyEncrypter) KeyID() string {
+	return e.id
+}
+
+func (e aesKeyEncrypter) WrapKey(dataKey []byte) ([]byte, error) {
+	return gcmSeal(e.aead, dataKey, nil)
+}
+
+func (e aesKeyEncrypter) UnwrapKey(wrapped []byte) ([]byte, error) {
+	return gcmOpen(e.aead, wrapped, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// gcmSeal encrypts the plaintext, authenticating the additional data, prefixing the result with a random nonce.
+func gcmSeal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// gcmOpen decrypts a ciphertext encrypted by gcmSeal with the same additional data.
+func gcmOpen(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrMessageDecryptionFailed
+	}
+
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], additionalData)
+}
+
+// validateTrustedKeys ensures each trusted signing key is a public nkey.
+func validateTrustedKeys(keys []string) error {
+	for _, key := range keys {
+		if !nkeys.IsValidPublicKey(key) {
+			return fmt.Errorf("%w: trusted key %q is not a public nkey", ErrInvalidSigningKey, key)
+		}
+	}
+
+	return nil
+}
+
+// messageSecurity signs and encrypts published messages and verifies and decrypts received messages.
+// The zero value and a nil messageSecurity leave messages unchanged.
+type messageSecurity struct {
+	signer            MessageSigner
+	trusted           map[string]nkeys.KeyPair
+	encrypter         KeyEncrypter
+	keys              map[string]KeyEncrypter
+	encryptedSubjects []string
+	maxAge            time.Duration
+	clockSkew         time.Duration
+}
+
+// newMessageSecurity validates the message security configuration, encryptedTopics are relative to the publishPrefix.
+// Messages are encrypted with the first of the keys, all keys are used to decrypt messages so keys may be rotated.
+// Signatures older than maxAge or signed further than clockSkew in the future are rejected, both zero accept signatures of any time.
+func newMessageSecurity(
+	signer MessageSigner,
+	trustedKeys []string,
+	maxAge, clockSkew time.Duration,
+	keys []KeyEncrypter,
+	publishPrefix string,
+	encryptedTopics []string,
+) (*messageSecurity, error) {
+	s := &messageSecurity{
+		signer:    signer,
+		trusted:   make(map[string]nkeys.KeyPair, len(trustedKeys)),
+		keys:      make(map[string]KeyEncrypter, len(keys)),
+		maxAge:    maxAge,
+		clockSkew: clockSkew,
+	}
+
+	for _, topic := range encryptedTopics {
+		s.encryptedSubjects = append(s.encryptedSubjects, buildSubject(publishPrefix, topic))
+	}
+
+	for _, key := range trustedKeys {
+		kp, err := nkeys.FromPublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: trusted key %q: %w", ErrInvalidSigningKey, key, err)
+		}
+
+		s.trusted[key] = kp
+	}
+
+	for _, key := range keys {
+		s.keys[key.KeyID()] = key
+	}
+
+	if len(encryptedTopics) != 0 {
+		if len(keys) == 0 {
+			return nil, ErrEncryptionKeyRequired
+		}
+
+		s.encrypter = keys[0]
+	}
+
+	return s, nil
+}
+
+// seal encrypts the payload if the subject is an encrypted subject and signs the message when a signer is configured.
+// Headers are updated with the encryption and signature headers, seal must be called once all other headers, including the message id, are set.
+// Signatures and encrypted payloads are bound to the subject and message id, so they may not be replayed under another subject or id.
+func (s *messageSecurity) seal(subject string, headers Headers, payload []byte) ([]byte, error) {
+	if s == nil {
+		return payload, nil
+	}
+
+	if s.signer != nil {
+		headers.Set(HeaderSignedAt, time.Now().UTC().Format(time.RFC3339Nano))
+	}
+
+	if s.encrypter != nil && slices.ContainsFunc(s.encryptedSubjects, func(filter string) bool { return subjectMatches(filter, subject) }) {
+		var err error
+
+		payload, err = s.encrypt(subject, headers, payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.signer != nil {
+		signature, err := s.signer.Sign(signingData(subject, headers.Get, headers.Keys(), payload))
+		if err != nil {
+			return nil, fmt.Errorf("signing message: %w", err)
+		}
+
+		headers.Set(HeaderSigningKey, s.signer.PublicKey())
+		headers.Set(HeaderSignature, base64.RawURLEncoding.EncodeToString(signature))
+	}
+
+	return payload, nil
+}
+
+func (s *messageSecurity) encrypt(subject string, headers Headers, payload []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := s.encrypter.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping data key: %w", err)
+	}
+
+	headers.Set(HeaderEncryptionKeyID, s.encrypter.KeyID())
+	headers.Set(HeaderEncryptedKey, base64.RawURLEncoding.EncodeToString(wrapped))
+
+	// the subject and signed headers are authenticated as additional data, so the payload may not be moved to another subject or message.
+	return gcmSeal(aead, payload, signingData(subject, headers.Get, headers.Keys(), nil))
+}
+
+// open verifies the message was signed by a trusted key, when trusted keys are configured, and decrypts encrypted payloads.
+// The subject is the subject the message was published to, which signatures and encrypted payloads are bound to.
+func (s *messageSecurity) open(subject string, get func(key string) string, keys []string, payload []byte) ([]byte, error) {
+	if s == nil {
+		return payload, nil
+	}
+
+	if len(s.trusted) != 0 {
+		if err := s.verify(subject, get, keys, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	keyID := get(HeaderEncryptionKeyID)
+	if keyID == "" {
+		return payload, nil
+	}
+
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrMessageDecryptionFailed, keyID)
+	}
+
+	wrapped, err := base64.RawURLEncoding.DecodeString(get(HeaderEncryptedKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMessageDecryptionFailed, err)
+	}
+
+	dataKey, err := key.UnwrapKey(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unwrapping data key: %w", ErrMessageDecryptionFailed, err)
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMessageDecryptionFailed, err)
+	}
+
+	payload, err = gcmOpen(aead, payload, signingData(subject, get, keys, nil))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMessageDecryptionFailed, err)
+	}
+
+	return payload, nil
+}
+
+func (s *messageSecurity) verify(subject string, get func(key string) string, keys []string, payload []byte) error {
+	signingKey := get(HeaderSigningKey)
+	if signingKey == "" {
+		return fmt.Errorf("%w: message is not signed", ErrMessageUnverified)
+	}
+
+	kp, ok := s.trusted[signingKey]
+	if !ok {
+		return fmt.Errorf("%w: signing key %s is not trusted", ErrMessageUnverified, signingKey)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(get(HeaderSignature))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMessageUnverified, err)
+	}
+
+	if err := kp.Verify(signingData(subject, get, keys, payload), signature); err != nil {
+		return fmt.Errorf("%w: %w", ErrMessageUnverified, err)
+	}
+
+	return s.verifySignedAt(get(HeaderSignedAt))
+}
+
+// verifySignedAt ensures the signature is not older than the max age or signed in the future, allowing for the clock skew.
+// The signed at header is covered by the signature, so it's only checked once the signature is verified.
+func (s *messageSecurity) verifySignedAt(value string) error {
+	if s.maxAge <= 0 && s.clockSkew <= 0 {
+		return nil
+	}
+
+	signedAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signed at %q: %w", ErrMessageUnverified, value, err)
+	}
+
+	now := time.Now()
+
+	if signedAt.After(now.Add(s.clockSkew)) {
+		return fmt.Errorf("%w: signed at %s is in the future", ErrMessageUnverified, value)
+	}
+
+	if s.maxAge > 0 && now.Sub(signedAt) > s.maxAge+s.clockSkew {
+		return fmt.Errorf("%w: signed at %s is older than %s", ErrMessageUnverified, value, s.maxAge)
+	}
+
+	return nil
+}
+
+// signingData returns the data a message signature covers, the subject, message id, payload and the values of the headers
+// which affect how it's decoded. Headers outside of the signed headers, such as dead letter headers, may be added after signing.
+func signingData(subject string, get func(key string) string, keys []string, payload []byte) []byte {
+	signed := make([]string, 0, len(signedHeaders))
+
+	for _, key := range keys {
+		if slices.Contains(signedHeaders, key) || strings.HasPrefix(strings.ToLower(key), CloudEventsHeaderPrefix) {
+			signed = append(signed, key)
+		}
+	}
+
+	slices.SortFunc(signed, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "subject:%s\nid:%s\n", subject, signedMessageID(get))
+
+	for _, key := range signed {
+		fmt.Fprintf(&buf, "%s:%s\n", strings.ToLower(key), get(key))
+	}
+
+	buf.WriteByte('\n')
+	buf.Write(payload)
+
+	return buf.Bytes()
+}
+
+// signedMessageID returns the id the message was published with. Messages republished without their native message id,
+// such as dead-lettered or replayed NATS messages, keep the id in the HeaderMessageID header.
+func signedMessageID(get func(key string) string) string {
+	if id := get(nats.MsgIdHdr); id != "" {
+		return id
+	}
+
+	return get(HeaderMessageID)
+}
@@ -0,0 +1,463 @@
+package events_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	nc "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nkeys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func newTestNKeySigner(t *testing.T) events.MessageSigner {
+	t.Helper()
+
+	kp, err := nkeys.CreateUser()
+	require.NoError(t, err)
+
+	signer, err := events.NewNKeySigner(kp)
+	require.NoError(t, err)
+
+	return signer
+}
+
+// recordingSigner records the data last signed by the signer.
+type recordingSigner struct {
+	events.MessageSigner
+
+	data []byte
+}
+
+func (s *recordingSigner) Sign(data []byte) ([]byte, error) {
+	s.data = data
+
+	return s.MessageSigner.Sign(data)
+}
+
+func TestNATSMessageSigning(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	nkeySigner := newTestNKeySigner(t)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	edSigner, err := events.NewEd25519Signer(edKey)
+	require.NoError(t, err)
+
+	edPublic, err := events.Ed25519PublicKey(edKey.Public().(ed25519.PublicKey))
+	require.NoError(t, err)
+	assert.Equal(t, edSigner.PublicKey(), edPublic)
+
+	consumerCfg := nats.Config.NATS
+	consumerCfg.TrustedSigningKeys = []string{nkeySigner.PublicKey(), edPublic}
+
+	consumer, err := events.NewNATSConnection(consumerCfg)
+	require.NoError(t, err)
+
+	defer consumer.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := consumer.SubscribeChanges(ctx, "create.signed")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		signer    events.MessageSigner
+		expectErr error
+	}{
+		{name: "nkey", signer: nkeySigner},
+		{name: "ed25519", signer: edSigner},
+		{name: "untrusted", signer: newTestNKeySigner(t), expectErr: events.ErrMessageUnverified},
+		{name: "unsigned", expectErr: events.ErrMessageUnverified},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var options []events.NATSOption
+
+			if tc.signer != nil {
+				options = append(options, events.WithNATSSigner(tc.signer))
+			}
+
+			publisher, err := events.NewNATSConnection(nats.Config.NATS, options...)
+			require.NoError(t, err)
+
+			defer publisher.Shutdown(ctx) //nolint:errcheck // within test
+
+			change := testCreateChange()
+
+			_, err = publisher.PublishChange(ctx, "signed", change)
+			require.NoError(t, err)
+
+			received, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+
+			defer received.Ack() //nolint:errcheck // within test
+
+			if tc.expectErr != nil {
+				require.ErrorIs(t, received.Error(), tc.expectErr)
+				assert.Empty(t, received.Message().SubjectID)
+
+				return
+			}
+
+			require.NoError(t, received.Error())
+			assert.Equal(t, tc.signer.PublicKey(), received.Headers().Get(events.HeaderSigningKey))
+			assert.Equal(t, change.SubjectID, received.Message().SubjectID)
+		})
+	}
+
+	t.Run("tampered", func(t *testing.T) {
+		publisher, err := events.NewNATSConnection(nats.Config.NATS, events.WithNATSSigner(nkeySigner))
+		require.NoError(t, err)
+
+		defer publisher.Shutdown(ctx) //nolint:errcheck // within test
+
+		msg, err := publisher.PublishChange(ctx, "signed", testCreateChange())
+		require.NoError(t, err)
+
+		received, err := getSingleMessage(messages, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, received.Error())
+		require.NoError(t, received.Ack())
+
+		forged := testCreateChange()
+		forged.SubjectID = msg.Message().SubjectID
+
+		data, err := json.Marshal(forged)
+		require.NoError(t, err)
+
+		// the original signature does not cover the forged payload.
+		header := nc.Header(received.Headers())
+		header.Del(nc.MsgIdHdr)
+
+		_, err = nats.JetStream.PublishMsg(&nc.Msg{
+			Subject: msg.Topic(),
+			Header:  header,
+			Data:    data,
+		})
+		require.NoError(t, err)
+
+		received, err = getSingleMessage(messages, time.Second)
+		require.NoError(t, err)
+		require.ErrorIs(t, received.Error(), events.ErrMessageUnverified)
+		require.NoError(t, received.Ack())
+	})
+
+	t.Run("replayed", func(t *testing.T) {
+		publisher, err := events.NewNATSConnection(nats.Config.NATS, events.WithNATSSigner(nkeySigner))
+		require.NoError(t, err)
+
+		defer publisher.Shutdown(ctx) //nolint:errcheck // within test
+
+		movedMessages, err := consumer.SubscribeChanges(ctx, "create.moved")
+		require.NoError(t, err)
+
+		msg, err := publisher.PublishChange(ctx, "signed", testCreateChange())
+		require.NoError(t, err)
+
+		received, err := getSingleMessage(messages, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, received.Error())
+		require.NoError(t, received.Ack())
+
+		original := received.Source().(jetstream.Msg)
+
+		// the signature is bound to the subject the message was published to.
+		// the id is moved out of the jetstream message id header, so the moved message is not de-duplicated.
+		moved := nc.Header(events.Headers(original.Headers()).Clone())
+		moved.Del(nc.MsgIdHdr)
+		moved.Set(events.HeaderMessageID, msg.ID())
+
+		_, err = nats.JetStream.PublishMsg(&nc.Msg{
+			Subject: nats.Config.NATS.PublishPrefix + ".changes.create.moved",
+			Header:  moved,
+			Data:    original.Data(),
+		})
+		require.NoError(t, err)
+
+		received, err = getSingleMessage(movedMessages, time.Second)
+		require.NoError(t, err)
+		require.ErrorIs(t, received.Error(), events.ErrMessageUnverified)
+		require.NoError(t, received.Ack())
+
+		// the signature is bound to the message id, so replays can't avoid de-duplication with a new id.
+		header := nc.Header(received.Headers().Clone())
+		header.Set(nc.MsgIdHdr, "replayed")
+
+		_, err = nats.JetStream.PublishMsg(&nc.Msg{
+			Subject: msg.Topic(),
+			Header:  header,
+			Data:    original.Data(),
+		})
+		require.NoError(t, err)
+
+		received, err = getSingleMessage(messages, time.Second)
+		require.NoError(t, err)
+		require.ErrorIs(t, received.Error(), events.ErrMessageUnverified)
+		require.NoError(t, received.Ack())
+	})
+}
+
+func TestNATSMessageSignatureAge(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	kp, err := nkeys.CreateUser()
+	require.NoError(t, err)
+
+	nkeySigner, err := events.NewNKeySigner(kp)
+	require.NoError(t, err)
+
+	signer := &recordingSigner{MessageSigner: nkeySigner}
+
+	consumerCfg := nats.Config.NATS
+	consumerCfg.TrustedSigningKeys = []string{signer.PublicKey()}
+	consumerCfg.SignatureMaxAge = time.Minute
+	consumerCfg.SignatureClockSkew = time.Second * 5
+
+	consumer, err := events.NewNATSConnection(consumerCfg)
+	require.NoError(t, err)
+
+	defer consumer.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := consumer.SubscribeChanges(ctx, "create.aged")
+	require.NoError(t, err)
+
+	publisher, err := events.NewNATSConnection(nats.Config.NATS, events.WithNATSSigner(signer))
+	require.NoError(t, err)
+
+	defer publisher.Shutdown(ctx) //nolint:errcheck // within test
+
+	msg, err := publisher.PublishChange(ctx, "aged", testCreateChange())
+	require.NoError(t, err)
+
+	received, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, received.Error())
+	require.NoError(t, received.Ack())
+
+	original := received.Source().(jetstream.Msg)
+
+	testCases := []struct {
+		name      string
+		signedAt  time.Time
+		expectErr error
+	}{
+		{name: "within max age", signedAt: time.Now().Add(-time.Second * 30)},
+		{name: "within clock skew", signedAt: time.Now().Add(time.Second)},
+		{name: "stale", signedAt: time.Now().Add(-time.Minute * 2), expectErr: events.ErrMessageUnverified},
+		{name: "future", signedAt: time.Now().Add(time.Minute), expectErr: events.ErrMessageUnverified},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			signedAt := tc.signedAt.UTC().Format(time.RFC3339Nano)
+
+			// the message is signed again with the signed at time replaced, so only the signed at time differs.
+			data := bytes.Replace(signer.data,
+				[]byte("events-signed-at:"+original.Headers().Get(events.HeaderSignedAt)+"\n"),
+				[]byte("events-signed-at:"+signedAt+"\n"),
+				1,
+			)
+
+			signature, err := kp.Sign(data)
+			require.NoError(t, err)
+
+			header := nc.Header(events.Headers(original.Headers()).Clone())
+			header.Del(nc.MsgIdHdr)
+			header.Set(events.HeaderMessageID, msg.ID())
+			header.Set(events.HeaderSignedAt, signedAt)
+			header.Set(events.HeaderSignature, base64.RawURLEncoding.EncodeToString(signature))
+
+			_, err = nats.JetStream.PublishMsg(&nc.Msg{
+				Subject: msg.Topic(),
+				Header:  header,
+				Data:    original.Data(),
+			})
+			require.NoError(t, err)
+
+			received, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+
+			defer received.Ack() //nolint:errcheck // within test
+
+			if tc.expectErr != nil {
+				require.ErrorIs(t, received.Error(), tc.expectErr)
+
+				return
+			}
+
+			require.NoError(t, received.Error())
+			assert.Equal(t, signedAt, received.Headers().Get(events.HeaderSignedAt))
+		})
+	}
+}
+
+func TestNATSMessageEncryptionBoundToSubject(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	key, err := events.NewAESKeyEncrypter("key", make([]byte, 32))
+	require.NoError(t, err)
+
+	cfg := nats.Config.NATS
+	cfg.EncryptedTopics = []string{"changes.>"}
+
+	conn, err := events.NewNATSConnection(cfg, events.WithNATSEncryptionKeys(key))
+	require.NoError(t, err)
+
+	defer conn.Shutdown(ctx) //nolint:errcheck // within test
+
+	messages, err := conn.SubscribeChanges(ctx, "create.>")
+	require.NoError(t, err)
+
+	_, err = conn.PublishChange(ctx, "secret", testCreateChange())
+	require.NoError(t, err)
+
+	received, err := getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, received.Error())
+	require.NoError(t, received.Ack())
+
+	original := received.Source().(jetstream.Msg)
+
+	header := nc.Header(events.Headers(original.Headers()).Clone())
+	header.Del(nc.MsgIdHdr)
+	header.Set(events.HeaderMessageID, original.Headers().Get(nc.MsgIdHdr))
+
+	// the subject is authenticated as additional data, so the payload fails to decrypt on another subject.
+	_, err = nats.JetStream.PublishMsg(&nc.Msg{
+		Subject: cfg.PublishPrefix + ".changes.create.other",
+		Header:  header,
+		Data:    original.Data(),
+	})
+	require.NoError(t, err)
+
+	received, err = getSingleMessage(messages, time.Second)
+	require.NoError(t, err)
+	require.ErrorIs(t, received.Error(), events.ErrMessageDecryptionFailed)
+	require.NoError(t, received.Ack())
+}
+
+func TestMemoryMessageEncryption(t *testing.T) {
+	ctx := context.Background()
+
+	oldKey, err := events.NewAESKeyEncrypter("old", make([]byte, 32))
+	require.NoError(t, err)
+
+	newKey, err := events.NewAESKeyEncrypter("new", []byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+
+	newConnection := func(t *testing.T, cfg events.MemoryConfig, options ...events.MemoryOption) *events.MemoryConnection {
+		t.Helper()
+
+		cfg.Enabled = true
+		cfg.Name = t.Name()
+
+		conn, err := events.NewMemoryConnection(cfg, options...)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			conn.Shutdown(ctx) //nolint:errcheck // within test
+		})
+
+		return conn
+	}
+
+	_, err = events.NewMemoryConnection(events.MemoryConfig{Enabled: true, EncryptedTopics: []string{"changes.>"}})
+	require.ErrorIs(t, err, events.ErrEncryptionKeyRequired)
+
+	// the previous key is kept to decrypt messages published before the rotation.
+	consumer := newConnection(t, events.MemoryConfig{}, events.WithMemoryEncryptionKeys(newKey, oldKey))
+	noKeys := newConnection(t, events.MemoryConfig{})
+
+	publisher := newConnection(t, events.MemoryConfig{EncryptedTopics: []string{"changes.*.secret"}}, events.WithMemoryEncryptionKeys(oldKey))
+	signingPublisher := newConnection(t, events.MemoryConfig{EncryptedTopics: []string{"changes.*.secret"}},
+		events.WithMemoryEncryptionKeys(newKey),
+		events.WithMemorySigner(newTestNKeySigner(t)),
+	)
+
+	messages, err := consumer.SubscribeChanges(ctx, "create.>")
+	require.NoError(t, err)
+
+	noKeyMessages, err := noKeys.SubscribeChanges(ctx, "create.>")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		publisher *events.MemoryConnection
+		topic     string
+		keyID     string
+	}{
+		{name: "plain topic", publisher: publisher, topic: "plain"},
+		{name: "encrypted topic", publisher: publisher, topic: "secret", keyID: "old"},
+		{name: "rotated key and signed", publisher: signingPublisher, topic: "secret", keyID: "new"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			change := testCreateChange()
+			change.SubjectFields = map[string]string{"password": "hunter2"}
+
+			_, err := tc.publisher.PublishChange(ctx, tc.topic, change)
+			require.NoError(t, err)
+
+			received, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, received.Error())
+			require.NoError(t, received.Ack())
+
+			assert.Equal(t, tc.keyID, received.Headers().Get(events.HeaderEncryptionKeyID))
+			assert.Equal(t, change.SubjectFields, received.Message().SubjectFields)
+
+			raw := received.Source().(*events.MemoryMsg).Data
+
+			noKeyReceived, err := getSingleMessage(noKeyMessages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, noKeyReceived.Ack())
+
+			if tc.keyID == "" {
+				assert.Contains(t, string(raw), "hunter2")
+				require.NoError(t, noKeyReceived.Error())
+
+				return
+			}
+
+			assert.NotContains(t, string(raw), "hunter2")
+			require.ErrorIs(t, noKeyReceived.Error(), events.ErrMessageDecryptionFailed)
+		})
+	}
+}
+
+func TestTrustedSigningKeysValidation(t *testing.T) {
+	_, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, TrustedSigningKeys: []string{"not-a-key"}})
+	require.ErrorIs(t, err, events.ErrInvalidSigningKey)
+
+	cfg := events.NATSConfig{URL: "nats://localhost", TrustedSigningKeys: []string{"not-a-key"}}
+	require.ErrorIs(t, cfg.Validate(), events.ErrInvalidSigningKey)
+}
@@ -0,0 +1,45 @@
+package events
+
+import "strings"
+
+const (
+	subjectSeparator    = "."
+	subjectWildcard     = "*"
+	subjectFullWildcard = ">"
+)
+
+// buildSubject joins the provided parts with the subject separator, prefixing the subject with prefix if set.
+func buildSubject(prefix string, parts ...string) string {
+	var subjectParts []string
+
+	if prefix != "" {
+		subjectParts = append(subjectParts, prefix)
+	}
+
+	subjectParts = append(subjectParts, parts...)
+
+	return strings.Join(subjectParts, subjectSeparator)
+}
+
+// subjectMatches reports whether subject matches the filter, supporting the nats style wildcards.
+// A '*' token matches exactly one token while a trailing '>' token matches one or more tokens.
+func subjectMatches(filter, subject string) bool {
+	filterTokens := strings.Split(filter, subjectSeparator)
+	subjectTokens := strings.Split(subject, subjectSeparator)
+
+	for i, token := range filterTokens {
+		if token == subjectFullWildcard {
+			return i == len(filterTokens)-1 && len(subjectTokens) > i
+		}
+
+		if i >= len(subjectTokens) {
+			return false
+		}
+
+		if token != subjectWildcard && token != subjectTokens[i] {
+			return false
+		}
+	}
+
+	return len(filterTokens) == len(subjectTokens)
+}
@@ -0,0 +1,128 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// processSpan is the consumer span covering the processing of a received message.
+// The span is ended by the first ack, nak or term of the message.
+type processSpan struct {
+	ctx  context.Context
+	span trace.Span
+	once sync.Once
+}
+
+// processSpanConfig describes the received message a process span is started for.
+type processSpanConfig struct {
+	system       string
+	subject      string
+	subscription string
+	group        string
+	messageID    string
+	deliveries   uint64
+	headers      Headers
+	err          error
+}
+
+// startProcessSpan starts a process span for a received message following the OpenTelemetry messaging conventions.
+// The span is parented to the producer trace context propagated in the headers, or in the message body for legacy publishers.
+// Any span on the subscription context is not used as the parent.
+func startProcessSpan(ctx context.Context, tracer trace.Tracer, cfg processSpanConfig, message any) *processSpan {
+	ctx = trace.ContextWithSpanContext(ctx, trace.SpanContext{})
+
+	propagator := otel.GetTextMapPropagator()
+
+	producerCtx := propagator.Extract(ctx, cfg.headers)
+
+	if !trace.SpanContextFromContext(producerCtx).IsValid() {
+		producerCtx = propagator.Extract(ctx, propagation.MapCarrier(messageTraceContext(message)))
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemKey.String(cfg.system),
+		semconv.MessagingOperationName("process"),
+		semconv.MessagingOperationTypeProcess,
+		semconv.MessagingDestinationName(cfg.subject),
+		semconv.MessagingDestinationSubscriptionName(cfg.subscription),
+		semconv.MessagingMessageID(cfg.messageID),
+		attribute.Int64("events.deliveries", int64(cfg.deliveries)), //nolint:gosec // deliveries will not overflow
+	}
+
+	if cfg.group != "" {
+		attrs = append(attrs, semconv.MessagingConsumerGroupName(cfg.group))
+	}
+
+	spanCtx, span := tracer.Start(producerCtx, "process "+cfg.subscription,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+
+	if cfg.err != nil {
+		span.RecordError(cfg.err)
+		span.SetStatus(codes.Error, cfg.err.Error())
+	}
+
+	return &processSpan{
+		ctx:  spanCtx,
+		span: span,
+	}
+}
+
+// context returns the process span context, messages which were not received by a subscription return a background context.
+func (s *processSpan) context() context.Context {
+	if s == nil {
+		return context.Background()
+	}
+
+	return s.ctx
+}
+
+// end records the outcome of processing and ends the span, returning err.
+// Naks and terms are recorded as errors as the message was not processed successfully.
+func (s *processSpan) end(action string, err error) error {
+	if s == nil {
+		return err
+	}
+
+	s.once.Do(func() {
+		s.span.SetAttributes(attribute.String("events.ack.action", action))
+
+		switch {
+		case err != nil:
+			s.span.RecordError(err)
+			s.span.SetStatus(codes.Error, err.Error())
+		case action == ackActionNak:
+			s.span.SetStatus(codes.Error, "message nacked")
+		case action == ackActionTerm:
+			s.span.SetStatus(codes.Error, "message terminated")
+		}
+
+		s.span.End()
+	})
+
+	return err
+}
+
+// messageTraceContext returns the trace context from the body of messages which carry one.
+func messageTraceContext(message any) map[string]string {
+	switch m := message.(type) {
+	case ChangeMessage:
+		return m.TraceContext
+	case EventMessage:
+		return m.TraceContext
+	case AuthRelationshipRequest:
+		return m.TraceContext
+	case AuthRelationshipResponse:
+		return m.TraceContext
+	default:
+		return nil
+	}
+}
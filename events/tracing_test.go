@@ -0,0 +1,115 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+func TestProcessSpans(t *testing.T) {
+	ctx, _ := testTraceContext(t)
+
+	recorder := tracetest.NewSpanRecorder()
+
+	prevProvider := otel.GetTracerProvider()
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	defer otel.SetTracerProvider(prevProvider)
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	natsConn, err := events.NewNATSConnection(nats.Config.NATS)
+	require.NoError(t, err)
+
+	defer natsConn.Shutdown(ctx) //nolint:errcheck // within test
+
+	memoryConn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, SubscribePrefix: eventtools.Prefix, PublishPrefix: eventtools.Prefix})
+	require.NoError(t, err)
+
+	defer memoryConn.Shutdown(ctx) //nolint:errcheck // within test
+
+	testCases := []struct {
+		name   string
+		system string
+		conn   events.Connection
+	}{
+		{
+			name:   "nats",
+			system: "nats",
+			conn:   natsConn,
+		},
+		{
+			name:   "memory",
+			system: "memory",
+			conn:   memoryConn,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder.Reset()
+
+			messages, err := tc.conn.SubscribeChanges(context.Background(), "*."+tc.name+".>")
+			require.NoError(t, err)
+
+			_, err = tc.conn.PublishChange(ctx, tc.name+".test", testCreateChange())
+			require.NoError(t, err)
+
+			_, err = tc.conn.PublishChange(ctx, tc.name+".test", testCreateChange())
+			require.NoError(t, err)
+
+			published := recorder.Ended()
+			require.Len(t, published, 2)
+
+			receivedMsg, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+
+			// the process span continues the producer trace.
+			processSpan := trace.SpanFromContext(receivedMsg.Context())
+			assert.Equal(t, published[0].SpanContext().TraceID(), processSpan.SpanContext().TraceID())
+			assert.True(t, processSpan.IsRecording())
+
+			require.NoError(t, receivedMsg.Ack())
+			assert.False(t, processSpan.IsRecording())
+
+			receivedMsg, err = getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, receivedMsg.Term())
+
+			ended := recorder.Ended()[len(published):]
+			require.Len(t, ended, 2)
+
+			subscription := eventtools.Prefix + ".changes.*." + tc.name + ".>"
+
+			for i, span := range ended {
+				assert.Equal(t, "process "+subscription, span.Name())
+				assert.Equal(t, trace.SpanKindConsumer, span.SpanKind())
+				assert.Equal(t, published[i].SpanContext().SpanID(), span.Parent().SpanID())
+				assert.Contains(t, span.Attributes(), attribute.String("messaging.system", tc.system))
+				assert.Contains(t, span.Attributes(), attribute.String("messaging.destination.subscription.name", subscription))
+			}
+
+			assert.Contains(t, ended[0].Attributes(), attribute.String("events.ack.action", "ack"))
+			assert.Equal(t, codes.Unset, ended[0].Status().Code)
+
+			assert.Contains(t, ended[1].Attributes(), attribute.String("events.ack.action", "term"))
+			assert.Equal(t, codes.Error, ended[1].Status().Code)
+		})
+	}
+}
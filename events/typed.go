@@ -0,0 +1,202 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// reservedFamilies are the subject tokens used by change messages, event messages, auth relationship requests and requests,
+// which may not be used as the family of custom messages.
+var reservedFamilies = []string{"auth", "changes", "events", requestSubjectToken}
+
+// Validator is implemented by messages published with Publish.
+// Validate is called before the message is published and the message is not published if an error is returned.
+type Validator interface {
+	Validate() error
+}
+
+// EventTyper may be implemented by messages published with Publish.
+// When the event type is not empty, it's included in the subject between the family and the topic,
+// matching the subjects used for change and event messages.
+type EventTyper interface {
+	GetEventType() string
+}
+
+// Publish publishes a message of a custom message family to the specified topic.
+// The subject is built the same as change and event messages, using the family in place of changes or events.
+//
+// Subscribers receive the message by calling Subscribe with the same family and message type.
+func Publish[T Validator](ctx context.Context, conn Connection, family, topic string, message T) (Message[T], error) {
+	if err := validateFamily(family); err != nil {
+		return nil, err
+	}
+
+	return runTyped[Message[T]](ctx, conn, publishOperation[T]{
+		messageFamily: family,
+		topic:         topic,
+		message:       message,
+	})
+}
+
+// Subscribe subscribes to messages of a custom message family on the provided topic.
+// Received messages are decoded into T, decoding errors are returned by the message Error method.
+// Messages are acked, naked and terminated the same as change and event messages.
+func Subscribe[T Validator](ctx context.Context, conn Connection, family, topic string) (<-chan Message[T], error) {
+	if err := validateFamily(family); err != nil {
+		return nil, err
+	}
+
+	return runTyped[<-chan Message[T]](ctx, conn, subscribeOperation[T]{
+		messageFamily: family,
+		topic:         topic,
+	})
+}
+
+// FamilyConnection may be implemented by connections which are not one of the connection providers, such as mocks,
+// to handle Publish, Subscribe and RequestReply. Each method returns the result of the calling function instantiated
+// with its message types, a Message[T], a <-chan Message[T], a Message[TResp] and a <-chan Request[TReq, TResp].
+type FamilyConnection interface {
+	PublishFamily(ctx context.Context, family, topic string, message any) (any, error)
+	SubscribeFamily(ctx context.Context, family, topic string) (any, error)
+	PublishFamilyRequest(ctx context.Context, family, topic string, request any) (any, error)
+	SubscribeFamilyRequests(ctx context.Context, family, topic string) (any, error)
+}
+
+// typedProvider is implemented by the connection providers supporting messages of custom families.
+// Connections wrapping a provider by embedding it implement the interface with the promoted method.
+type typedProvider interface {
+	runTyped(ctx context.Context, op typedOperation) (any, error)
+}
+
+// typedOperation is an operation instantiated with its message types, run with the concrete connection.
+// Generic functions may not be methods, so the provider calls back into the operation with itself.
+type typedOperation interface {
+	nats(ctx context.Context, c *NATSConnection) (any, error)
+	memory(ctx context.Context, c *MemoryConnection) (any, error)
+	redis(ctx context.Context, c *RedisConnection) (any, error)
+	familyConnection(ctx context.Context, c FamilyConnection) (any, error)
+}
+
+// supportsTyped reports whether the connection supports messages of custom families.
+func supportsTyped(conn Connection) bool {
+	switch conn.(type) {
+	case typedProvider, FamilyConnection:
+		return true
+	default:
+		return false
+	}
+}
+
+// runTyped runs the operation with the connection, returning the result as the type instantiated by the operation.
+func runTyped[R any](ctx context.Context, conn Connection, op typedOperation) (R, error) {
+	var (
+		result any
+		err    error
+		typed  R
+	)
+
+	switch c := conn.(type) {
+	case typedProvider:
+		result, err = c.runTyped(ctx, op)
+	case FamilyConnection:
+		result, err = op.familyConnection(ctx, c)
+	default:
+		return typed, fmt.Errorf("%w: %T", ErrUnsupportedConnection, conn)
+	}
+
+	if result == nil {
+		return typed, err
+	}
+
+	typed, ok := result.(R)
+	if !ok {
+		return typed, fmt.Errorf("%w: %T returned %T, expected %T", ErrUnsupportedConnection, conn, result, typed)
+	}
+
+	return typed, err
+}
+
+type publishOperation[T Validator] struct {
+	messageFamily string
+	topic         string
+	message       T
+}
+
+func (o publishOperation[T]) nats(ctx context.Context, c *NATSConnection) (any, error) {
+	return natsPublish(ctx, c, o.messageFamily, o.topic, o.message)
+}
+
+func (o publishOperation[T]) memory(ctx context.Context, c *MemoryConnection) (any, error) {
+	return memoryPublish(ctx, c, o.messageFamily, o.topic, o.message)
+}
+
+func (o publishOperation[T]) redis(ctx context.Context, c *RedisConnection) (any, error) {
+	return redisPublish(ctx, c, o.messageFamily, o.topic, o.message)
+}
+
+func (o publishOperation[T]) familyConnection(ctx context.Context, c FamilyConnection) (any, error) {
+	if err := o.message.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c.PublishFamily(ctx, o.messageFamily, o.topic, o.message)
+}
+
+type subscribeOperation[T any] struct {
+	messageFamily string
+	topic         string
+}
+
+func (o subscribeOperation[T]) nats(ctx context.Context, c *NATSConnection) (any, error) {
+	return natsSubscribe[T](ctx, c, o.messageFamily, o.topic)
+}
+
+func (o subscribeOperation[T]) memory(ctx context.Context, c *MemoryConnection) (any, error) {
+	return memorySubscribe[T](ctx, c, o.messageFamily, o.topic)
+}
+
+func (o subscribeOperation[T]) redis(ctx context.Context, c *RedisConnection) (any, error) {
+	return redisSubscribe[T](ctx, c, o.messageFamily, o.topic)
+}
+
+func (o subscribeOperation[T]) familyConnection(ctx context.Context, c FamilyConnection) (any, error) {
+	return c.SubscribeFamily(ctx, o.messageFamily, o.topic)
+}
+
+// validateFamily ensures the family is a single subject token which is not reserved for the built in messages.
+func validateFamily(family string) error {
+	if family == "" || strings.ContainsAny(family, subjectSeparator+subjectWildcard+subjectFullWildcard+" ") {
+		return fmt.Errorf("%w: %q", ErrInvalidMessageFamily, family)
+	}
+
+	if slices.Contains(reservedFamilies, family) {
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidMessageFamily, family)
+	}
+
+	return nil
+}
+
+// typedSubjectParts returns the subject parts for a message of the provided family.
+func typedSubjectParts(family, topic string, message any) []string {
+	if eventType := messageEventType(message); eventType != "" {
+		return []string{family, eventType, topic}
+	}
+
+	return []string{family, topic}
+}
+
+// requestSubjectParts returns the subject parts for a request of the provided family.
+func requestSubjectParts(family, topic string, message any) []string {
+	return append([]string{requestSubjectToken}, typedSubjectParts(family, topic, message)...)
+}
+
+// messageEventType returns the event type of messages implementing EventTyper.
+func messageEventType(message any) string {
+	if typer, ok := message.(EventTyper); ok {
+		return typer.GetEventType()
+	}
+
+	return ""
+}
@@ -0,0 +1,178 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.infratographer.com/x/events"
+	"go.infratographer.com/x/testing/eventtools"
+)
+
+var errMissingInvoiceID = errors.New("invoice id required")
+
+type testInvoice struct {
+	ID        string `json:"id"`
+	EventType string `json:"eventType"`
+	Amount    int    `json:"amount"`
+}
+
+func (i testInvoice) Validate() error {
+	if i.ID == "" {
+		return errMissingInvoiceID
+	}
+
+	return nil
+}
+
+func (i testInvoice) GetEventType() string {
+	return i.EventType
+}
+
+func TestTypedPublishAndSubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	nats, err := eventtools.NewNatsServer()
+	require.NoError(t, err)
+
+	defer nats.Close()
+
+	natsCfg := nats.Config.NATS
+	natsCfg.Streams = []events.NATSStreamConfig{
+		{
+			Name:     "typed-tests",
+			Subjects: []string{"invoices.>"},
+		},
+	}
+
+	natsConn, err := events.NewNATSConnection(natsCfg)
+	require.NoError(t, err)
+
+	defer natsConn.Shutdown(ctx) //nolint:errcheck // within test
+
+	memoryConn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true, SubscribePrefix: eventtools.Prefix, PublishPrefix: eventtools.Prefix})
+	require.NoError(t, err)
+
+	defer memoryConn.Shutdown(ctx) //nolint:errcheck // within test
+
+	testCases := []struct {
+		name string
+		conn events.Connection
+	}{
+		{
+			name: "nats",
+			conn: natsConn,
+		},
+		{
+			name: "memory",
+			conn: memoryConn,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			messages, err := events.Subscribe[testInvoice](ctx, tc.conn, "invoices", "*.billing")
+			require.NoError(t, err)
+
+			invoice := testInvoice{ID: "inv-1", EventType: "paid", Amount: 100}
+
+			msg, err := events.Publish(ctx, tc.conn, "invoices", "billing", invoice)
+			require.NoError(t, err)
+			assert.Equal(t, invoice, msg.Message())
+
+			receivedMsg, err := getSingleMessage(messages, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, receivedMsg.Error())
+
+			assert.Equal(t, invoice, receivedMsg.Message())
+			assert.Equal(t, eventtools.Prefix+".invoices.paid.billing", receivedMsg.Topic())
+
+			require.NoError(t, receivedMsg.Ack())
+
+			_, err = events.Publish(ctx, tc.conn, "invoices", "billing", testInvoice{})
+			require.ErrorIs(t, err, errMissingInvoiceID)
+
+			_, err = events.Publish(ctx, tc.conn, "invoices.>", "billing", invoice)
+			require.ErrorIs(t, err, events.ErrInvalidMessageFamily)
+
+			for _, family := range []string{"auth", "changes", "events", "requests"} {
+				_, err = events.Publish(ctx, tc.conn, family, "billing", invoice)
+				require.ErrorIs(t, err, events.ErrInvalidMessageFamily, "family %s is reserved", family)
+			}
+		})
+	}
+}
+
+// wrappedConnection wraps a connection provider by embedding it.
+type wrappedConnection struct {
+	*events.MemoryConnection
+}
+
+func TestTypedConnections(t *testing.T) {
+	ctx := context.Background()
+
+	memoryConn, err := events.NewMemoryConnection(events.MemoryConfig{Enabled: true})
+	require.NoError(t, err)
+
+	defer memoryConn.Shutdown(ctx) //nolint:errcheck // within test
+
+	invoice := testInvoice{ID: "inv-1", Amount: 100}
+
+	t.Run("wrapped provider", func(t *testing.T) {
+		conn := wrappedConnection{memoryConn}
+
+		messages, err := events.Subscribe[testInvoice](ctx, conn, "invoices", ">")
+		require.NoError(t, err)
+
+		_, err = events.Publish(ctx, conn, "invoices", "billing", invoice)
+		require.NoError(t, err)
+
+		receivedMsg, err := getSingleMessage(messages, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, invoice, receivedMsg.Message())
+		require.NoError(t, receivedMsg.Ack())
+	})
+
+	t.Run("mock", func(t *testing.T) {
+		conn := &eventtools.MockConnection{}
+
+		published := &eventtools.MockMessage[testInvoice]{}
+		messages := make(chan events.Message[testInvoice])
+
+		conn.On("PublishFamily", "invoices", "billing", invoice).Return(events.Message[testInvoice](published), nil)
+		conn.On("SubscribeFamily", "invoices", ">").Return((<-chan events.Message[testInvoice])(messages), nil)
+
+		msg, err := events.Publish(ctx, conn, "invoices", "billing", invoice)
+		require.NoError(t, err)
+		assert.Same(t, published, msg)
+
+		subscribed, err := events.Subscribe[testInvoice](ctx, conn, "invoices", ">")
+		require.NoError(t, err)
+		assert.Equal(t, (<-chan events.Message[testInvoice])(messages), subscribed)
+
+		// messages are validated before they reach the mock.
+		_, err = events.Publish(ctx, conn, "invoices", "billing", testInvoice{})
+		require.ErrorIs(t, err, errMissingInvoiceID)
+
+		// a mock returning a result of another type fails instead of panicking.
+		_, err = events.Subscribe[testCommand](ctx, conn, "invoices", ">")
+		require.ErrorIs(t, err, events.ErrUnsupportedConnection)
+
+		conn.AssertExpectations(t)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		// wrapping the Connection interface does not expose the provider.
+		conn := struct{ events.Connection }{memoryConn}
+
+		_, err := events.Subscribe[testInvoice](ctx, conn, "invoices", ">")
+		require.ErrorIs(t, err, events.ErrUnsupportedConnection)
+
+		_, err = events.NewRequestReply[testCommand, testCommandResult](conn, "commands")
+		require.ErrorIs(t, err, events.ErrUnsupportedConnection)
+	})
+}
@@ -8,7 +8,10 @@ import (
 	"go.infratographer.com/x/events"
 )
 
-var _ events.Connection = (*MockConnection)(nil)
+var (
+	_ events.Connection       = (*MockConnection)(nil)
+	_ events.FamilyConnection = (*MockConnection)(nil)
+)
 
 // MockConnection implements events.Connection
 type MockConnection struct {
@@ -22,6 +25,13 @@ func (c *MockConnection) Shutdown(_ context.Context) error {
 	return args.Error(0)
 }
 
+// HealthCheck implements events.Connection
+func (c *MockConnection) HealthCheck(_ context.Context) error {
+	args := c.Called()
+
+	return args.Error(0)
+}
+
 // PublishAuthRelationshipRequest implements events.Connection
 func (c *MockConnection) PublishAuthRelationshipRequest(_ context.Context, topic string, message events.AuthRelationshipRequest) (events.Message[events.AuthRelationshipResponse], error) {
 	args := c.Called(topic, message)
@@ -70,3 +80,35 @@ func (c *MockConnection) SubscribeEvents(_ context.Context, topic string) (<-cha
 
 	return args.Get(0).(<-chan events.Message[events.EventMessage]), args.Error(1)
 }
+
+// PublishFamily implements events.FamilyConnection
+// The returned message must be an events.Message of the published message type.
+func (c *MockConnection) PublishFamily(_ context.Context, family, topic string, message any) (any, error) {
+	args := c.Called(family, topic, message)
+
+	return args.Get(0), args.Error(1)
+}
+
+// SubscribeFamily implements events.FamilyConnection
+// The returned channel must be a receive only channel of events.Message of the subscribed message type.
+func (c *MockConnection) SubscribeFamily(_ context.Context, family, topic string) (any, error) {
+	args := c.Called(family, topic)
+
+	return args.Get(0), args.Error(1)
+}
+
+// PublishFamilyRequest implements events.FamilyConnection
+// The returned message must be an events.Message of the response type.
+func (c *MockConnection) PublishFamilyRequest(_ context.Context, family, topic string, request any) (any, error) {
+	args := c.Called(family, topic, request)
+
+	return args.Get(0), args.Error(1)
+}
+
+// SubscribeFamilyRequests implements events.FamilyConnection
+// The returned channel must be a receive only channel of events.Request of the request and response types.
+func (c *MockConnection) SubscribeFamilyRequests(_ context.Context, family, topic string) (any, error) {
+	args := c.Called(family, topic)
+
+	return args.Get(0), args.Error(1)
+}
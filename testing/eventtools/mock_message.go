@@ -44,6 +44,13 @@ func (m *MockMessage[T]) Message() T {
 	return args.Get(0).(T)
 }
 
+// Headers implements events.Message.
+func (m *MockMessage[T]) Headers() events.Headers {
+	args := m.Called()
+
+	return args.Get(0).(events.Headers)
+}
+
 // Ack implements events.Message.
 func (m *MockMessage[T]) Ack() error {
 	args := m.Called()
@@ -65,6 +72,20 @@ func (m *MockMessage[T]) Term() error {
 	return args.Error(0)
 }
 
+// InProgress implements events.Message.
+func (m *MockMessage[T]) InProgress() error {
+	args := m.Called()
+
+	return args.Error(0)
+}
+
+// Context implements events.Message.
+func (m *MockMessage[T]) Context() context.Context {
+	args := m.Called()
+
+	return args.Get(0).(context.Context)
+}
+
 // Timestamp implements events.Message.
 func (m *MockMessage[T]) Timestamp() time.Time {
 	args := m.Called()
@@ -36,7 +36,7 @@ var (
 	// Prefix to use when creating the nats server jetstream subjects
 	Prefix = "com.infratographer.testing"
 	// Subjects to create in jetstream
-	Subjects = []string{Prefix + ".events.>", Prefix + ".changes.>"}
+	Subjects = []string{Prefix + ".events.>", Prefix + ".changes.>", Prefix + "." + events.NATSDefaultDeadLetterSubject + ".>", Prefix + "." + events.NATSDefaultScheduleSubject + ".>"}
 
 	// ErrNack is returned if a nack is received instead of an ack
 	ErrNack = errors.New("nack received")
@@ -0,0 +1,37 @@
+package eventtools
+
+import (
+	"github.com/alicebob/miniredis/v2"
+
+	"go.infratographer.com/x/events"
+)
+
+// TestRedis maintains the in-process redis environment
+type TestRedis struct {
+	Server *miniredis.Miniredis
+	Config events.Config
+}
+
+// Close stops the redis server
+func (s *TestRedis) Close() {
+	s.Server.Close()
+}
+
+// NewRedisServer returns an in-process redis server, backed by miniredis, configured for the Redis Streams provider.
+func NewRedisServer() (*TestRedis, error) {
+	s, err := miniredis.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TestRedis{
+		Server: s,
+		Config: events.Config{
+			Redis: events.RedisConfig{
+				URL:             "redis://" + s.Addr(),
+				SubscribePrefix: Prefix,
+				PublishPrefix:   Prefix,
+			},
+		},
+	}, nil
+}